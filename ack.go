@@ -0,0 +1,127 @@
+package tailf
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Line is a line delivered by an Acker, without its trailing newline.
+type Line struct {
+	Bytes []byte
+	// Offset is the offset of the line in the file it was read from.
+	Offset int64
+	// Token acknowledges the line once it has been processed.
+	Token Token
+	// Labels are the labels of the follower, set with WithLabels.
+	Labels Labels
+}
+
+// Token identifies a line delivered by an Acker.
+type Token uint64
+
+// Acker delivers the lines of a Follower for at-least-once processing.
+// The position of the Acker only advances past a line once it and every
+// line before it were acknowledged, so that resuming from its cursor after
+// a crash delivers again whatever wasn't fully processed. A Checkpointer
+// given the follower saves that position instead of the follower's own.
+type Acker struct {
+	f *Follower
+
+	buf      []byte
+	chunk    []byte // unread part of buf
+	chunkEnd Cursor // cursor right after chunk
+	line     []byte // partial line read so far
+
+	mu      sync.Mutex
+	next    Token
+	pending []pendingLine // delivered lines, oldest first
+	durable Cursor
+}
+
+type pendingLine struct {
+	end   Cursor
+	acked bool
+}
+
+// NewAcker returns an Acker delivering the lines of f, which shouldn't be
+// read from otherwise.
+func NewAcker(f *Follower) *Acker {
+	a := &Acker{
+		f:       f,
+		buf:     make([]byte, 32*1024),
+		durable: f.Cursor(),
+	}
+	f.mu.Lock()
+	f.acker = a
+	f.mu.Unlock()
+	return a
+}
+
+// ReadLine blocks until a complete line is available and returns it. Once
+// the follower is closed and drained, it returns io.EOF and a trailing
+// incomplete line is dropped, to be delivered in full after resuming.
+func (a *Acker) ReadLine() (Line, error) {
+	for {
+		if i := bytes.IndexByte(a.chunk, '\n'); i >= 0 {
+			a.line = append(a.line, a.chunk[:i]...)
+			a.chunk = a.chunk[i+1:]
+			end := a.chunkEnd
+			end.Offset -= int64(len(a.chunk))
+
+			off := end.Offset - int64(len(a.line)) - 1
+			if off < 0 {
+				// the line started in the previous file
+				off = 0
+			}
+			line := Line{Bytes: a.line, Offset: off, Token: a.deliver(end), Labels: a.f.opts.labels}
+			a.line = nil
+			return line, nil
+		}
+		a.line = append(a.line, a.chunk...)
+
+		n, err := a.f.read(a.buf, &a.chunkEnd)
+		a.chunk = a.buf[:n]
+		if err != nil {
+			return Line{}, err
+		}
+	}
+}
+
+func (a *Acker) deliver(end Cursor) Token {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	t := a.next + Token(len(a.pending))
+	a.pending = append(a.pending, pendingLine{end: end})
+	return t
+}
+
+// Ack acknowledges that the line with token t was processed.
+func (a *Acker) Ack(t Token) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if t < a.next || t >= a.next+Token(len(a.pending)) {
+		return
+	}
+	a.pending[t-a.next].acked = true
+
+	for len(a.pending) != 0 && a.pending[0].acked {
+		a.durable = a.pending[0].end
+		a.pending = a.pending[1:]
+		a.next++
+	}
+}
+
+// Cursor returns the position after the last line that was acknowledged
+// along with every line before it.
+func (a *Acker) Cursor() Cursor {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.durable
+}
+
+// Unacked returns how many delivered lines are waiting to be acknowledged.
+func (a *Acker) Unacked() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.pending)
+}
@@ -0,0 +1,82 @@
+package tailf_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestAckerOnlyAdvancesOnContiguousAcks(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("one\ntwo\nthree\nfour"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+		acker := tailf.NewAcker(follow)
+
+		var lines []tailf.Line
+		for i, want := range []string{"one", "two", "three"} {
+			line, err := acker.ReadLine()
+			if err != nil {
+				return err
+			}
+			if got := string(line.Bytes); got != want {
+				t.Errorf("wanted line '%v', got '%v'", want, got)
+			}
+			if wantOff := []int64{0, 4, 8}[i]; line.Offset != wantOff {
+				t.Errorf("wanted line '%v' at offset %d, got %d", want, wantOff, line.Offset)
+			}
+			lines = append(lines, line)
+		}
+
+		steps := []struct {
+			ack  int
+			want int64
+		}{
+			{ack: 0, want: 4},
+			{ack: 2, want: 4},
+			{ack: 1, want: 14},
+		}
+		for _, step := range steps {
+			acker.Ack(lines[step.ack].Token)
+			if got := acker.Cursor().Offset; got != step.want {
+				t.Errorf("after acking line %d, wanted offset %d, got %d", step.ack, step.want, got)
+			}
+		}
+		if n := acker.Unacked(); n != 0 {
+			t.Errorf("wanted all lines acked, %d are not", n)
+		}
+		return nil
+	})
+}
+
+func TestAckerLabels(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("one\n"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true, tailf.WithLabels(tailf.Labels{"service": "api"}))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		line, err := tailf.NewAcker(follow).ReadLine()
+		if err != nil {
+			return err
+		}
+		if got := line.Labels["service"]; got != "api" {
+			t.Errorf("wanted the line labeled service=api, got service=%q", got)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,85 @@
+// Package tailfafero follows files of an afero.Fs, such as the in-memory
+// filesystems applications use to be tested without touching the disk.
+package tailfafero
+
+import (
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/aybabtme/tailf"
+	"github.com/spf13/afero"
+	"github.com/spf13/afero/mem"
+)
+
+// WithFs follows files of afs instead of the OS filesystem, by their path
+// in afs, as tailf.WithFS does for an fs.FS. Unlike with afero.NewIOFS,
+// paths don't need to be relative.
+func WithFs(afs afero.Fs) tailf.Option {
+	return tailf.WithFS(aferoFS{afs})
+}
+
+// aferoFS is an fs.FS opening files of an afero.Fs, which implement
+// tailf.File.
+type aferoFS struct {
+	afs afero.Fs
+}
+
+func (a aferoFS) Open(name string) (fs.File, error) {
+	f, err := a.afs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return file{f}, nil
+}
+
+func (a aferoFS) Stat(name string) (fs.FileInfo, error) {
+	return a.afs.Stat(name)
+}
+
+// SameFile tells if fi1 and fi2 describe the same file, which the files
+// of in-memory filesystems do if they hold the same data, and OS files if
+// os.SameFile says so.
+func (a aferoFS) SameFile(fi1, fi2 fs.FileInfo) bool {
+	m1, ok1 := fi1.(*mem.FileInfo)
+	m2, ok2 := fi2.(*mem.FileInfo)
+	if ok1 && ok2 {
+		return m1.FileData == m2.FileData
+	}
+	return os.SameFile(fi1, fi2)
+}
+
+func (a aferoFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	infos, err := afero.ReadDir(a.afs, name)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fs.DirEntry, len(infos))
+	for i, fi := range infos {
+		entries[i] = fs.FileInfoToDirEntry(fi)
+	}
+	return entries, nil
+}
+
+// file is a file of an afero.Fs. The files of its in-memory filesystems
+// fail with io.ErrUnexpectedEOF when read past their end, as they are
+// once truncated, which is reported as io.EOF, as OS files do.
+type file struct {
+	afero.File
+}
+
+func (f file) Read(b []byte) (int, error) {
+	n, err := f.File.Read(b)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f file) ReadAt(b []byte, off int64) (int, error) {
+	n, err := f.File.ReadAt(b, off)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
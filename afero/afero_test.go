@@ -0,0 +1,97 @@
+package tailfafero_test
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	tailfafero "github.com/aybabtme/tailf/afero"
+	"github.com/aybabtme/tailf/tailftest"
+	"github.com/spf13/afero"
+)
+
+func TestWithFs(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	if err := afero.WriteFile(afs, "/var/log/a.log", []byte("hello,"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	follow, err := tailf.Follow("/var/log/a.log", true, tailfafero.WithFs(afs), tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+
+	if err := afs.Rename("/var/log/a.log", "/var/log/a.log.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(afs, "/var/log/a.log", []byte(" world!"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "hello, world!"
+	got := make([]byte, len(want))
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(follow, got)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wanted the rotation to be followed")
+	}
+	if string(got) != want {
+		t.Errorf("wanted %q, got %q", want, got)
+	}
+}
+
+func TestBackend(t *testing.T) {
+	afs := afero.NewMemMapFs()
+	tailftest.RunBackendTests(t, backend{afs}, tailfafero.WithFs(afs))
+}
+
+// backend changes the files of an afero.Fs for tailftest.RunBackendTests.
+type backend struct {
+	afs afero.Fs
+}
+
+func (b backend) Append(name, s string) {
+	file, err := b.afs.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(s); err != nil {
+		panic(err)
+	}
+}
+
+func (b backend) Rotate(name, rotated string) {
+	if err := b.afs.Rename(name, rotated); err != nil {
+		panic(err)
+	}
+	b.Append(name, "")
+}
+
+func (b backend) Truncate(name string) {
+	file, err := b.afs.OpenFile(name, os.O_WRONLY, 0644)
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+	if err := file.Truncate(0); err != nil {
+		panic(err)
+	}
+}
+
+func (b backend) Remove(name string) {
+	if err := b.afs.Remove(name); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,192 @@
+package tailf
+
+import (
+	"os"
+	"sort"
+	"sync"
+)
+
+// BacklogOrder is the order in which a FollowerSet forwards the data of
+// the files that have a backlog, such as when it starts following files
+// from their start, so that the data that matters most reaches consumers
+// first.
+type BacklogOrder int
+
+const (
+	// BacklogAny forwards the data of the files as it is read, in no
+	// particular order.
+	BacklogAny BacklogOrder = iota
+	// BacklogOldestFirst forwards the backlog of the files modified the
+	// longest ago first, one file after the other.
+	BacklogOldestFirst
+	// BacklogLargestFirst forwards the largest backlog first, one file
+	// after the other.
+	BacklogLargestFirst
+	// BacklogRoundRobin forwards an event of each file with a backlog in
+	// turn, so that they all catch up together.
+	BacklogRoundRobin
+)
+
+// SetBacklogOrder sets the order in which the data of the files that have
+// a backlog when their events start being forwarded is forwarded on the
+// Events channel, until they caught up. It applies to the followers that
+// stream their data, as set by WithEventStream, from the next call to
+// Events, or to Add once it was called. The files without a backlog are
+// forwarded meanwhile.
+//
+// A file caught up once the data it had when it joined was forwarded,
+// so that a file written to faster than it is consumed doesn't hold the
+// others back. Only data waits for its turn: the other events of a file,
+// such as errors and rotations, are forwarded as soon as the data before
+// them was.
+func (s *FollowerSet) SetBacklogOrder(order BacklogOrder) {
+	s.backlog.mu.Lock()
+	defer s.backlog.mu.Unlock()
+	s.backlog.order = order
+}
+
+// backlog holds back the events of the files catching up on their backlog
+// until their turn comes.
+type backlog struct {
+	mu      sync.Mutex
+	order   BacklogOrder
+	turn    *sync.Cond // broadcast when the file whose turn it is changed
+	queue   []behind   // files catching up, the first one's turn first
+	started bool       // whether the first file of the queue forwarded yet
+}
+
+// behind is a file catching up.
+type behind struct {
+	path   string
+	key    int64 // the smallest key goes first
+	target int64 // the end of the file when it joined
+}
+
+// join queues the file of f at path if it has a backlog to catch up on.
+func (b *backlog) join(path string, f *Follower) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.order == BacklogAny || !f.opts.eventStream {
+		return
+	}
+	if caughtUp(f) {
+		return
+	}
+	e := behind{path: path, target: fileEnd(f)}
+	switch b.order {
+	case BacklogOldestFirst:
+		if fi, err := os.Stat(path); err == nil {
+			e.key = fi.ModTime().UnixNano()
+		}
+	case BacklogLargestFirst:
+		lag, _ := f.Lag()
+		e.key = -lag
+	case BacklogRoundRobin:
+		b.queue = append(b.queue, e)
+		return
+	}
+	// the file whose turn it is finishes catching up first, once it
+	// started
+	i := sort.Search(len(b.queue), func(i int) bool {
+		return (i != 0 || !b.started) && b.queue[i].key > e.key
+	})
+	b.queue = append(b.queue, behind{})
+	copy(b.queue[i+1:], b.queue[i:])
+	b.queue[i] = e
+}
+
+// wait waits for the turn of the file at path, if it is catching up.
+func (b *backlog) wait(path string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.index(path) > 0 {
+		b.turn.Wait()
+	}
+	if b.index(path) == 0 {
+		b.started = true
+	}
+}
+
+// forwarded moves on to the next turn once ev, an event of f at path, was
+// forwarded, and lets f go once it caught up.
+func (b *backlog) forwarded(path string, f *Follower, ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	i := b.index(path)
+	if i < 0 {
+		return
+	}
+	chunk, data := ev.(DataChunk)
+	switch {
+	case data && chunk.Offset+int64(len(chunk.Bytes)) >= b.queue[i].target,
+		!data && reached(ev), caughtUp(f):
+		b.remove(i)
+	case data && i == 0 && b.order == BacklogRoundRobin:
+		e := b.queue[0]
+		b.remove(0)
+		b.queue = append(b.queue, e)
+	default:
+		return
+	}
+	b.turn.Broadcast()
+}
+
+// reached tells if ev ends the catching up of a file, as when it moved
+// on to a new file.
+func reached(ev Event) bool {
+	switch ev.(type) {
+	case Rotated, Truncated, Skipped:
+		return true
+	}
+	return false
+}
+
+// leave lets the file at path go, once its events stopped.
+func (b *backlog) leave(path string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if i := b.index(path); i >= 0 {
+		b.remove(i)
+		b.turn.Broadcast()
+	}
+}
+
+// index returns the position of the file at path in the queue, or -1 if it
+// isn't catching up. The backlog must be locked.
+func (b *backlog) index(path string) int {
+	for i, e := range b.queue {
+		if e.path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// remove removes the i-th file of the queue. The backlog must be locked.
+func (b *backlog) remove(i int) {
+	b.queue = append(b.queue[:i], b.queue[i+1:]...)
+	if i == 0 {
+		b.started = false
+	}
+}
+
+// fileEnd returns the size of the file f reads, or 0 if it can't be told.
+func fileEnd(f *Follower) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.released {
+		return 0
+	}
+	fi, err := f.file.Stat()
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
+
+// caughtUp tells if f was read to the end of its file, and its events
+// were all forwarded.
+func caughtUp(f *Follower) bool {
+	lag, err := f.Lag()
+	return err != nil || lag == 0 && len(f.Events()) == 0
+}
@@ -0,0 +1,146 @@
+package tailf
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	// batchReadSize is the size of the buffers ReadBatch reads into.
+	batchReadSize = 64 * 1024
+	// maxBatchBuffers is how many buffers ReadBatch fills at most with a
+	// single vectored read, while catching up.
+	maxBatchBuffers = 16
+)
+
+// batchReader holds what ReadBatch read past the lines it returned.
+type batchReader struct {
+	mu sync.Mutex
+	// lines splits the data read in lines.
+	lines Splitter
+	// buffers is how many buffers to fill on the next vectored read. It
+	// grows as long as they all get filled, that is while catching up.
+	buffers int
+}
+
+// ReadBatch blocks until at least one complete line is available, and
+// returns up to max lines, without their trailing newline. Lines read at
+// once mostly share the buffers they were read into, which saves per
+// line overhead for high throughput consumers. Once the follower is
+// closed and drained, a trailing incomplete line is returned, then
+// io.EOF. A line left incomplete at the end of a rotated or truncated
+// file is returned on its own, rather than joined with the first line of
+// the file read next.
+//
+// While catching up with a backlog, several buffers are filled with a
+// single vectored read where the platform supports it.
+//
+// ReadBatch shouldn't be mixed with other ways of reading the follower.
+func (f *Follower) ReadBatch(max int) ([][]byte, error) {
+	b := &f.batch
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var lines [][]byte
+	for {
+		lines = b.lines.Lines(lines, max)
+		if len(lines) != 0 {
+			return lines, nil
+		}
+
+		buf := make([]byte, batchReadSize)
+		if f.readBatchDirect(buf) {
+			continue
+		}
+		crossings := f.crossings()
+		n, err := f.read(buf, nil)
+		if f.crossings() != crossings {
+			b.lines.Boundary()
+		}
+		b.lines.Add(buf[:n])
+		if err == io.EOF && b.lines.Len() != 0 {
+			if lines = b.lines.Lines(lines, max); len(lines) == 0 {
+				lines = append(lines, b.lines.Flush())
+			}
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// crossings returns how many times the reader moved on from a rotated or
+// truncated file.
+func (f *Follower) crossings() int64 {
+	f.statsmu.Lock()
+	defer f.statsmu.Unlock()
+	return f.stats.Rotations + f.stats.Truncations
+}
+
+// readBatchDirect fills first, then more buffers, straight from the file
+// with a single vectored read and adds them to the data left to split. It
+// returns false if the regular read path must be taken instead, because
+// data is buffered, the follower needs to look at the data, or there is
+// nothing to read.
+func (f *Follower) readBatchDirect(first []byte) bool {
+	f.mu.Lock()
+	p := f.position
+	file, _ := p.file.(*os.File)
+	if f.released || f.transformer != nil || f.rotationBuffer.Len() != 0 ||
+		f.fileReader.Buffered() != 0 || len(f.ahead) != 0 || f.opts.stop != (StopPolicy{}) || f.teeErr != nil ||
+		p.mapped != nil || p.ring != nil || p.bounded || file == nil {
+		f.mu.Unlock()
+		return false
+	}
+
+	b := &f.batch
+	bufs := make([][]byte, imax(b.buffers, 1))
+	bufs[0] = first
+	for i := 1; i < len(bufs); i++ {
+		bufs[i] = make([]byte, batchReadSize)
+	}
+	if f.prev != nil {
+		f.crossRotation()
+		b.lines.Boundary()
+	}
+	n, _ := readv(file, bufs)
+	p.pos += int64(n)
+	if n == 0 {
+		// let the regular path block or report the error
+		f.mu.Unlock()
+		return false
+	}
+
+	read := bufs[:0]
+	for left := n; left != 0; {
+		buf := bufs[len(read)]
+		if len(buf) > left {
+			buf = buf[:left]
+		}
+		read = append(read, buf)
+		left -= len(buf)
+	}
+	for _, buf := range read {
+		f.checkpoint(buf)
+	}
+	f.rotationBuffer.delivered(read[len(read)-1])
+	f.dropBehind()
+	f.mu.Unlock()
+	f.emitPending()
+
+	for _, buf := range read {
+		f.consumed(buf)
+	}
+	for _, buf := range read {
+		b.lines.Add(buf)
+	}
+
+	if n == len(bufs)*batchReadSize {
+		b.buffers = imin(2*b.buffers, maxBatchBuffers)
+	} else {
+		b.buffers = 1
+	}
+	return true
+}
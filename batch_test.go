@@ -0,0 +1,128 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestReadBatch(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		for i := 0; i < 10; i++ {
+			if _, err := fmt.Fprintf(file, "line %d\n", i); err != nil {
+				return err
+			}
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		var next int
+		for _, want := range []int{4, 4, 2} {
+			lines, err := follow.ReadBatch(4)
+			if err != nil {
+				return err
+			}
+			if len(lines) != want {
+				t.Errorf("wanted a batch of %d lines, got %d", want, len(lines))
+			}
+			for _, line := range lines {
+				if want := fmt.Sprintf("line %d", next); string(line) != want {
+					t.Errorf("wanted '%v', got '%v'", want, string(line))
+				}
+				next++
+			}
+		}
+		return nil
+	})
+}
+
+func TestReadBatchCatchUp(t *testing.T) {
+	withTempFile(t, time.Second*2, func(t *testing.T, filename string, file *os.File) error {
+		// lines of all sizes, some spanning several read buffers
+		var want []string
+		for i := 0; i < 2000; i++ {
+			line := strings.Repeat(strconv.Itoa(i%10), rand.Intn(2048))
+			if i%100 == 0 {
+				line = strings.Repeat("x", 200*1024)
+			}
+			want = append(want, line)
+		}
+		if _, err := file.WriteString(strings.Join(want, "\n") + "\n"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		var got []string
+		for len(got) < len(want) {
+			lines, err := follow.ReadBatch(100)
+			if err != nil {
+				return err
+			}
+			if len(lines) > 100 {
+				t.Fatalf("wanted at most 100 lines, got %d", len(lines))
+			}
+			for _, line := range lines {
+				got = append(got, string(line))
+			}
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("line %d: wanted %d bytes, got %d bytes", i, len(want[i]), len(got[i]))
+			}
+		}
+		return nil
+	})
+}
+
+func TestReadBatchRotation(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello\ntorn"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+		lines, err := follow.ReadBatch(10)
+		if err != nil {
+			return err
+		}
+
+		// the writer moved on to a new file without ending its last line
+		if err := os.Rename(filename, filename+".1"); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filename, []byte("record\n"), 0644); err != nil {
+			return err
+		}
+		for len(lines) < 3 {
+			more, err := follow.ReadBatch(10)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, more...)
+		}
+		if got := fmt.Sprintf("%q", lines); got != `["hello" "torn" "record"]` {
+			t.Errorf("wanted the incomplete line of the rotated file on its own, got %s", got)
+		}
+		return nil
+	})
+}
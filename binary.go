@@ -0,0 +1,103 @@
+package tailf
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// BinaryPolicy decides what a follower does with non-text content, such as
+// NUL bytes, control characters and invalid UTF-8.
+type BinaryPolicy int
+
+const (
+	// BinaryPass delivers non-text content as is. This is the default.
+	BinaryPass BinaryPolicy = iota
+	// BinaryFlag delivers non-text content as is, but reports it with a
+	// Binary event.
+	BinaryFlag
+	// BinaryHex replaces each non-text byte with its \xNN hex escape.
+	BinaryHex
+	// BinarySkip drops non-text bytes.
+	BinarySkip
+)
+
+// binaryFilter is a transform.Transformer applying a BinaryPolicy.
+type binaryFilter struct {
+	policy BinaryPolicy
+	flag   func(n int)
+}
+
+const hexDigits = "0123456789abcdef"
+
+func (b *binaryFilter) Reset() {}
+
+func (b *binaryFilter) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	found := 0
+	defer func() {
+		if found != 0 && b.flag != nil {
+			b.flag(found)
+		}
+	}()
+
+	for nSrc < len(src) {
+		c, size, text := src[nSrc], 1, true
+		if c < utf8.RuneSelf {
+			text = isText(c)
+		} else if r, n := utf8.DecodeRune(src[nSrc:]); r != utf8.RuneError || n > 1 {
+			size = n
+		} else if !atEOF && !utf8.FullRune(src[nSrc:]) {
+			return nDst, nSrc, transform.ErrShortSrc
+		} else {
+			text = false
+		}
+
+		switch {
+		case text, b.policy == BinaryFlag:
+			if nDst+size > len(dst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			nDst += copy(dst[nDst:], src[nSrc:nSrc+size])
+		case b.policy == BinaryHex:
+			if nDst+4 > len(dst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			dst[nDst] = '\\'
+			dst[nDst+1] = 'x'
+			dst[nDst+2] = hexDigits[c>>4]
+			dst[nDst+3] = hexDigits[c&0xf]
+			nDst += 4
+		}
+		if !text {
+			found++
+		}
+		nSrc += size
+	}
+	return nDst, nSrc, nil
+}
+
+// isText tells if an ASCII byte is found in text, which excludes most
+// control characters.
+func isText(c byte) bool {
+	switch c {
+	case '\t', '\n', '\v', '\f', '\r', '\b', 0x1b:
+		return true
+	}
+	return c >= 0x20 && c != 0x7f
+}
+
+// binaryTransformer returns the transformer applying the follower's
+// binary policy, or nil if the content is to be passed through.
+func (f *Follower) binaryTransformer() transform.Transformer {
+	switch f.opts.binary {
+	case BinaryPass:
+		return nil
+	case BinaryFlag:
+		// transforms run with the follower locked
+		return &binaryFilter{policy: BinaryFlag, flag: func(n int) {
+			f.queue(Binary{Len: n})
+		}}
+	default:
+		return &binaryFilter{policy: f.opts.binary}
+	}
+}
@@ -0,0 +1,57 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestBinaryPolicies(t *testing.T) {
+	tests := []struct {
+		policy tailf.BinaryPolicy
+		want   string
+	}{
+		{tailf.BinaryPass, "hello\x00\xff, world!"},
+		{tailf.BinaryFlag, "hello\x00\xff, world!"},
+		{tailf.BinaryHex, `hello\x00\xff, world!`},
+		{tailf.BinarySkip, "hello, world!"},
+	}
+	for _, tt := range tests {
+		withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+			if _, err := file.WriteString("hello\x00\xff, world!"); err != nil {
+				return err
+			}
+
+			follow, err := tailf.Follow(filename, true, tailf.WithBinary(tt.policy))
+			if err != nil {
+				return fmt.Errorf("failed creating tailf.follower: %v", err)
+			}
+			defer follow.Close()
+
+			data := make([]byte, len(tt.want))
+			if _, err := io.ReadAtLeast(follow, data, len(tt.want)); err != nil {
+				return err
+			}
+			if got := string(data); got != tt.want {
+				t.Errorf("policy %d: wanted '%q', got '%q'", tt.policy, tt.want, got)
+			}
+
+			if tt.policy != tailf.BinaryFlag {
+				return nil
+			}
+			select {
+			case ev := <-follow.Events():
+				if bin, ok := ev.(tailf.Binary); !ok || bin.Len != 2 {
+					t.Errorf("wanted a Binary event for 2 bytes, got %#v", ev)
+				}
+			default:
+				t.Errorf("wanted a Binary event")
+			}
+			return nil
+		})
+	}
+}
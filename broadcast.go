@@ -0,0 +1,139 @@
+package tailf
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrSinkBehind is reported by Broadcast for sinks that were dropped
+// because they fell too far behind the others.
+var ErrSinkBehind = errors.New("tailf: sink fell behind")
+
+const (
+	// broadcastQueue is how many chunks of data can be queued for a sink.
+	broadcastQueue = 64
+	// broadcastTimeout is how long a sink's queue can stay full before
+	// the sink is dropped.
+	broadcastTimeout = time.Second
+	// broadcastChunk is the size of the chunks read from the follower.
+	broadcastChunk = 32 * 1024
+)
+
+// sink is a writer fed by Broadcast through its own goroutine.
+type sink struct {
+	w     io.Writer
+	queue chan []byte
+	// done is closed once the sink stopped writing, either because its
+	// queue was closed and drained, or because writing failed.
+	done   chan struct{}
+	err    error
+	behind bool
+}
+
+func (s *sink) run() {
+	defer close(s.done)
+	for chunk := range s.queue {
+		n, err := s.w.Write(chunk)
+		if err == nil && n != len(chunk) {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			s.err = err
+			return
+		}
+	}
+}
+
+// Broadcast copies the followed data to each of sinks concurrently, until
+// the follower is closed and everything it buffered was written, reading
+// it fails, or every sink was dropped.
+//
+// Each sink is written to from its own goroutine, through a queue of
+// chunks, so that a slow or broken sink doesn't hold up the others: a sink
+// is dropped as soon as writing to it fails, or when it falls so far
+// behind that its queue stays full for a second. Broadcast returns once
+// every sink has written what was queued for it.
+//
+// errs holds, for each sink, the error that made it be dropped, or
+// ErrSinkBehind if it fell behind, and is nil if no sink was dropped. err
+// is the error that reading from the follower failed with, if any.
+func Broadcast(f *Follower, sinks ...io.Writer) (errs []error, err error) {
+	all := make([]*sink, len(sinks))
+	for i, w := range sinks {
+		all[i] = &sink{
+			w:     w,
+			queue: make(chan []byte, broadcastQueue),
+			done:  make(chan struct{}),
+		}
+		go all[i].run()
+	}
+
+	live := append([]*sink(nil), all...)
+	buf := make([]byte, broadcastChunk)
+	for len(live) != 0 {
+		n, rerr := f.Read(buf)
+		if n != 0 {
+			// the chunk is shared by the sinks, which only read it
+			chunk := append([]byte(nil), buf[:n]...)
+			live = feed(live, chunk, f.clock())
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			err = rerr
+			break
+		}
+	}
+	for _, s := range live {
+		close(s.queue)
+	}
+
+	for i, s := range all {
+		<-s.done
+		serr := s.err
+		if serr == nil && s.behind {
+			serr = ErrSinkBehind
+		}
+		if serr == nil {
+			continue
+		}
+		if errs == nil {
+			errs = make([]error, len(all))
+		}
+		errs[i] = serr
+	}
+	return errs, err
+}
+
+// feed queues chunk for each of the live sinks, and returns those that
+// are still live. Sinks that are behind are waited for on clock.
+func feed(live []*sink, chunk []byte, clock Clock) []*sink {
+	var timeout <-chan time.Time
+	kept := live[:0]
+	for _, s := range live {
+		select {
+		case s.queue <- chunk:
+			kept = append(kept, s)
+			continue
+		case <-s.done:
+			// writing failed
+			continue
+		default:
+		}
+		if timeout == nil {
+			// sinks that are behind share the wait
+			timeout = clock.After(broadcastTimeout)
+		}
+		select {
+		case s.queue <- chunk:
+			kept = append(kept, s)
+		case <-s.done:
+		case <-timeout:
+			s.behind = true
+			close(s.queue)
+		}
+	}
+	return kept
+}
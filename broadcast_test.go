@@ -0,0 +1,75 @@
+package tailf_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write(p []byte) (int, error) { return 0, w.err }
+
+type blockedWriter struct{ release chan struct{} }
+
+func (w blockedWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func TestBroadcast(t *testing.T) {
+	withTempFile(t, time.Second*5, func(t *testing.T, filename string, file *os.File) error {
+		want := make([]byte, 4<<20)
+		for i := range want {
+			want[i] = byte('a' + rand.Intn(26))
+		}
+		if _, err := file.Write(want); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+
+		var first, second bytes.Buffer
+		broken := errors.New("broken sink")
+		slow := blockedWriter{release: make(chan struct{})}
+
+		type result struct {
+			errs []error
+			err  error
+		}
+		done := make(chan result, 1)
+		go func() {
+			errs, err := tailf.Broadcast(follow, &first, failingWriter{broken}, slow, &second)
+			done <- result{errs, err}
+		}()
+
+		for follow.Cursor().Offset != int64(len(want)) {
+			time.Sleep(time.Millisecond)
+		}
+		if err := follow.Close(); err != nil {
+			return err
+		}
+		close(slow.release)
+		res := <-done
+
+		if res.err != nil {
+			return res.err
+		}
+		if !bytes.Equal(want, first.Bytes()) || !bytes.Equal(want, second.Bytes()) {
+			t.Errorf("data broadcast differs from data written")
+		}
+		if len(res.errs) != 4 || res.errs[0] != nil || res.errs[1] != broken || res.errs[2] != tailf.ErrSinkBehind || res.errs[3] != nil {
+			t.Errorf("wanted the broken and slow sinks to be dropped, got %v", res.errs)
+		}
+		return nil
+	})
+}
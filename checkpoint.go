@@ -0,0 +1,166 @@
+package tailf
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CheckpointStore persists cursors, keyed by the absolute path of the
+// file they point in.
+type CheckpointStore interface {
+	// Load returns the cursors that were last saved.
+	Load() (map[string]Cursor, error)
+	// Save replaces the saved cursors with cursors.
+	Save(cursors map[string]Cursor) error
+}
+
+// FileStore is a CheckpointStore keeping cursors in a state file, which
+// is written with WriteState and read with ReadState.
+type FileStore struct {
+	path string
+}
+
+// NewFileStore returns a store keeping cursors in the file at path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load returns the cursors in the file. A missing file holds no cursors.
+func (s *FileStore) Load() (map[string]Cursor, error) {
+	cursors, err := ReadState(s.path)
+	if os.IsNotExist(err) {
+		return map[string]Cursor{}, nil
+	}
+	return cursors, err
+}
+
+// Save replaces the file with one holding cursors.
+func (s *FileStore) Save(cursors map[string]Cursor) error {
+	return WriteState(s.path, cursors)
+}
+
+// Restore resumes following filename from the cursor found in store, or
+// follows it from its start if there is none.
+func Restore(store CheckpointStore, filename string, opts ...Option) (*Follower, error) {
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	cursors, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := cursors[path]; ok {
+		return Resume(filename, c, opts...)
+	}
+	return Follow(filename, true, opts...)
+}
+
+// Checkpointer periodically saves the cursors of a group of followers to
+// a CheckpointStore. For followers whose lines are delivered by an Acker,
+// the last acknowledged position is saved.
+type Checkpointer struct {
+	store CheckpointStore
+
+	mu        sync.Mutex
+	followers map[string]*Follower
+	err       error
+	clock     Clock
+
+	done  chan struct{}
+	reset chan struct{} // signaled when the clock changed
+	wg    sync.WaitGroup
+}
+
+// NewCheckpointer starts saving the cursors of the followers added to it
+// to store, every interval.
+func NewCheckpointer(store CheckpointStore, interval time.Duration) *Checkpointer {
+	c := &Checkpointer{
+		store:     store,
+		followers: make(map[string]*Follower),
+		clock:     systemClock{},
+		done:      make(chan struct{}),
+		reset:     make(chan struct{}, 1),
+	}
+	c.wg.Add(1)
+	go c.run(interval)
+	return c
+}
+
+// SetClock times the saves with clock instead of the system clock.
+func (c *Checkpointer) SetClock(clock Clock) {
+	c.mu.Lock()
+	c.clock = clock
+	c.mu.Unlock()
+	select {
+	case c.reset <- struct{}{}:
+	default:
+	}
+}
+
+// Add starts checkpointing the cursor of f.
+func (c *Checkpointer) Add(f *Follower) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.followers[f.filename] = f
+}
+
+// Remove stops checkpointing the cursor of f. The cursor last saved for
+// it is dropped from the store on the next save.
+func (c *Checkpointer) Remove(f *Follower) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.followers[f.filename] == f {
+		delete(c.followers, f.filename)
+	}
+}
+
+// Flush saves the cursors of the followers right away.
+func (c *Checkpointer) Flush() error {
+	c.mu.Lock()
+	cursors := make(map[string]Cursor, len(c.followers))
+	for path, f := range c.followers {
+		cursors[path] = f.durableCursor()
+	}
+	c.mu.Unlock()
+	return c.store.Save(cursors)
+}
+
+// Close stops the periodic saves and saves the cursors one last time. It
+// returns the error of the last failed periodic save, if any, or the error
+// of the final save.
+func (c *Checkpointer) Close() error {
+	close(c.done)
+	c.wg.Wait()
+
+	err := c.Flush()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	return err
+}
+
+func (c *Checkpointer) run(interval time.Duration) {
+	defer c.wg.Done()
+	for {
+		c.mu.Lock()
+		clock := c.clock
+		c.mu.Unlock()
+		select {
+		case <-c.done:
+			return
+		case <-c.reset:
+			// wait on the new clock
+		case <-clock.After(interval):
+			if err := c.Flush(); err != nil {
+				c.mu.Lock()
+				c.err = err
+				c.mu.Unlock()
+			}
+		}
+	}
+}
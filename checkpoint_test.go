@@ -0,0 +1,123 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	"github.com/aybabtme/tailf/tailftest"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		store := tailf.NewFileStore(filepath.Join(filepath.Dir(filename), "cursors.json"))
+
+		got, err := store.Load()
+		if err != nil {
+			return err
+		}
+		if len(got) != 0 {
+			t.Errorf("wanted no cursors in a new store, got %v", got)
+		}
+
+		want := map[string]tailf.Cursor{
+			"/var/log/a.log": {Device: 1, Inode: 2, Fingerprint: 3, Offset: 4},
+			"/var/log/b.log": {Device: 1, Inode: 5, Offset: 6},
+		}
+		if err := store.Save(want); err != nil {
+			return err
+		}
+		got, err = store.Load()
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("wanted %v, got %v", want, got)
+		}
+		return nil
+	})
+}
+
+func TestCheckpointerRestoresFollowers(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!"); err != nil {
+			return err
+		}
+		store := tailf.NewFileStore(filepath.Join(filepath.Dir(filename), "cursors.json"))
+
+		follow, err := tailf.Restore(store, filename)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		checkpoints := tailf.NewCheckpointer(store, time.Hour)
+		checkpoints.Add(follow)
+
+		if _, err := io.ReadAtLeast(follow, make([]byte, 7), 7); err != nil {
+			return err
+		}
+		if err := checkpoints.Close(); err != nil {
+			return err
+		}
+		if err := follow.Close(); err != nil {
+			return err
+		}
+
+		follow, err = tailf.Restore(store, filename)
+		if err != nil {
+			return fmt.Errorf("failed restoring tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		want := "world!"
+		data := make([]byte, len(want))
+		if _, err := io.ReadAtLeast(follow, data, len(want)); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
+
+func TestCheckpointerClock(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!"); err != nil {
+			return err
+		}
+		store := tailf.NewFileStore(filepath.Join(filepath.Dir(filename), "cursors.json"))
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+		if _, err := io.ReadAtLeast(follow, make([]byte, 7), 7); err != nil {
+			return err
+		}
+
+		clock := tailftest.NewClock(time.Unix(0, 0))
+		checkpoints := tailf.NewCheckpointer(store, time.Hour)
+		defer checkpoints.Close()
+		checkpoints.SetClock(clock)
+		checkpoints.Add(follow)
+
+		// the hour between saves takes no time
+		clock.BlockUntil(1)
+		clock.Advance(time.Hour)
+		for {
+			cursors, err := store.Load()
+			if err != nil {
+				return err
+			}
+			if len(cursors) != 0 {
+				return nil
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+}
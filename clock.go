@@ -0,0 +1,35 @@
+package tailf
+
+import "time"
+
+// Clock tells the time and waits for it to pass, for what followers do
+// over time: polling, coalescing writes, retrying, beating and taking
+// snapshots, as well as timing checkpoints and stats. Tests can set one
+// with WithClock that they advance themselves, such as tailftest.Clock,
+// instead of waiting.
+type Clock interface {
+	Now() time.Time
+	// After sends the time on the returned channel once d passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// WithClock times the follower with c instead of the system clock.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// systemClock is the Clock of the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clock returns the clock timing the follower.
+func (f *Follower) clock() Clock {
+	if f.opts.clock == nil {
+		return systemClock{}
+	}
+	return f.opts.clock
+}
@@ -0,0 +1,336 @@
+// Command tailf prints the end of files, and follows what is written to
+// them, like tail(1) does, with the rotation handling of the tailf
+// package.
+//
+// Usage:
+//
+//	tailf [-f | -F] [-n lines | -c bytes] file|glob...
+//
+// Unlike tail(1), files are always followed by name, so -f and -F only
+// differ in that -F waits for missing files to appear.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func main() {
+	stop := make(chan struct{})
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt)
+	go func() {
+		<-sigc
+		close(stop)
+	}()
+
+	if err := run(os.Args[1:], os.Stdout, stop); err != nil {
+		fmt.Fprintf(os.Stderr, "tailf: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// retryPolicy is how often -F looks for files that are missing.
+var retryPolicy = tailf.Backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+
+// run runs the command with args, printing to stdout. Following stops
+// once stop is closed.
+func run(args []string, stdout io.Writer, stop <-chan struct{}) error {
+	fs := flag.NewFlagSet("tailf", flag.ContinueOnError)
+	follow := fs.Bool("f", false, "follow the files as they grow")
+	retry := fs.Bool("F", false, "like -f, and wait for files that are missing")
+	nLines := fs.String("n", "10", "print the last `lines` lines, or from line `+lines` on")
+	nBytes := fs.String("c", "", "print the last `bytes` bytes, or from byte `+bytes` on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("no file given")
+	}
+
+	var from start
+	var err error
+	if *nBytes != "" {
+		from, err = parseStart(*nBytes, false)
+	} else {
+		from, err = parseStart(*nLines, true)
+	}
+	if err != nil {
+		return err
+	}
+
+	var filenames []string
+	for _, arg := range fs.Args() {
+		matches, err := filepath.Glob(arg)
+		if err != nil || len(matches) == 0 {
+			// let opening the file report it missing
+			matches = []string{arg}
+		}
+		filenames = append(filenames, matches...)
+	}
+
+	out := &output{w: stdout, headers: len(filenames) > 1}
+	if !*follow && !*retry {
+		for _, filename := range filenames {
+			if err := printFile(out, filename, from); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// following stops as soon as a file fails, rather than once the
+	// others stopped too
+	done := make(chan struct{})
+	var once sync.Once
+	quit := func() { once.Do(func() { close(done) }) }
+	go func() {
+		select {
+		case <-stop:
+			quit()
+		case <-done:
+		}
+	}()
+
+	errc := make(chan error, len(filenames))
+	for _, filename := range filenames {
+		go func(filename string) {
+			errc <- followFile(out, filename, from, *retry, done)
+		}(filename)
+	}
+	var first error
+	for range filenames {
+		if err := <-errc; err != nil && first == nil {
+			first = err
+			quit()
+		}
+	}
+	quit()
+	return first
+}
+
+// start is where printing starts in a file: a count of lines or bytes,
+// from the end of the file, or from its start if fromStart is true.
+type start struct {
+	n         int64
+	lines     bool
+	fromStart bool
+}
+
+func parseStart(s string, lines bool) (start, error) {
+	from := start{lines: lines, fromStart: strings.HasPrefix(s, "+")}
+	n, err := strconv.ParseInt(strings.TrimPrefix(s, "+"), 10, 64)
+	if err != nil || n < 0 {
+		return start{}, fmt.Errorf("invalid count: %q", s)
+	}
+	from.n = n
+	return from, nil
+}
+
+// offset returns the offset at which printing starts in file, of the
+// given size.
+func (s start) offset(file *os.File, size int64) (int64, error) {
+	if !s.lines {
+		if s.fromStart {
+			return clamp(s.n-1, 0, size), nil
+		}
+		return clamp(size-s.n, 0, size), nil
+	}
+	if s.fromStart {
+		return lineOffset(file, s.n-1)
+	}
+	return lastLinesOffset(file, size, s.n)
+}
+
+// lineOffset returns the offset of the line following the first n
+// lines of file.
+func lineOffset(file *os.File, n int64) (int64, error) {
+	buf := make([]byte, 32*1024)
+	var off int64
+	for n > 0 {
+		m, err := file.ReadAt(buf, off)
+		for i := 0; i < m && n > 0; i++ {
+			if buf[i] == '\n' {
+				n--
+			}
+			off++
+		}
+		if err == io.EOF {
+			return off, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return off, nil
+}
+
+// lastLinesOffset returns the offset of the last n lines of file, of the
+// given size. A last line without a trailing newline counts as a line.
+func lastLinesOffset(file *os.File, size, n int64) (int64, error) {
+	if n == 0 {
+		return size, nil
+	}
+	buf := make([]byte, 32*1024)
+	end := size
+	// the newline ending the last line doesn't start a line
+	skip := true
+	for end > 0 {
+		begin := clamp(end-int64(len(buf)), 0, end)
+		chunk := buf[:end-begin]
+		if _, err := file.ReadAt(chunk, begin); err != nil {
+			return 0, err
+		}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if chunk[i] != '\n' {
+				skip = false
+				continue
+			}
+			if skip {
+				skip = false
+				continue
+			}
+			if n--; n == 0 {
+				return begin + int64(i) + 1, nil
+			}
+		}
+		end = begin
+	}
+	return 0, nil
+}
+
+func clamp(v, min, max int64) int64 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// printFile prints filename from where printing starts, up to its end.
+func printFile(out *output, filename string, from start) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	off, err := from.offset(file, fi.Size())
+	if err != nil {
+		return err
+	}
+	return out.copy(filename, io.NewSectionReader(file, off, fi.Size()-off))
+}
+
+// followFile prints filename from where printing starts, and what is
+// written to it from then on, until stop is closed. If retry is true,
+// a missing file is waited for.
+func followFile(out *output, filename string, from start, retry bool, stop <-chan struct{}) error {
+	f, err := followAt(filename, from)
+	for attempt := 0; retry && os.IsNotExist(err); attempt++ {
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(retryPolicy.Delay(attempt)):
+		}
+		// the file is new, print all of it
+		f, err = tailf.Follow(filename, true)
+	}
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-stop
+		f.Close()
+	}()
+	return out.copy(filename, f)
+}
+
+// followAt follows filename from where printing starts. The cursor to
+// resume from is taken from the file the offset was found in, so that
+// the rest of that file is printed even if it is rotated before
+// following starts.
+func followAt(filename string, from start) (*tailf.Follower, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	off, err := from.offset(file, fi.Size())
+	if err != nil {
+		return nil, err
+	}
+	c, err := tailf.FileCursor(file, off)
+	if err != nil {
+		return nil, err
+	}
+	return tailf.Resume(filename, c)
+}
+
+// output prints what is read from several files, with a header naming
+// the file before what is read from it whenever the file changes, as
+// tail(1) does.
+type output struct {
+	w       io.Writer
+	headers bool
+
+	mu   sync.Mutex
+	last string
+}
+
+func (o *output) copy(filename string, r io.Reader) error {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n != 0 {
+			if werr := o.write(filename, buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (o *output) write(filename string, data []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.headers && filename != o.last {
+		var header bytes.Buffer
+		if o.last != "" {
+			header.WriteByte('\n')
+		}
+		fmt.Fprintf(&header, "==> %s <==\n", filename)
+		if _, err := o.w.Write(header.Bytes()); err != nil {
+			return err
+		}
+		o.last = filename
+	}
+	_, err := o.w.Write(data)
+	return err
+}
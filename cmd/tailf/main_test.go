@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "tailf_cmd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, filename, content string) {
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	a := filepath.Join(dir, "a.log")
+	writeFile(t, a, "1\n2\n3\n4\n")
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{a}, "1\n2\n3\n4\n"},
+		{[]string{"-n", "2", a}, "3\n4\n"},
+		{[]string{"-n", "+2", a}, "2\n3\n4\n"},
+		{[]string{"-n", "0", a}, ""},
+		{[]string{"-c", "3", a}, "\n4\n"},
+		{[]string{"-c", "+3", a}, "2\n3\n4\n"},
+	} {
+		var out bytes.Buffer
+		if err := run(tc.args, &out, nil); err != nil {
+			t.Fatalf("%v: %v", tc.args, err)
+		}
+		if out.String() != tc.want {
+			t.Errorf("%v: wanted %q, got %q", tc.args, tc.want, out.String())
+		}
+	}
+}
+
+func TestPrintUnterminatedLine(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	a := filepath.Join(dir, "a.log")
+	writeFile(t, a, "1\n2\n3")
+
+	var out bytes.Buffer
+	if err := run([]string{"-n", "2", a}, &out, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := "2\n3"; out.String() != want {
+		t.Errorf("wanted %q, got %q", want, out.String())
+	}
+}
+
+func TestPrintGlob(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	writeFile(t, a, "a\n")
+	writeFile(t, b, "b\n")
+
+	var out bytes.Buffer
+	if err := run([]string{filepath.Join(dir, "*.log")}, &out, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "==> " + a + " <==\na\n\n==> " + b + " <==\nb\n"
+	if out.String() != want {
+		t.Errorf("wanted %q, got %q", want, out.String())
+	}
+}
+
+func TestFollow(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	a := filepath.Join(dir, "a.log")
+	writeFile(t, a, "1\n2\n")
+
+	r, w := io.Pipe()
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- run([]string{"-F", "-n", "1", a}, w, stop)
+		w.Close()
+	}()
+
+	lines := bufio.NewScanner(r)
+	expect := func(want string) {
+		if !lines.Scan() {
+			t.Fatalf("wanted %q, got %v", want, lines.Err())
+		}
+		if lines.Text() != want {
+			t.Fatalf("wanted %q, got %q", want, lines.Text())
+		}
+	}
+	expect("2")
+
+	// rotate the file
+	if err := os.Rename(a, a+".1"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, a, "3\n")
+	expect("3")
+
+	close(stop)
+	go ioutil.ReadAll(r)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wanted following to stop")
+	}
+}
+
+func TestFollowFailsFast(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	a, missing := filepath.Join(dir, "a.log"), filepath.Join(dir, "missing.log")
+	writeFile(t, a, "1\n")
+
+	// never stopped, a.log is followed until missing.log fails
+	done := make(chan error, 1)
+	go func() { done <- run([]string{"-f", a, missing}, ioutil.Discard, nil) }()
+	select {
+	case err := <-done:
+		if !os.IsNotExist(err) {
+			t.Fatalf("wanted missing.log not to exist, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wanted following to stop once a file failed")
+	}
+}
@@ -0,0 +1,25 @@
+package tailf
+
+import "io"
+
+// Contents returns a reader of what is left to read of the file right
+// now, n bytes as Lag counts them, past which reading the follower carries
+// on following the file live, for UIs showing the whole current log
+// before streaming what is written to it.
+//
+// Only what lies past the follower's position is part of it: followed
+// from its start and not read yet, that is the whole content of the file,
+// but a follower created with fromStart false starts at the end of the
+// file, and its contents hold none of what the file held by then.
+//
+// The contents are read from the follower, so that nothing is lost nor
+// read twice between the two, and the follower mustn't be read otherwise
+// until they were. Should the file be truncated before they were read in
+// full, what is written after the truncation makes up the rest of them.
+func (f *Follower) Contents() (io.Reader, int64, error) {
+	n, err := f.Lag()
+	if err != nil {
+		return nil, 0, err
+	}
+	return io.LimitReader(f, n), n, nil
+}
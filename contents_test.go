@@ -0,0 +1,91 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestContentsThenFollow(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!\n"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		contents, n, err := follow.Contents()
+		if err != nil {
+			return err
+		}
+		// written after the contents were taken
+		if _, err := file.WriteString("bonjour!\n"); err != nil {
+			return err
+		}
+		got, err := ioutil.ReadAll(contents)
+		if err != nil {
+			return err
+		}
+		if want := "hello, world!\n"; string(got) != want || n != int64(len(want)) {
+			t.Errorf("wanted contents of %q, got %q of %d bytes", want, got, n)
+		}
+
+		// then follows on from there
+		got = make([]byte, 9)
+		if _, err := io.ReadFull(follow, got); err != nil {
+			return err
+		}
+		if want := "bonjour!\n"; string(got) != want {
+			t.Errorf("wanted to read %q, got %q", want, got)
+		}
+		return nil
+	})
+}
+
+func TestContentsNotFromStart(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!\n"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, false)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		contents, n, err := follow.Contents()
+		if err != nil {
+			return err
+		}
+		got, err := ioutil.ReadAll(contents)
+		if err != nil {
+			return err
+		}
+		if len(got) != 0 || n != 0 {
+			t.Errorf("wanted no contents, got %q of %d bytes", got, n)
+		}
+
+		// only what is written from then on is read
+		if _, err := file.WriteString("bonjour!\n"); err != nil {
+			return err
+		}
+		got = make([]byte, 9)
+		if _, err := io.ReadFull(follow, got); err != nil {
+			return err
+		}
+		if want := "bonjour!\n"; string(got) != want {
+			t.Errorf("wanted to read %q, got %q", want, got)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,241 @@
+package tailf
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+)
+
+// Cursor is a position in a followed file. It identifies the file
+// generation being read by its device and inode numbers, which change when
+// the file is rotated, along with a fingerprint of its content. Cursors
+// are meant to be persisted, for instance as JSON, so that following can
+// resume where it left off.
+type Cursor struct {
+	Device uint64 `json:"device"`
+	Inode  uint64 `json:"inode"`
+	// Fingerprint is a hash of the first FingerprintLen bytes of the
+	// file. Since inode numbers are reused once a file is deleted, it
+	// tells apart a new file that got the inode of one that was rotated
+	// away.
+	Fingerprint    uint64 `json:"fingerprint"`
+	FingerprintLen int    `json:"fingerprint_len"`
+	// Offset is the number of bytes of the file that were consumed.
+	Offset int64 `json:"offset"`
+}
+
+// fingerprintSize is how many bytes at the start of a file are hashed to
+// fingerprint it. Files smaller than that are fingerprinted as a whole,
+// and fingerprinted again as they grow.
+const fingerprintSize = 1024
+
+// Cursor returns the position of the consumer in the followed file. While
+// data left in a rotated file is being read, the cursor points in that
+// file. Offsets are counted in bytes of the file, before any transform is
+// applied, and are only approximate across a rotation when transforms are
+// configured.
+func (f *Follower) Cursor() Cursor {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.cursor()
+}
+
+// FileCursor returns the cursor at offset in file, for Resume to follow
+// the file from a position found by reading it, even if it was rotated
+// once the position was found.
+func FileCursor(file File, offset int64) (Cursor, error) {
+	c, err := identify(file)
+	if err != nil {
+		return Cursor{}, err
+	}
+	c.Offset = offset
+	return c, nil
+}
+
+// durableCursor returns the cursor that is safe to checkpoint, which is
+// the last acknowledged position if an Acker is delivering the lines.
+func (f *Follower) durableCursor() Cursor {
+	f.mu.Lock()
+	a := f.acker
+	f.mu.Unlock()
+	if a != nil {
+		return a.Cursor()
+	}
+	return f.Cursor()
+}
+
+func (f *Follower) cursor() Cursor {
+	if f.released {
+		return f.final
+	}
+	if f.prev != nil {
+		c := *f.prev
+		c.Offset -= int64(f.rotationBuffer.Len() + len(f.ahead))
+		if c.Offset < 0 {
+			c.Offset = 0
+		}
+		return c
+	}
+
+	if f.gen.FingerprintLen < fingerprintSize && f.position.pos > int64(f.gen.FingerprintLen) {
+		// the file grew, unless it can't be read anymore
+		if fp, n := fingerprint(f.file, fingerprintSize); n > f.gen.FingerprintLen {
+			f.gen.Fingerprint, f.gen.FingerprintLen = fp, n
+		}
+	}
+	c := f.gen
+	c.Offset = f.offset() - int64(len(f.ahead))
+	if c.Offset < 0 {
+		// read ahead past a rotation
+		c.Offset = 0
+	}
+	return c
+}
+
+// offset returns how many bytes of the current file were consumed.
+func (f *Follower) offset() int64 {
+	n := f.position.pos - int64(f.fileReader.Buffered())
+	if f.transformer != nil {
+		n -= int64(len(f.transformer.src))
+	}
+	return n
+}
+
+// identify returns the cursor at the start of file. Files that aren't OS
+// files are only identified by their fingerprint.
+func identify(file File) (Cursor, error) {
+	var c Cursor
+	if osFile, ok := file.(*os.File); ok {
+		dev, ino, err := fileID(osFile)
+		if err != nil {
+			return Cursor{}, err
+		}
+		c.Device, c.Inode = dev, ino
+	}
+	c.Fingerprint, c.FingerprintLen = fingerprint(file, fingerprintSize)
+	return c, nil
+}
+
+// fingerprint hashes up to the first size bytes of file, returning the
+// hash and how many bytes it covers.
+func fingerprint(file File, size int) (uint64, int) {
+	var head [fingerprintSize]byte
+	if size > fingerprintSize {
+		size = fingerprintSize
+	}
+	n, _ := file.ReadAt(head[:size], 0)
+	return fnv64a(head[:n]), n
+}
+
+// fnv64a is hash/fnv's FNV-1a, without allocating a hash.Hash.
+func fnv64a(b []byte) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	h := uint64(offset64)
+	for _, c := range b {
+		h ^= uint64(c)
+		h *= prime64
+	}
+	return h
+}
+
+// sameFingerprint tells if file starts with the content fingerprinted in
+// c.
+func sameFingerprint(c Cursor, file File) bool {
+	if c.FingerprintLen == 0 {
+		return true
+	}
+	fp, n := fingerprint(file, c.FingerprintLen)
+	return n == c.FingerprintLen && fp == c.Fingerprint
+}
+
+// positionReader reads from a file, keeping track of its position in it.
+// If bounded, it reaches io.EOF at limit. If mapped, the data is copied
+// out of the mapping until the reader moves past it. If ring is set, the
+// file is read through it.
+type positionReader struct {
+	file    File
+	pos     int64
+	bounded bool
+	limit   int64
+	mapped  *mapping
+	ring    *URing
+}
+
+func (p *positionReader) Read(b []byte) (int, error) {
+	if p.bounded {
+		if p.pos >= p.limit {
+			return 0, io.EOF
+		}
+		if left := p.limit - p.pos; int64(len(b)) > left {
+			b = b[:left]
+		}
+	}
+	if p.mapped != nil {
+		if n := p.mapped.readAt(b, p.pos); n != 0 {
+			p.pos += int64(n)
+			return n, nil
+		}
+		// past the mapping, or the file shrank: go on with regular reads
+		p.unmap()
+		if _, err := p.file.Seek(p.pos, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+	if p.ring != nil {
+		n, err := p.ring.pread(p.file.(*os.File), b, p.pos)
+		p.pos += int64(n)
+		return n, err
+	}
+	n, err := p.file.Read(b)
+	p.pos += int64(n)
+	return n, err
+}
+
+// unmap releases the mapping, if any.
+func (p *positionReader) unmap() {
+	if p.mapped != nil {
+		p.mapped.unmap()
+		p.mapped = nil
+	}
+}
+
+// exhausted tells if a bounded reader reached its limit.
+func (p *positionReader) exhausted() bool {
+	return p.bounded && p.pos >= p.limit
+}
+
+// checkpointCounter tracks what was read since the last Checkpoint event.
+type checkpointCounter struct {
+	bytes int64
+	lines int
+	last  time.Time
+}
+
+// checkpoint accounts for data having been read, queuing a Checkpoint
+// event if the checkpoint policy says so. The follower must be locked.
+func (f *Follower) checkpoint(data []byte) {
+	p := f.opts.checkpoints
+	if p == (CheckpointPolicy{}) || len(data) == 0 {
+		return
+	}
+
+	cnt := &f.sinceCheckpoint
+	if cnt.last.IsZero() {
+		cnt.last = f.clock().Now()
+	}
+	cnt.bytes += int64(len(data))
+	if p.Lines != 0 {
+		cnt.lines += bytes.Count(data, []byte{'\n'})
+	}
+
+	if (p.Bytes != 0 && cnt.bytes >= p.Bytes) ||
+		(p.Lines != 0 && cnt.lines >= p.Lines) ||
+		(p.Interval != 0 && f.clock().Now().Sub(cnt.last) >= p.Interval) {
+		f.queue(Checkpoint{Cursor: f.cursor()})
+		*cnt = checkpointCounter{last: f.clock().Now()}
+	}
+}
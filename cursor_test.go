@@ -0,0 +1,83 @@
+//go:build !windows
+// +build !windows
+
+package tailf_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestCursorTracksOffset(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.Write(bytes.Repeat([]byte("0123456789"), 200)); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if _, err := io.ReadAtLeast(follow, make([]byte, 1500), 1500); err != nil {
+			return err
+		}
+
+		fi, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		st := fi.Sys().(*syscall.Stat_t)
+
+		c := follow.Cursor()
+		if c.Offset != 1500 {
+			t.Errorf("wanted offset 1500, got %d", c.Offset)
+		}
+		if c.Inode != uint64(st.Ino) || c.Device != uint64(st.Dev) {
+			t.Errorf("wanted cursor on inode %d, got %#v", st.Ino, c)
+		}
+		if c.Fingerprint == 0 {
+			t.Errorf("wanted a fingerprint, got %#v", c)
+		}
+		return nil
+	})
+}
+
+func BenchmarkCursor(b *testing.B) {
+	file, err := ioutil.TempFile("", "tailf_bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	// stay below the fingerprinted size, so that the fingerprint is
+	// incomplete
+	if _, err := file.WriteString("hello, world!\n"); err != nil {
+		b.Fatal(err)
+	}
+	follow, err := tailf.Follow(file.Name(), true)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer follow.Close()
+	a := tailf.NewAcker(follow)
+	if _, err := a.ReadLine(); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = follow.Cursor()
+	}
+}
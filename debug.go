@@ -0,0 +1,92 @@
+package tailf
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"text/tabwriter"
+)
+
+// live holds the followers of the process that weren't closed yet.
+var live = struct {
+	sync.Mutex
+	followers map[*Follower]struct{}
+}{followers: make(map[*Follower]struct{})}
+
+func register(f *Follower) {
+	live.Lock()
+	defer live.Unlock()
+	live.followers[f] = struct{}{}
+}
+
+func unregister(f *Follower) {
+	live.Lock()
+	defer live.Unlock()
+	delete(live.followers, f)
+}
+
+// DebugHandler returns an http.Handler listing the followers of the
+// process that weren't closed yet, with the file they read, their
+// position, lag and state, and the last error they ran into.
+func DebugHandler() http.Handler {
+	return http.HandlerFunc(serveDebug)
+}
+
+// followerState is a follower as listed by DebugHandler.
+type followerState struct {
+	path    string
+	cursor  Cursor
+	lag     int64
+	state   string
+	lastErr error
+}
+
+func serveDebug(w http.ResponseWriter, r *http.Request) {
+	live.Lock()
+	followers := make([]*Follower, 0, len(live.followers))
+	for f := range live.followers {
+		followers = append(followers, f)
+	}
+	live.Unlock()
+
+	states := make([]followerState, 0, len(followers))
+	for _, f := range followers {
+		states = append(states, f.debugState())
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].path < states[j].path })
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tINODE\tOFFSET\tLAG\tSTATE\tLAST ERROR")
+	for _, s := range states {
+		lastErr := "-"
+		if s.lastErr != nil {
+			lastErr = s.lastErr.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\n", s.path, s.cursor.Inode, s.cursor.Offset, s.lag, s.state, lastErr)
+	}
+	_ = tw.Flush()
+}
+
+func (f *Follower) debugState() followerState {
+	f.mu.Lock()
+	s := followerState{path: f.filename, cursor: f.cursor(), state: "following"}
+	lag, err := f.currentLag()
+	if err != nil {
+		lag = f.lag(f.size)
+	}
+	s.lag = lag
+	switch {
+	case f.prev != nil:
+		s.state = "rotating"
+	case f.opts.polling:
+		s.state = "polling"
+	}
+	f.mu.Unlock()
+
+	f.statsmu.Lock()
+	s.lastErr = f.lastErr
+	f.statsmu.Unlock()
+	return s
+}
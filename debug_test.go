@@ -0,0 +1,54 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestDebugHandler(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!\n"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		if _, err := io.ReadFull(follow, make([]byte, 5)); err != nil {
+			return err
+		}
+
+		list := func() string {
+			rec := httptest.NewRecorder()
+			tailf.DebugHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/tailf", nil))
+			return rec.Body.String()
+		}
+		var row string
+		for _, line := range strings.Split(list(), "\n") {
+			if strings.HasPrefix(line, follow.Filename()+" ") {
+				row = line
+			}
+		}
+		c := follow.Cursor()
+		want := []string{follow.Filename(), fmt.Sprint(c.Inode), "5", "9", "following", "-"}
+		if got := strings.Fields(row); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("wanted the follower listed as %v, got %v", want, got)
+		}
+
+		if err := follow.Close(); err != nil {
+			return err
+		}
+		if body := list(); strings.Contains(body, follow.Filename()) {
+			t.Errorf("wanted the closed follower not to be listed, got %q", body)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,410 @@
+package tailf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// discoveryInterval is how often a FollowerSet looks for the files it
+// discovers, unless set otherwise.
+const discoveryInterval = time.Second
+
+// Discovery makes a FollowerSet find the files it follows by itself, by
+// matching the paths of the files of the OS filesystem with patterns.
+type Discovery struct {
+	// Include are the patterns of the paths of the files to follow, as
+	// filepath.Match takes them. A pattern matching a directory
+	// matches the files in it.
+	Include []string
+	// Exclude are the patterns of the files not to follow among those,
+	// matched with both their path and their name.
+	Exclude []string
+	// Interval is how often the patterns are matched again, to find the
+	// files created since. It defaults to a second.
+	Interval time.Duration
+	// FromStart follows the files found when discovery starts or is
+	// reconfigured from their start, rather than from their end. The
+	// files found later are followed from their start, since they were
+	// created since.
+	FromStart bool
+	// IgnoreOlder skips the files that weren't modified for that long,
+	// such as the archives kept next to live files, unless it is 0. A file
+	// skipped is found once it is modified again, and followed from where
+	// it ended when it was skipped.
+	IgnoreOlder time.Duration
+	// FollowIgnored follows the files IgnoreOlder skips from their end
+	// instead, so that only what is written to them from then on is read.
+	FollowIgnored bool
+	// DeleteGrace is how long the followers of deleted files keep reading
+	// them before they are removed, since their writer may still be
+	// flushing its last lines to the open file. It is a multiple of
+	// Interval, at least one.
+	DeleteGrace time.Duration
+	// Options are the options of the followers of the files found,
+	// after those of the set. Reconfiguring them doesn't change the
+	// followers started already.
+	Options []Option
+}
+
+// DiscoveryReason tells why a FollowerSet started or stopped following a
+// file it discovers.
+type DiscoveryReason int
+
+const (
+	// DiscoveryMatched is for the files found when discovery started or
+	// was reconfigured.
+	DiscoveryMatched DiscoveryReason = iota
+	// DiscoveryCreated is for the files found later, which were created
+	// since the previous look.
+	DiscoveryCreated
+	// DiscoveryDeleted is for the files that were deleted.
+	DiscoveryDeleted
+	// DiscoveryExcluded is for the files that don't match anymore, since
+	// discovery was reconfigured.
+	DiscoveryExcluded
+)
+
+func (r DiscoveryReason) String() string {
+	switch r {
+	case DiscoveryMatched:
+		return "matched"
+	case DiscoveryCreated:
+		return "created"
+	case DiscoveryDeleted:
+		return "deleted"
+	case DiscoveryExcluded:
+		return "excluded"
+	}
+	return fmt.Sprintf("DiscoveryReason(%d)", int(r))
+}
+
+// FileAdded is reported by a FollowerSet when it starts following a file
+// it discovered.
+type FileAdded struct {
+	Reason DiscoveryReason
+}
+
+// FileRemoved is reported by a FollowerSet when it stops following a file
+// it discovered. The follower is closed once it was read to the end of
+// its file.
+type FileRemoved struct {
+	Reason DiscoveryReason
+}
+
+func (FileAdded) isEvent()   {}
+func (FileRemoved) isEvent() {}
+
+// Discover makes the set follow the files matching d, and look for them
+// again every interval, until it is closed. Calling it again reconfigures
+// discovery while following, without tearing the set down: the files
+// matching the new patterns are added, with the new options, and the
+// followers of those that don't match anymore are closed once they were
+// read to the end. The followers of the files that still match keep the
+// options they were started with, so that what they buffered isn't lost:
+// new options only apply to the files found from then on. The followers
+// added with Add are left alone. Each file added or removed is reported
+// on the Events and Discoveries channels of the set, with FileAdded or
+// FileRemoved, without waiting for them to be read.
+func (s *FollowerSet) Discover(d Discovery) error {
+	for _, pattern := range append(d.Include[:len(d.Include):len(d.Include)], d.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("tailf: bad pattern %q: %v", pattern, err)
+		}
+	}
+	if d.Interval <= 0 {
+		d.Interval = discoveryInterval
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrSetClosed
+	}
+	first := s.discovery == nil
+	s.discovery = &d
+	if first {
+		s.found = make(map[string]bool)
+		s.ignored = make(map[string]Cursor)
+		s.deleted = make(map[string]time.Time)
+		s.loops.Add(1)
+		go s.discover()
+	}
+	s.mu.Unlock()
+
+	s.scan(DiscoveryMatched)
+	return nil
+}
+
+// discover looks for the files to follow every interval, until the set
+// is closed.
+func (s *FollowerSet) discover() {
+	defer s.loops.Done()
+	for {
+		s.mu.Lock()
+		interval := s.discovery.Interval
+		s.mu.Unlock()
+		if !s.wait(interval) {
+			return
+		}
+		s.scan(DiscoveryCreated)
+	}
+}
+
+// wait waits for d to pass, and returns false if the set is closed first.
+func (s *FollowerSet) wait(d time.Duration) bool {
+	select {
+	case <-s.timing().After(d):
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// scan follows the files that match the discovery patterns and weren't
+// followed yet, for reason, and drains the followers of those that don't
+// match anymore. The files matched when discovery starts or is
+// reconfigured are followed as FromStart says, those created since from
+// their start.
+func (s *FollowerSet) scan(reason DiscoveryReason) {
+	s.scanmu.Lock()
+	defer s.scanmu.Unlock()
+	s.mu.Lock()
+	d := *s.discovery
+	s.mu.Unlock()
+
+	now := s.timing().Now()
+	matches := d.match()
+	for path := range matches {
+		if s.found[path] {
+			continue
+		}
+		fromStart := reason == DiscoveryCreated || d.FromStart
+		if d.old(path, now) {
+			if !d.FollowIgnored {
+				s.ignore(path)
+				continue
+			}
+			fromStart = false
+		}
+		var from *Cursor
+		if c, ok := s.ignored[path]; ok {
+			// modified since it was skipped
+			from = &c
+		}
+		f, err := s.add(path, fromStart, from, d.Options)
+		if err != nil {
+			// added by hand, gone already or unreadable, which
+			// is tried again on the next scan
+			continue
+		}
+		s.evict()
+		s.found[path] = true
+		delete(s.ignored, path)
+		s.report(path, f.Labels(), FileAdded{Reason: reason})
+	}
+	for path := range s.ignored {
+		if !matches[path] {
+			delete(s.ignored, path)
+		}
+	}
+	for path := range s.found {
+		if matches[path] {
+			delete(s.deleted, path)
+			continue
+		}
+		reason := DiscoveryExcluded
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			reason = DiscoveryDeleted
+			if !s.graceOver(path, d.DeleteGrace, now) {
+				continue
+			}
+		}
+		delete(s.found, path)
+		delete(s.deleted, path)
+		s.drain(path, reason)
+	}
+}
+
+// ignore remembers where the file at path, which IgnoreOlder skips, ends,
+// unless it was skipped already, so that it is followed from there once
+// it is modified. A file that can't be read is followed from its start.
+func (s *FollowerSet) ignore(path string) {
+	if _, ok := s.ignored[path]; ok {
+		return
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	c, err := identify(file)
+	if err != nil {
+		return
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		return
+	}
+	c.Offset = fi.Size()
+	s.ignored[path] = c
+}
+
+// graceOver tells if the file at path, which was deleted, was for longer
+// than grace at now, keeping track of when it was first found deleted.
+func (s *FollowerSet) graceOver(path string, grace time.Duration, now time.Time) bool {
+	if grace <= 0 {
+		return true
+	}
+	since, ok := s.deleted[path]
+	if !ok {
+		s.deleted[path] = now
+		return false
+	}
+	return now.Sub(since) >= grace
+}
+
+// drain removes the follower of path from the set for reason, and closes
+// it once it was read to the end of its file, or the set is closed.
+func (s *FollowerSet) drain(path string, reason DiscoveryReason) {
+	s.mu.Lock()
+	if !s.has(path) {
+		s.mu.Unlock()
+		return
+	}
+	if e, ok := s.evicted[path]; ok {
+		// closed already
+		s.forget(path)
+		s.mu.Unlock()
+		s.report(path, e.labels, FileRemoved{Reason: reason})
+		return
+	}
+	f := s.forget(path)
+	interval := s.discovery.Interval
+	s.loops.Add(1)
+	s.mu.Unlock()
+	s.report(path, f.Labels(), FileRemoved{Reason: reason})
+
+	go func() {
+		defer s.loops.Done()
+		for {
+			if lag, err := f.Lag(); err != nil || lag == 0 || !s.wait(interval) {
+				break
+			}
+		}
+		_ = closeFollower(f)
+	}()
+}
+
+// report queues ev about the file at path, labeled with labels, to be
+// reported on the Events and Discoveries channels of the set, those that
+// were asked for. It doesn't wait for them to be read, so that Discover
+// returns however many files it found, even to the goroutine draining the
+// channels.
+func (s *FollowerSet) report(path string, labels Labels, ev Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.reports = append(s.reports, LabeledEvent{Event: ev, Path: path, Labels: labels})
+	if !s.reporting {
+		s.reporting = true
+		s.forwarding.Add(1)
+		go s.relay()
+	}
+}
+
+// relay sends the queued reports in order, until none is left.
+func (s *FollowerSet) relay() {
+	defer s.forwarding.Done()
+	for {
+		s.mu.Lock()
+		if len(s.reports) == 0 {
+			s.reporting = false
+			s.mu.Unlock()
+			return
+		}
+		labeled := s.reports[0]
+		s.reports[0] = LabeledEvent{}
+		s.reports = s.reports[1:]
+		events, discoveries := s.events, s.discoveries
+		s.mu.Unlock()
+		if events != nil {
+			events <- labeled
+		}
+		if discoveries != nil {
+			discoveries <- labeled
+		}
+	}
+}
+
+// Discoveries returns the channel on which the files the set starts and
+// stops following as it discovers them are reported, with FileAdded and
+// FileRemoved events, so that what is followed can be audited apart from
+// the data. Once it was called, it must be drained until it is closed,
+// which happens once the set was closed.
+func (s *FollowerSet) Discoveries() <-chan LabeledEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.discoveries == nil {
+		s.discoveries = make(chan LabeledEvent, eventBufferSize)
+		if s.closed {
+			close(s.discoveries)
+		}
+	}
+	return s.discoveries
+}
+
+// match returns the paths of the files matching d.
+func (d *Discovery) match() map[string]bool {
+	found := make(map[string]bool)
+	for _, pattern := range d.Include {
+		paths, _ := filepath.Glob(pattern)
+		for _, path := range paths {
+			fi, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if !fi.IsDir() {
+				if !d.excluded(path) {
+					found[path] = true
+				}
+				continue
+			}
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				continue
+			}
+			for _, e := range entries {
+				if name := filepath.Join(path, e.Name()); !e.IsDir() && !d.excluded(name) {
+					found[name] = true
+				}
+			}
+		}
+	}
+	return found
+}
+
+// excluded tells if the path of a file matching d is excluded.
+func (d *Discovery) excluded(path string) bool {
+	for _, pattern := range d.Exclude {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// old tells if the file at path is older than d ignores at now.
+func (d *Discovery) old(path string, now time.Time) bool {
+	if d.IgnoreOlder == 0 {
+		return false
+	}
+	fi, err := os.Stat(path)
+	return err == nil && now.Sub(fi.ModTime()) > d.IgnoreOlder
+}
@@ -0,0 +1,362 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	"github.com/aybabtme/tailf/tailftest"
+)
+
+func TestDiscover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_discover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	create := func(name string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	api, db := create("api.log"), create("db.log")
+	create("api.log.1")
+	create("old.log")
+
+	set := tailf.NewFollowerSet()
+	events := set.Discoveries()
+	expect := func(want tailf.Event, path string) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if ev.Event != want || ev.Path != path {
+				t.Fatalf("wanted %#v for %s, got %#v for %s", want, path, ev.Event, ev.Path)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("wanted %#v for %s, got nothing", want, path)
+		}
+	}
+	list := func(want ...string) {
+		t.Helper()
+		if got := set.List(); !reflect.DeepEqual(got, want) {
+			t.Errorf("wanted %v followed, got %v", want, got)
+		}
+	}
+
+	go func() {
+		err := set.Discover(tailf.Discovery{
+			Include:  []string{filepath.Join(dir, "*.log")},
+			Exclude:  []string{"old.*"},
+			Interval: 10 * time.Millisecond,
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	added := map[string]tailf.Event{}
+	for i := 0; i < 2; i++ {
+		ev := <-events
+		added[ev.Path] = ev.Event
+	}
+	matched := tailf.FileAdded{Reason: tailf.DiscoveryMatched}
+	if added[api] != matched || added[db] != matched {
+		t.Errorf("wanted %s and %s matched, got %v", api, db, added)
+	}
+	list(api, db)
+
+	// files created since are found
+	web := create("web.log")
+	expect(tailf.FileAdded{Reason: tailf.DiscoveryCreated}, web)
+	list(api, db, web)
+
+	// files that don't match anymore are removed
+	go func() {
+		err := set.Discover(tailf.Discovery{
+			Include:  []string{dir},
+			Exclude:  []string{"db.*", "old.*", "*.1"},
+			Interval: 10 * time.Millisecond,
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	expect(tailf.FileRemoved{Reason: tailf.DiscoveryExcluded}, db)
+	list(api, web)
+
+	if err := os.Remove(web); err != nil {
+		t.Fatal(err)
+	}
+	expect(tailf.FileRemoved{Reason: tailf.DiscoveryDeleted}, web)
+	list(api)
+
+	go set.Close()
+	for range events {
+	}
+}
+
+func TestDiscoverReconfigure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_discover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	logs, txt := filepath.Join(dir, "app.log"), filepath.Join(dir, "app.txt")
+	for _, path := range []string{logs, txt} {
+		if err := ioutil.WriteFile(path, []byte("old\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	set := tailf.NewFollowerSet()
+	defer set.Close()
+	events := set.Discoveries()
+	if err := set.Discover(tailf.Discovery{Include: []string{filepath.Join(dir, "*.log")}}); err != nil {
+		t.Fatal(err)
+	}
+	<-events
+
+	// the files matching the new patterns were there already, and are
+	// followed from their end as well
+	err = set.Discover(tailf.Discovery{Include: []string{filepath.Join(dir, "*.log"), filepath.Join(dir, "*.txt")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev := <-events; ev.Path != txt || ev.Event != (tailf.FileAdded{Reason: tailf.DiscoveryMatched}) {
+		t.Errorf("wanted %s matched, got %#v for %s", txt, ev.Event, ev.Path)
+	}
+	if want, got := []string{logs, txt}, set.List(); !reflect.DeepEqual(got, want) {
+		t.Errorf("wanted %v followed, got %v", want, got)
+	}
+	if lag, err := set.Get(txt).Lag(); err != nil || lag != 0 {
+		t.Errorf("wanted %s followed from its end, got a lag of %d (%v)", txt, lag, err)
+	}
+}
+
+func TestDiscoverMany(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_discover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	const n = 100
+	for i := 0; i < n; i++ {
+		if err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.log", i)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	set := tailf.NewFollowerSet()
+	defer set.Close()
+	events := set.Discoveries()
+	// more files are found than the channel holds, which is only read
+	// once discovery started
+	if err := set.Discover(tailf.Discovery{Include: []string{dir}}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		select {
+		case <-events:
+		case <-time.After(time.Second):
+			t.Fatalf("wanted %d files added, got %d", n, i)
+		}
+	}
+}
+
+func TestDiscoverIgnoreOlder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_discover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	archive, live := filepath.Join(dir, "archive.log"), filepath.Join(dir, "live.log")
+	for _, name := range []string{archive, live} {
+		if err := ioutil.WriteFile(name, []byte("hello\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * time.Hour)
+
+	for _, follow := range []bool{false, true} {
+		if err := os.Chtimes(archive, old, old); err != nil {
+			t.Fatal(err)
+		}
+		set := tailf.NewFollowerSet()
+		err := set.Discover(tailf.Discovery{
+			Include:       []string{dir},
+			Interval:      10 * time.Millisecond,
+			FromStart:     true,
+			IgnoreOlder:   time.Hour,
+			FollowIgnored: follow,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{live}
+		if follow {
+			want = []string{archive, live}
+		}
+		if got := set.List(); !reflect.DeepEqual(got, want) {
+			t.Errorf("wanted %v followed, got %v", want, got)
+		}
+		if follow {
+			// only what is written from then on is read
+			if lag, err := set.Get(archive).Lag(); err != nil || lag != 0 {
+				t.Errorf("wanted %s followed from its end, got a lag of %d (%v)", archive, lag, err)
+			}
+		} else {
+			// once modified, the archive is read from where it was
+			// skipped
+			file, err := os.OpenFile(archive, os.O_WRONLY|os.O_APPEND, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = file.WriteString("world\n")
+			file.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for set.Get(archive) == nil {
+				time.Sleep(time.Millisecond)
+			}
+			line, err := set.Get(archive).ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line != "world\n" {
+				t.Errorf("wanted %q read from %s, got %q", "world\n", archive, line)
+			}
+		}
+		if err := set.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDiscoverDeleteGrace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_discover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	set := tailf.NewFollowerSet()
+	defer set.Close()
+	events := set.Discoveries()
+	err = set.Discover(tailf.Discovery{
+		Include:     []string{dir},
+		Interval:    10 * time.Millisecond,
+		DeleteGrace: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-events
+	follow := set.Get(path)
+
+	// the writer flushes its last lines once the file was deleted
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, err := file.WriteString("last\n"); err != nil {
+		t.Fatal(err)
+	}
+	line, err := follow.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "last\n" {
+		t.Errorf("wanted %q, got %q", "last\n", line)
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("wanted nothing within the grace period, got %#v", ev.Event)
+	default:
+	}
+
+	select {
+	case ev := <-events:
+		if want := (tailf.FileRemoved{Reason: tailf.DiscoveryDeleted}); ev.Event != want {
+			t.Errorf("wanted %#v, got %#v", want, ev.Event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wanted the file removed once the grace period passed")
+	}
+}
+
+func TestDiscoverClock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_discover")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	archive, live := filepath.Join(dir, "archive.log"), filepath.Join(dir, "live.log")
+	if err := ioutil.WriteFile(archive, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the set lives two hours ahead, the archive looks old to it
+	clock := tailftest.NewClock(time.Now().Add(2 * time.Hour))
+
+	set := tailf.NewFollowerSet()
+	defer set.Close()
+	set.SetClock(clock)
+	events := set.Discoveries()
+	err = set.Discover(tailf.Discovery{
+		Include:     []string{dir},
+		Interval:    time.Hour,
+		IgnoreOlder: time.Hour,
+		DeleteGrace: 2 * time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := set.List(); len(got) != 0 {
+		t.Fatalf("wanted the archive ignored, got %v followed", got)
+	}
+	expect := func(want tailf.Event) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if ev.Event != want || ev.Path != live {
+				t.Fatalf("wanted %#v for %s, got %#v for %s", want, live, ev.Event, ev.Path)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("wanted %#v for %s, got nothing", want, live)
+		}
+	}
+
+	// an hour between looks takes no time
+	if err := ioutil.WriteFile(live, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(live, clock.Now(), clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	expect(tailf.FileAdded{Reason: tailf.DiscoveryCreated})
+
+	// nor does the grace period of deleted files
+	if err := os.Remove(live); err != nil {
+		t.Fatal(err)
+	}
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Hour)
+	expect(tailf.FileRemoved{Reason: tailf.DiscoveryDeleted})
+}
@@ -0,0 +1,47 @@
+package tailf
+
+import "fmt"
+
+// FileError is the error following a file failed with. It tells which
+// file failed and how far it was read, and can be told apart with
+// errors.Is by its Kind, one of the Err variables of the package, as well
+// as by the error that caused it, which it wraps.
+type FileError struct {
+	// Path is the followed path.
+	Path string
+	// Device and Inode identify the file on the OS filesystem, if it
+	// was opened from it.
+	Device, Inode uint64
+	// Offset is how far the file was read.
+	Offset int64
+	// Kind is what failed, such as ErrMountLost.
+	Kind error
+	// Err is the error that caused the failure, if any.
+	Err error
+}
+
+func (e *FileError) Error() string {
+	msg := fmt.Sprintf("%v: %s (offset %d)", e.Kind, e.Path, e.Offset)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *FileError) Unwrap() error { return e.Err }
+
+// Is tells if target is the Kind of e.
+func (e *FileError) Is(target error) bool { return target == e.Kind }
+
+// fileError returns a FileError of the given kind, caused by err, about
+// the file being read. The follower must be locked.
+func (f *Follower) fileError(kind, err error) error {
+	return &FileError{
+		Path:   f.filename,
+		Device: f.gen.Device,
+		Inode:  f.gen.Inode,
+		Offset: f.position.pos,
+		Kind:   kind,
+		Err:    err,
+	}
+}
@@ -0,0 +1,184 @@
+package tailf
+
+// Event is a notable occurrence in the followed stream, delivered on the
+// channel returned by Follower.Events.
+type Event interface {
+	isEvent()
+}
+
+// DataChunk carries followed data when the follower is configured with
+// WithEventStream.
+type DataChunk struct {
+	Bytes []byte
+	// Offset is the offset of Bytes in the file they were read from.
+	Offset int64
+}
+
+// Rotated is emitted when the reader moves on to the new file at the
+// followed path, after having read everything left in the rotated one.
+type Rotated struct {
+	// From is the end of the rotated file, To the start of the new one.
+	From, To Cursor
+}
+
+// Truncated is emitted when the reader moves back to the start of the
+// followed file, after it was truncated.
+type Truncated struct {
+	// From is where the file ended before it was truncated, To is the
+	// start of the file.
+	From, To Cursor
+}
+
+// Error is emitted when following fails.
+type Error struct {
+	Err error
+}
+
+// Binary is emitted when the BinaryFlag policy finds non-text content in
+// the followed stream.
+type Binary struct {
+	// Len is the number of non-text bytes that were found.
+	Len int
+}
+
+// Checkpoint is emitted periodically as data is read, as configured with
+// WithCheckpoints. It carries the cursor right after the data that was
+// read, which consumers can persist without polling Follower.Cursor.
+type Checkpoint struct {
+	Cursor Cursor
+}
+
+// Skipped is emitted when the reader skips to the end of the file, with
+// Follower.SkipToLive or because it fell behind more than WithMaxLag
+// allows.
+type Skipped struct {
+	// From is where the reader was, To the end of the file it skipped to.
+	From, To Cursor
+}
+
+// Dropped is emitted when data left unread in a rotated file was dropped
+// because it didn't fit in the rotation buffer, as configured with
+// WithDropOldest.
+type Dropped struct {
+	// Bytes and Lines are how much was dropped.
+	Bytes, Lines int64
+}
+
+// Heartbeat is emitted when nothing was read for a while, as configured
+// with WithHeartbeat.
+type Heartbeat struct {
+	// Cursor is the position of the reader, Size the size of the file it
+	// reads.
+	Cursor Cursor
+	Size   int64
+}
+
+func (DataChunk) isEvent()  {}
+func (Rotated) isEvent()    {}
+func (Truncated) isEvent()  {}
+func (Error) isEvent()      {}
+func (Binary) isEvent()     {}
+func (Checkpoint) isEvent() {}
+func (Skipped) isEvent()    {}
+func (Dropped) isEvent()    {}
+func (Heartbeat) isEvent()  {}
+
+const eventBufferSize = 64
+
+// Events returns the channel on which the follower reports events. Events
+// are emitted as the data is read, so that they appear at the position in
+// the stream where they occurred.
+//
+// By default, events are dropped rather than blocking the follower if the
+// channel is full, and the channel is closed when the follower is closed.
+// With WithEventStream, the channel carries the data as well and must be
+// drained until it is closed.
+func (f *Follower) Events() <-chan Event { return f.events }
+
+// emit delivers ev, without blocking unless the follower is streaming its
+// data as events.
+func (f *Follower) emit(ev Event) {
+	f.evmu.Lock()
+	defer f.evmu.Unlock()
+	if f.eventsClosed {
+		return
+	}
+	if f.opts.eventStream {
+		f.events <- ev
+		return
+	}
+	select {
+	case f.events <- ev:
+	default:
+	}
+}
+
+// queue queues ev to be emitted by emitPending, for the events occurring
+// while the follower is locked, since emitting blocks with
+// WithEventStream until the consumer, who may be waiting for the lock,
+// reads the channel. The follower must be locked.
+func (f *Follower) queue(ev Event) {
+	f.pending = append(f.pending, ev)
+}
+
+// emitPending emits the events queued since the last call, in order. The
+// follower mustn't be locked.
+func (f *Follower) emitPending() {
+	f.emitmu.Lock()
+	defer f.emitmu.Unlock()
+	f.mu.Lock()
+	pending := f.pending
+	f.pending = nil
+	f.mu.Unlock()
+	for _, ev := range pending {
+		f.emit(ev)
+	}
+}
+
+func (f *Follower) closeEvents() {
+	f.evmu.Lock()
+	defer f.evmu.Unlock()
+	if !f.eventsClosed {
+		f.eventsClosed = true
+		close(f.events)
+	}
+}
+
+// crossRotation queues the event marking the move of the reader from the
+// previous file to the current one. The follower must be locked.
+func (f *Follower) crossRotation() {
+	from := *f.prev
+	f.prev = nil
+	to := f.cursor()
+	f.countRotation(f.prevTruncated)
+	if f.prevTruncated {
+		if f.opts.hooks.OnTruncate != nil {
+			f.opts.hooks.OnTruncate(from, to)
+		}
+		f.queue(Truncated{From: from, To: to})
+	} else {
+		if f.opts.hooks.OnRotate != nil {
+			f.opts.hooks.OnRotate(from, to)
+		}
+		f.queue(Rotated{From: from, To: to})
+	}
+}
+
+// streamEvents reads the followed data and emits it as DataChunk events,
+// until the follower is closed or fails.
+func (f *Follower) streamEvents() {
+	defer f.closeEvents()
+	buf := make([]byte, 32*1024)
+	for {
+		var c Cursor
+		n, err := f.read(buf, &c)
+		if n != 0 {
+			chunk := append([]byte(nil), buf[:n]...)
+			f.emit(DataChunk{Bytes: chunk, Offset: c.Offset - int64(n)})
+		}
+		if err != nil {
+			// errors other than io.EOF were emitted by read
+			return
+		}
+	}
+}
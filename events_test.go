@@ -0,0 +1,224 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestCheckpointEvents(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("0123456789abcdefghij"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true, tailf.WithCheckpoints(tailf.CheckpointPolicy{Bytes: 10}))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		for i := 0; i < 4; i++ {
+			if _, err := io.ReadFull(follow, make([]byte, 5)); err != nil {
+				return err
+			}
+		}
+
+		for _, want := range []int64{10, 20} {
+			select {
+			case ev := <-follow.Events():
+				cp, ok := ev.(tailf.Checkpoint)
+				if !ok || cp.Cursor.Offset != want {
+					t.Errorf("wanted a checkpoint at offset %d, got %#v", want, ev)
+				}
+			default:
+				t.Errorf("wanted a checkpoint at offset %d", want)
+			}
+		}
+		return nil
+	})
+}
+
+func TestEventStreamInterleavesRotations(t *testing.T) {
+	withTempFile(t, time.Millisecond*500, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello,"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true, tailf.WithEventStream())
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+
+		if err := os.Rename(filename, filename+".1"); err != nil {
+			return err
+		}
+		file, err = os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := file.WriteString(" world!"); err != nil {
+			return err
+		}
+
+		var got []string
+		for ev := range follow.Events() {
+			switch ev := ev.(type) {
+			case tailf.DataChunk:
+				got = append(got, fmt.Sprintf("%q@%d", ev.Bytes, ev.Offset))
+			case tailf.Rotated:
+				got = append(got, fmt.Sprintf("rotated@%d", ev.From.Offset))
+			default:
+				got = append(got, fmt.Sprintf("%T", ev))
+			}
+			if len(got) == 3 {
+				follow.Close()
+			}
+		}
+
+		want := fmt.Sprint([]string{`"hello,"@0`, "rotated@6", `" world!"@0`})
+		if fmt.Sprint(got) != want {
+			t.Errorf("wanted events %v, got %v", want, got)
+		}
+		return nil
+	})
+}
+
+func TestEventStreamFullBuffer(t *testing.T) {
+	withTempFile(t, 2*time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello,"); err != nil {
+			return err
+		}
+		follow, err := tailf.Follow(filename, true, tailf.WithEventStream(), tailf.WithEventBuffer(1))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+
+		// the rotation is reached while the channel is full
+		if err := os.Rename(filename, filename+".1"); err != nil {
+			return err
+		}
+		file, err = os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := file.WriteString(" world!"); err != nil {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		done := make(chan struct{})
+		go func() {
+			follow.Cursor()
+			follow.Lag()
+			follow.Close()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			return fmt.Errorf("wanted the follower usable before its events are read")
+		}
+		for range follow.Events() {
+		}
+		return nil
+	})
+}
+
+func TestCheckpointEventsFullBuffer(t *testing.T) {
+	withTempFile(t, 2*time.Second, func(t *testing.T, filename string, file *os.File) error {
+		follow, err := tailf.Follow(filename, true, tailf.WithEventStream(), tailf.WithEventBuffer(1),
+			tailf.WithCheckpoints(tailf.CheckpointPolicy{Bytes: 1}))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+
+		// checkpoints are reached while the channel is full
+		stop := make(chan struct{})
+		written := make(chan struct{})
+		go func() {
+			defer close(written)
+			for {
+				select {
+				case <-stop:
+					return
+				case <-time.After(time.Millisecond):
+				}
+				if _, err := file.WriteString("hello\n"); err != nil {
+					return
+				}
+			}
+		}()
+		defer func() {
+			close(stop)
+			<-written
+		}()
+		time.Sleep(50 * time.Millisecond)
+		<-follow.Events()
+		time.Sleep(50 * time.Millisecond)
+
+		done := make(chan struct{})
+		go func() {
+			follow.Cursor()
+			follow.Close()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			return fmt.Errorf("wanted the follower usable before its events are read")
+		}
+		for range follow.Events() {
+		}
+		return nil
+	})
+}
+
+func TestEventBuffer(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		follow, err := tailf.Follow(filename, false, tailf.WithEventBuffer(3))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if got := cap(follow.Events()); got != 3 {
+			t.Errorf("wanted an events channel of capacity 3, got %d", got)
+		}
+		return nil
+	})
+}
+
+func TestHeartbeat(t *testing.T) {
+	withTempFile(t, time.Millisecond*500, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true, tailf.WithHeartbeat(20*time.Millisecond))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if _, err := io.ReadFull(follow, make([]byte, 5)); err != nil {
+			return err
+		}
+
+		for ev := range follow.Events() {
+			if hb, ok := ev.(tailf.Heartbeat); ok {
+				if hb.Cursor.Offset != 5 || hb.Size != 13 {
+					t.Errorf("wanted a heartbeat at offset 5 of 13 bytes, got %+v", hb)
+				}
+				return nil
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,183 @@
+package tailf
+
+import (
+	"os"
+	"sort"
+	"time"
+)
+
+// openInterval is how often a FollowerSet with a limit of open files
+// checks the files of the followers it evicted, unless set otherwise.
+const openInterval = time.Second
+
+// evicted is a file of a FollowerSet whose follower was closed to stay
+// under its limit of open files.
+type evicted struct {
+	cursor  Cursor    // where its follower stopped
+	mtime   time.Time // modification time of the file then
+	labels  Labels
+	closing *Follower // its follower, until it was closed
+}
+
+// SetMaxOpen limits how many followers of the set hold their file open at
+// once to max, so that thousands of files can be followed under the
+// default limit of open files of the process. Past it, the least recently
+// active followers that were read to the end of their file are evicted:
+// they are closed, remembering their cursor, and resumed from it once
+// their file changes, which is checked every interval. A max of 0 lifts
+// the limit.
+//
+// While evicted, a file is still listed by the set, but Get returns nil
+// for it, and the follower it returned before reads io.EOF, so consumers
+// of a set with a limit read it from its Events.
+func (s *FollowerSet) SetMaxOpen(max int, interval time.Duration) {
+	if interval <= 0 {
+		interval = openInterval
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	first := s.openInterval == 0
+	s.maxOpen, s.openInterval = max, interval
+	if first {
+		s.loops.Add(1)
+		go s.resumeLoop()
+	}
+	s.mu.Unlock()
+
+	s.evict()
+}
+
+// resumeLoop resumes the followers of the evicted files that changed every
+// interval, and evicts others in their place, until the set is closed.
+func (s *FollowerSet) resumeLoop() {
+	defer s.loops.Done()
+	for {
+		s.mu.Lock()
+		interval := s.openInterval
+		s.mu.Unlock()
+		if !s.wait(interval) {
+			return
+		}
+		s.resume()
+		s.evict()
+	}
+}
+
+// evict closes the least recently active followers that were read to the
+// end of their file, until the set is under its limit of open files or no
+// such follower is left.
+func (s *FollowerSet) evict() {
+	s.openmu.Lock()
+	defer s.openmu.Unlock()
+
+	type candidate struct {
+		path   string
+		f      *Follower
+		active time.Time
+	}
+	s.mu.Lock()
+	excess := len(s.followers) - s.maxOpen
+	if s.maxOpen == 0 || excess <= 0 {
+		s.mu.Unlock()
+		return
+	}
+	var candidates []candidate
+	for path, f := range s.followers {
+		stats := f.Stats()
+		if stats.Lag != 0 {
+			continue
+		}
+		active := s.since[path]
+		if stats.LastEvent.After(active) {
+			active = stats.LastEvent
+		}
+		candidates = append(candidates, candidate{path, f, active})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].active.Before(candidates[j].active)
+	})
+	if len(candidates) > excess {
+		candidates = candidates[:excess]
+	}
+	parked := make([]*evicted, len(candidates))
+	for i, c := range candidates {
+		delete(s.followers, c.path)
+		parked[i] = &evicted{labels: c.f.Labels(), closing: c.f}
+		s.evicted[c.path] = parked[i]
+	}
+	s.mu.Unlock()
+
+	for i, c := range candidates {
+		_ = closeFollower(c.f)
+		stats := c.f.Stats()
+		stats.OpenFiles, stats.Lag = 0, 0
+		s.mu.Lock()
+		if s.evicted[c.path] == parked[i] {
+			parked[i].cursor = c.f.Cursor()
+			if fi, err := os.Stat(c.path); err == nil {
+				parked[i].mtime = fi.ModTime()
+			}
+			retired := s.retired[c.path]
+			retired.add(stats)
+			s.retired[c.path] = retired
+			parked[i].closing = nil
+		}
+		s.mu.Unlock()
+	}
+}
+
+// resume follows the evicted files that changed again, from where their
+// follower stopped.
+func (s *FollowerSet) resume() {
+	s.openmu.Lock()
+	defer s.openmu.Unlock()
+
+	s.mu.Lock()
+	parked := make(map[string]*evicted, len(s.evicted))
+	for path, e := range s.evicted {
+		parked[path] = e
+	}
+	s.mu.Unlock()
+
+	for path, e := range parked {
+		if !e.changed(path) {
+			continue
+		}
+		s.mu.Lock()
+		opts := append(s.opts[:len(s.opts):len(s.opts)], s.extra[path]...)
+		s.mu.Unlock()
+		f, err := Resume(path, e.cursor, opts...)
+		if err != nil {
+			// tried again on the next check
+			continue
+		}
+		s.mu.Lock()
+		if s.closed || s.evicted[path] != e {
+			// closed or removed meanwhile
+			s.mu.Unlock()
+			_ = closeFollower(f)
+			continue
+		}
+		delete(s.evicted, path)
+		s.followers[path] = f
+		s.since[path] = s.clock.Now()
+		if s.events != nil {
+			s.forward(path, f)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// changed tells if the file at path changed since its follower was
+// evicted. A file that can't be found is left evicted, until it is created
+// again or removed from the set.
+func (e *evicted) changed(path string) bool {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return fi.Size() != e.cursor.Offset || !fi.ModTime().Equal(e.mtime)
+}
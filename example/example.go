@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"github.com/aybabtme/tailf"
 	"io"
 	"io/ioutil"
@@ -34,12 +36,24 @@ func main() {
 		}
 	}()
 
-	_, err = io.Copy(os.Stdout, follow)
-	if err != nil {
+	if err := copyFollowed(follow); err != nil {
 		log.Fatalf("couldn't read from follower: %v", err)
 	}
 }
 
+// copyFollowed prints what is written to the followed file until the
+// follower is closed. Should following fail, the error is a
+// *tailf.FileError telling which file failed and how far it was read,
+// and errors.Is tells what failed.
+func copyFollowed(follow *tailf.Follower) error {
+	_, err := io.Copy(os.Stdout, follow)
+	var ferr *tailf.FileError
+	if errors.Is(err, tailf.ErrPermission) && errors.As(err, &ferr) {
+		return fmt.Errorf("check the permissions of %s: %v", ferr.Path, err)
+	}
+	return err
+}
+
 func makeTempFile() *os.File {
 	f, err := ioutil.TempFile(os.TempDir(), "tailf_example")
 	if err != nil {
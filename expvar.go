@@ -0,0 +1,16 @@
+package tailf
+
+import "expvar"
+
+// PublishExpvar publishes the stats of the followers of c with expvar,
+// so that they are served on /debug/vars: added up as prefix+".total",
+// and by followed path as prefix+".followers". Like expvar.Publish, it
+// panics if either name is already taken.
+func PublishExpvar(prefix string, c *Checkpointer) {
+	expvar.Publish(prefix+".total", expvar.Func(func() interface{} {
+		return c.Stats()
+	}))
+	expvar.Publish(prefix+".followers", expvar.Func(func() interface{} {
+		return c.FollowerStats()
+	}))
+}
@@ -0,0 +1,59 @@
+package tailf_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_expvar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := tailf.NewCheckpointer(tailf.NewFileStore(filepath.Join(dir, "state")), time.Hour)
+	defer c.Close()
+	// expvar names can't be reused, even by the same test run again
+	prefix := fmt.Sprintf("tailf_test_%d", time.Now().UnixNano())
+	tailf.PublishExpvar(prefix, c)
+
+	filename := filepath.Join(dir, "a.log")
+	if err := ioutil.WriteFile(filename, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	follow, err := tailf.Follow(filename, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+	if _, err := io.ReadFull(follow, make([]byte, 6)); err != nil {
+		t.Fatal(err)
+	}
+	c.Add(follow)
+
+	var total tailf.Stats
+	if err := json.Unmarshal([]byte(expvar.Get(prefix+".total").String()), &total); err != nil {
+		t.Fatal(err)
+	}
+	if total.Bytes != 6 || total.Lines != 1 {
+		t.Errorf("wanted 6 bytes and 1 line read, got %+v", total)
+	}
+
+	var followers map[string]tailf.Stats
+	if err := json.Unmarshal([]byte(expvar.Get(prefix+".followers").String()), &followers); err != nil {
+		t.Fatal(err)
+	}
+	if s, ok := followers[filename]; !ok || s.Bytes != 6 {
+		t.Errorf("wanted stats for %q, got %+v", filename, followers)
+	}
+}
@@ -0,0 +1,31 @@
+package tailf
+
+// dropBehindChunk is how much must have been read since the page cache
+// was last told to drop what's behind the cursor, before telling it again.
+const dropBehindChunk = 1 << 20
+
+// adviseOpen tells the kernel that file, just opened, will be read
+// sequentially, if the follower was asked to be friendly to the page
+// cache.
+func (f *Follower) adviseOpen() {
+	f.dropped = 0
+	if file := f.osFile(); file != nil && f.opts.fadvise {
+		fadviseSequential(file)
+	}
+}
+
+// dropBehind tells the kernel that what was read from the file won't be
+// read again, so that its pages can be evicted from the page cache before
+// those of the applications running alongside.
+func (f *Follower) dropBehind() {
+	file := f.osFile()
+	if !f.opts.fadvise || f.prev != nil || file == nil {
+		return
+	}
+	off := f.offset()
+	if off-f.dropped < dropBehindChunk {
+		return
+	}
+	fadviseDontNeed(file, f.dropped, off-f.dropped)
+	f.dropped = off
+}
@@ -0,0 +1,15 @@
+package tailf
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func fadviseSequential(file *os.File) {
+	_ = unix.Fadvise(int(file.Fd()), 0, 0, unix.FADV_SEQUENTIAL)
+}
+
+func fadviseDontNeed(file *os.File, off, n int64) {
+	_ = unix.Fadvise(int(file.Fd()), off, n, unix.FADV_DONTNEED)
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package tailf
+
+import "os"
+
+// posix_fadvise is only used on Linux.
+
+func fadviseSequential(file *os.File) {}
+
+func fadviseDontNeed(file *os.File, off, n int64) {}
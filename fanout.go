@@ -0,0 +1,167 @@
+package tailf
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrReaderBehind is returned by the readers of a Fanout that fell so far
+// behind that the data they had left to read was dropped.
+var ErrReaderBehind = errors.New("tailf: reader fell behind")
+
+// fanoutChunk is the size of the chunks read from the follower.
+const fanoutChunk = 32 * 1024
+
+// Fanout shares a follower among readers, each reading the followed data
+// at its own pace, from its own offset, so that consumers of the same
+// file don't each need a follower of their own, with its watch and file.
+//
+// The data is read from the follower as it is written, and retained until
+// every reader read it, up to retain bytes, past which the oldest data is
+// dropped. A reader that hadn't read it yet gets ErrReaderBehind.
+type Fanout struct {
+	f      *Follower
+	retain int
+
+	mu      sync.Mutex
+	grown   *sync.Cond // signaled when data was read, or reading ended
+	buf     []byte     // data retained, starting at offset base
+	base    int64
+	readers map[*FanoutReader]struct{}
+	err     error // error reading the follower ended with, io.EOF once closed
+}
+
+// NewFanout reads f in the background, for the readers of the returned
+// Fanout, retaining up to retain bytes they didn't all read. The fanout
+// owns f, which is closed with it.
+func NewFanout(f *Follower, retain int) *Fanout {
+	x := &Fanout{f: f, retain: retain, readers: make(map[*FanoutReader]struct{})}
+	x.grown = sync.NewCond(&x.mu)
+	go x.pump()
+	return x
+}
+
+// pump reads the follower until it is closed or fails.
+func (x *Fanout) pump() {
+	buf := make([]byte, fanoutChunk)
+	for {
+		n, err := x.f.Read(buf)
+		x.mu.Lock()
+		x.buf = append(x.buf, buf[:n]...)
+		x.trim()
+		if err != nil {
+			x.err = err
+		}
+		x.mu.Unlock()
+		if n != 0 || err != nil {
+			x.grown.Broadcast()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// trim drops the data that every reader read, and the oldest data past
+// what is retained. The fanout must be locked.
+func (x *Fanout) trim() {
+	end := x.base + int64(len(x.buf))
+	read := end
+	for r := range x.readers {
+		if r.offset < read {
+			read = r.offset
+		}
+	}
+	if retained := end - int64(x.retain); read < retained {
+		read = retained
+	}
+	if read > x.base {
+		x.buf = x.buf[read-x.base:]
+		x.base = read
+	}
+}
+
+// NewReader returns a reader of the data read from the follower from now
+// on.
+func (x *Fanout) NewReader() *FanoutReader {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.add(x.base + int64(len(x.buf)))
+}
+
+// add adds a reader reading from offset. The fanout must be locked.
+func (x *Fanout) add(offset int64) *FanoutReader {
+	r := &FanoutReader{x: x, offset: offset}
+	x.readers[r] = struct{}{}
+	return r
+}
+
+// Close closes the follower, after which readers read what was retained
+// for them, and then io.EOF.
+func (x *Fanout) Close() error {
+	return x.f.Close()
+}
+
+// FanoutReader is a reader of the data of a Fanout, with an offset of its
+// own.
+type FanoutReader struct {
+	x      *Fanout
+	offset int64
+	closed bool
+}
+
+// Read reads the data following the offset of the reader, blocking until
+// it is read from the follower.
+func (r *FanoutReader) Read(b []byte) (int, error) {
+	x := r.x
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for {
+		if r.closed {
+			return 0, io.EOF
+		}
+		if r.offset < x.base {
+			return 0, ErrReaderBehind
+		}
+		if left := x.buf[r.offset-x.base:]; len(left) != 0 {
+			n := copy(b, left)
+			r.offset += int64(n)
+			x.trim()
+			return n, nil
+		}
+		if x.err != nil {
+			return 0, x.err
+		}
+		x.grown.Wait()
+	}
+}
+
+// Offset returns the offset of the next byte the reader reads, among the
+// data read from the follower since the fanout started.
+func (r *FanoutReader) Offset() int64 {
+	r.x.mu.Lock()
+	defer r.x.mu.Unlock()
+	return r.offset
+}
+
+// Clone returns a reader reading on from the offset of r, independently
+// of it.
+func (r *FanoutReader) Clone() *FanoutReader {
+	r.x.mu.Lock()
+	defer r.x.mu.Unlock()
+	return r.x.add(r.offset)
+}
+
+// Close stops reading, so that the data retained for r can be dropped.
+// Reads return io.EOF from then on.
+func (r *FanoutReader) Close() error {
+	x := r.x
+	x.mu.Lock()
+	r.closed = true
+	delete(x.readers, r)
+	x.trim()
+	x.mu.Unlock()
+	x.grown.Broadcast()
+	return nil
+}
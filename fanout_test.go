@@ -0,0 +1,85 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestFanout(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		fanout := tailf.NewFanout(follow, 1024)
+		first, second := fanout.NewReader(), fanout.NewReader()
+
+		read := func(r io.Reader, want string) {
+			t.Helper()
+			got := make([]byte, len(want))
+			if _, err := io.ReadFull(r, got); err != nil || string(got) != want {
+				t.Errorf("wanted to read %q, got %q, %v", want, got, err)
+			}
+		}
+		if _, err := file.WriteString("hello, world!"); err != nil {
+			return err
+		}
+		// each reader reads at its own pace
+		read(first, "hello, world!")
+		read(second, "hello,")
+		clone := second.Clone()
+		read(second, " world!")
+		read(clone, " world!")
+		if off := clone.Offset(); off != 13 {
+			t.Errorf("wanted the clone at offset 13, got %d", off)
+		}
+
+		// a reader left behind doesn't hold up the others
+		if _, err := file.WriteString("bonjour!"); err != nil {
+			return err
+		}
+		read(first, "bonjour!")
+		if err := clone.Close(); err != nil {
+			return err
+		}
+		if err := fanout.Close(); err != nil {
+			return err
+		}
+		rest, err := ioutil.ReadAll(second)
+		if string(rest) != "bonjour!" || err != nil {
+			t.Errorf("wanted to read %q, got %q, %v", "bonjour!", rest, err)
+		}
+		return nil
+	})
+}
+
+func TestFanoutBehind(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		fanout := tailf.NewFanout(follow, 8)
+		defer fanout.Close()
+		slow, fast := fanout.NewReader(), fanout.NewReader()
+
+		for _, s := range []string{"hello, ", "world!"} {
+			if _, err := file.WriteString(s); err != nil {
+				return err
+			}
+			if _, err := io.ReadFull(fast, make([]byte, len(s))); err != nil {
+				return err
+			}
+		}
+		if _, err := slow.Read(make([]byte, 1)); err != tailf.ErrReaderBehind {
+			t.Errorf("wanted %v, got %v", tailf.ErrReaderBehind, err)
+		}
+		return nil
+	})
+}
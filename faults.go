@@ -0,0 +1,79 @@
+//go:build tailffaults
+// +build tailffaults
+
+package tailf
+
+import (
+	"sync"
+	"time"
+)
+
+// Faults injects the failures a follower recovers from, so that the
+// recovery of applications built on tailf can be tested. They are only
+// available in builds tagged tailffaults, such as with
+// `go test -tags tailffaults`. Faults are safe for concurrent use, and
+// can be set while following.
+type Faults struct {
+	mu         sync.Mutex
+	openFails  int
+	openErr    error
+	dropEvents int
+	delay      time.Duration
+}
+
+// WithFaults injects fl into the follower.
+func WithFaults(fl *Faults) Option {
+	return func(o *options) {
+		o.inject = fl
+	}
+}
+
+// FailOpens makes the next n opens of the followed path fail with err,
+// once the follower started.
+func (fl *Faults) FailOpens(n int, err error) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	fl.openFails, fl.openErr = n, err
+}
+
+// DropEvents drops the next n events notifying changes to the followed
+// path, as if they were lost.
+func (fl *Faults) DropEvents(n int) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	fl.dropEvents = n
+}
+
+// DelayReopen delays every reopen of the followed path by d, as if the
+// new file were slow to open.
+func (fl *Faults) DelayReopen(d time.Duration) {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	fl.delay = d
+}
+
+func (fl *Faults) failOpen(name string) error {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	if fl.openFails == 0 {
+		return nil
+	}
+	fl.openFails--
+	return fl.openErr
+}
+
+func (fl *Faults) dropEvent() bool {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	if fl.dropEvents == 0 {
+		return false
+	}
+	fl.dropEvents--
+	return true
+}
+
+func (fl *Faults) reopenDelay() time.Duration {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+	return fl.delay
+}
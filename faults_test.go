@@ -0,0 +1,74 @@
+//go:build tailffaults
+// +build tailffaults
+
+package tailf_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+// rotate moves filename away and writes data to a new file in its place.
+func rotate(filename, data string) error {
+	if err := os.Rename(filename, filename+".1"); err != nil {
+		return err
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(data)
+	return err
+}
+
+func TestFaultsFailOpens(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		faults := &tailf.Faults{}
+		follow, err := tailf.Follow(filename, false, tailf.WithFaults(faults))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		injected := errors.New("injected")
+		faults.FailOpens(1, injected)
+		if err := rotate(filename, "hello"); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(follow, make([]byte, 5)); !errors.Is(err, injected) {
+			t.Errorf("wanted the injected failure, got %v", err)
+		}
+		return nil
+	})
+}
+
+func TestFaultsDelayReopen(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		faults := &tailf.Faults{}
+		faults.DelayReopen(100 * time.Millisecond)
+		follow, err := tailf.Follow(filename, false, tailf.WithFaults(faults))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		start := time.Now()
+		if err := rotate(filename, "hello"); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(follow, make([]byte, 5)); err != nil {
+			return err
+		}
+		if took := time.Since(start); took < 100*time.Millisecond {
+			t.Errorf("wanted reopening to be delayed, took %v", took)
+		}
+		return nil
+	})
+}
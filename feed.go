@@ -0,0 +1,154 @@
+package tailf
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+// feedBufferSize is how much data fed to a feedFile is held until it is
+// read from the follower, past which feeding blocks.
+const feedBufferSize = 64 * 1024
+
+// feedFile is a file whose data is fed by the follower in the
+// background, for files that can't be followed like files growing as
+// they are written to, such as named pipes. Its data is handed out by
+// Read without blocking, and offsets count the bytes that were fed.
+type feedFile struct {
+	name string
+	file File
+	info fs.FileInfo
+	done chan struct{}
+	// pump feeds the file until it is closed.
+	pump func(*Follower, *feedFile)
+
+	mu     sync.Mutex
+	room   *sync.Cond
+	buf    []byte // data fed, not read yet
+	size   int64  // how much data was fed
+	err    error
+	closed bool
+}
+
+// newFeedFile returns a feedFile fed from file by pump.
+func newFeedFile(name string, file File, pump func(*Follower, *feedFile)) (*feedFile, error) {
+	fi, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	p := &feedFile{name: name, file: file, info: fi, done: make(chan struct{}), pump: pump}
+	p.room = sync.NewCond(&p.mu)
+	return p, nil
+}
+
+func (p *feedFile) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.buf) == 0 {
+		if p.err != nil {
+			return 0, p.err
+		}
+		return 0, io.EOF
+	}
+	n := copy(b, p.buf)
+	p.discard(n)
+	return n, nil
+}
+
+// discard drops the next n bytes of the buffer.
+func (p *feedFile) discard(n int) {
+	p.buf = p.buf[n:]
+	if len(p.buf) == 0 {
+		p.buf = nil
+	}
+	p.room.Broadcast()
+}
+
+// ReadAt reads nothing, as the data fed can only be read once.
+func (p *feedFile) ReadAt(b []byte, off int64) (int, error) {
+	return 0, io.EOF
+}
+
+// Seek can only skip data, which is what moving to the end of the file
+// does.
+func (p *feedFile) Seek(offset int64, whence int) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pos := p.size - int64(len(p.buf))
+	switch whence {
+	case io.SeekCurrent:
+		offset += pos
+	case io.SeekEnd:
+		offset += p.size
+	}
+	if offset < pos || offset > p.size {
+		return 0, fmt.Errorf("%s: can only seek forward", p.name)
+	}
+	p.discard(int(offset - pos))
+	return offset, nil
+}
+
+// Stat returns the info of the file, with the size of the data fed.
+func (p *feedFile) Stat() (fs.FileInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return feedInfo{FileInfo: p.info, size: p.size}, nil
+}
+
+func (p *feedFile) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	p.room.Broadcast()
+	return p.file.Close()
+}
+
+type feedInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (fi feedInfo) Size() int64 { return fi.size }
+
+// feed appends data to p, once there is room for it, and wakes up the
+// reader. The follower is locked while the data is appended, so that
+// readers never see following end without the data fed before. It
+// returns false if p was closed.
+func (f *Follower) feed(p *feedFile, data []byte) bool {
+	p.mu.Lock()
+	for len(p.buf) >= feedBufferSize && !p.closed {
+		p.room.Wait()
+	}
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return false
+	}
+
+	f.mu.Lock()
+	p.mu.Lock()
+	p.buf = append(p.buf, data...)
+	p.size += int64(len(data))
+	p.mu.Unlock()
+	f.mu.Unlock()
+
+	f.countWrite()
+	f.notify()
+	return true
+}
+
+// failFeed makes reads of p fail with err once its data is read.
+func (f *Follower) failFeed(p *feedFile, err error) {
+	p.mu.Lock()
+	if !p.closed {
+		p.err = err
+	}
+	p.mu.Unlock()
+	f.notify()
+}
@@ -0,0 +1,94 @@
+package tailf
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// FIFOPolicy decides what a follower of a named pipe does when the
+// writers of the pipe close it.
+type FIFOPolicy int
+
+const (
+	// FIFOWait waits for a new writer to open the pipe, and keeps
+	// following it as if the writers were one.
+	FIFOWait FIFOPolicy = iota
+	// FIFOEOF stops following once the writers close the pipe, after data
+	// was read from it, so that reads reach io.EOF once the data is read.
+	FIFOEOF
+)
+
+// WithFIFOPolicy sets what happens when the writers of a followed named
+// pipe close it, FIFOWait by default.
+func WithFIFOPolicy(p FIFOPolicy) Option {
+	return func(o *options) {
+		o.fifo = p
+	}
+}
+
+// openPipe opens the named pipe called name, without waiting for it to
+// have a writer. The pipe is read in the background, as reads of a pipe
+// block, and offsets count the bytes read from it since it was opened.
+func openPipe(name string) (*feedFile, error) {
+	file, err := os.OpenFile(name, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	return newFeedFile(name, file, (*Follower).pumpPipe)
+}
+
+// isPipe tells if name is a named pipe of fsys, which are only followed
+// on the OS filesystem.
+func isPipe(fsys fs.FS, name string) bool {
+	if _, ok := fsys.(osFS); !ok {
+		return false
+	}
+	fi, err := os.Stat(name)
+	return err == nil && fi.Mode()&os.ModeNamedPipe != 0
+}
+
+// pumpPipe reads the followed pipe until the follower is closed, or the
+// writers close the pipe with FIFOEOF.
+func (f *Follower) pumpPipe(p *feedFile) {
+	wait := f.opts.pollMin
+	if wait <= 0 {
+		wait = defaultPollMin
+	}
+	buf := make([]byte, 32*1024)
+	var read bool // whether data was read since the writers last closed the pipe
+	for {
+		n, err := p.file.Read(buf)
+		if n != 0 {
+			read = true
+			if !f.feed(p, buf[:n]) {
+				return
+			}
+		}
+		switch {
+		case err == nil:
+		case err == io.EOF, errors.Is(err, syscall.EAGAIN):
+			// the pipe has no writer, or no data where it can't be
+			// polled
+			if err == io.EOF && read {
+				read = false
+				if f.opts.fifo == FIFOEOF {
+					f.opts.logger.Debug("pipe closed by its writers", "path", f.filename)
+					_ = f.watch.Close()
+					return
+				}
+				f.opts.logger.Debug("pipe closed by its writers, waiting for a new one", "path", f.filename)
+			}
+			select {
+			case <-f.clock().After(wait):
+			case <-p.done:
+				return
+			}
+		default:
+			f.failFeed(p, err)
+			return
+		}
+	}
+}
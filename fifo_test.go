@@ -0,0 +1,98 @@
+//go:build !windows
+// +build !windows
+
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+// writePipe opens the named pipe called filename, writes data to it and
+// closes it.
+func writePipe(filename, data string) error {
+	pipe, err := os.OpenFile(filename, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := pipe.WriteString(data); err != nil {
+		pipe.Close()
+		return err
+	}
+	return pipe.Close()
+}
+
+func TestFollowFIFO(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, _ *os.File) error {
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+		if err := syscall.Mkfifo(filename, 0600); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true, tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		got := make([]byte, len("hello, world!"))
+		if err := writePipe(filename, "hello,"); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(follow, got[:6]); err != nil {
+			return err
+		}
+		// a new writer opens the pipe once the first one closed it
+		if err := writePipe(filename, " world!"); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(follow, got[6:]); err != nil {
+			return err
+		}
+		if string(got) != "hello, world!" {
+			t.Errorf("wanted both writes, got %q", got)
+		}
+		if c := follow.Cursor(); c.Offset != 13 {
+			t.Errorf("wanted to be past everything written to the pipe, got %+v", c)
+		}
+		return nil
+	})
+}
+
+func TestFollowFIFOEOF(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, _ *os.File) error {
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+		if err := syscall.Mkfifo(filename, 0600); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, false, tailf.WithFIFOPolicy(tailf.FIFOEOF))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if err := writePipe(filename, "hello, world!"); err != nil {
+			return err
+		}
+		got, err := ioutil.ReadAll(follow)
+		if err != nil {
+			return err
+		}
+		if string(got) != "hello, world!" {
+			t.Errorf("wanted what was written before the pipe was closed, got %q", got)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+// +build !windows
+
+package tailf
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileID returns the device and inode numbers of file.
+func fileID(file *os.File) (dev, ino uint64, err error) {
+	fi, err := file.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, fmt.Errorf("can't identify file %q: unexpected stat type %T", file.Name(), fi.Sys())
+	}
+	return uint64(st.Dev), uint64(st.Ino), nil
+}
@@ -0,0 +1,16 @@
+package tailf
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID returns the volume serial number and file index of file, which
+// are the Windows equivalents of the device and inode numbers.
+func fileID(file *os.File) (dev, ino uint64, err error) {
+	var info syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(syscall.Handle(file.Fd()), &info); err != nil {
+		return 0, 0, &os.PathError{Op: "GetFileInformationByHandle", Path: file.Name(), Err: err}
+	}
+	return uint64(info.VolumeSerialNumber), uint64(info.FileIndexHigh)<<32 | uint64(info.FileIndexLow), nil
+}
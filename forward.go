@@ -0,0 +1,206 @@
+package tailf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// RotatingFile is an io.WriteCloser appending to a file, which it rotates
+// once it grows past MaxSize bytes or gets older than MaxAge, whichever
+// is set. Rotated files are renamed with a numbered suffix, the most
+// recent being Filename.1, and only the last MaxBackups of them are kept,
+// or all of them if MaxBackups is zero.
+//
+// A file is only rotated between two writes, so that writes of whole
+// lines, as done by Forward, never straddle two files.
+type RotatingFile struct {
+	Filename   string
+	MaxSize    int64
+	MaxAge     time.Duration
+	MaxBackups int
+	// Perm is the mode with which files are created, 0644 by default.
+	Perm os.FileMode
+	// Clock times MaxAge, the system clock if nil.
+	Clock Clock
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// Write appends p to the file, after having rotated it if it is due.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	if r.due(len(p)) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// due tells if the file must be rotated before n more bytes are written
+// to it.
+func (r *RotatingFile) due(n int) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.MaxSize > 0 && r.size+int64(n) > r.MaxSize {
+		return true
+	}
+	return r.MaxAge > 0 && r.now().Sub(r.opened) >= r.MaxAge
+}
+
+func (r *RotatingFile) open() error {
+	perm := r.Perm
+	if perm == 0 {
+		perm = 0644
+	}
+	file, err := os.OpenFile(r.Filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, perm)
+	if err != nil {
+		return err
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file, r.size, r.opened = file, fi.Size(), r.now()
+	if r.size != 0 {
+		// an existing file is aged from its last write, since when it
+		// was started is unknown
+		r.opened = fi.ModTime()
+	}
+	return nil
+}
+
+// now returns the time of the clock of r.
+func (r *RotatingFile) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+	return r.Clock.Now()
+}
+
+// rotate moves the current file to Filename.1, shifting older files to
+// the next suffix, and starts a new file.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+
+	n := 1
+	for {
+		if _, err := os.Stat(backupName(r.Filename, n)); os.IsNotExist(err) {
+			break
+		}
+		n++
+	}
+	for ; n > 0; n-- {
+		from, to := backupName(r.Filename, n-1), backupName(r.Filename, n)
+		if r.MaxBackups > 0 && n > r.MaxBackups {
+			if err := os.Remove(from); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+	}
+	return r.open()
+}
+
+// backupName returns the name of the nth most recent rotated file, or of
+// the current file if n is zero.
+func backupName(filename string, n int) string {
+	if n == 0 {
+		return filename
+	}
+	return fmt.Sprintf("%s.%d", filename, n)
+}
+
+// Close closes the current file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// Forward writes the lines read from each of followers to w, until each
+// of them is closed and drained, or writing its lines failed, and returns
+// the first error met. Lines are written whole, so that the lines of
+// several followers are interleaved rather than mixed up, which
+// consolidates the logs of several processes into one file, such as a
+// RotatingFile. A trailing line without a newline is completed with one
+// once its follower is drained.
+func Forward(w io.Writer, followers ...*Follower) error {
+	var mu sync.Mutex
+	errc := make(chan error, len(followers))
+	for _, f := range followers {
+		go func(f *Follower) {
+			errc <- forwardLines(w, &mu, f)
+		}(f)
+	}
+
+	var err error
+	for range followers {
+		if ferr := <-errc; ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+func forwardLines(w io.Writer, mu *sync.Mutex, f *Follower) error {
+	br := getReader(f, f.opts.bufferSize)
+	defer putReader(br)
+	for {
+		line, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// lines longer than the buffer are gathered whole
+			var long []byte
+			for err == bufio.ErrBufferFull {
+				long = append(long, line...)
+				line, err = br.ReadSlice('\n')
+			}
+			line = append(long, line...)
+		}
+		if err == io.EOF && len(line) != 0 {
+			line = append(line, '\n')
+		}
+		if len(line) != 0 {
+			mu.Lock()
+			_, werr := w.Write(line)
+			mu.Unlock()
+			if werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	"github.com/aybabtme/tailf/tailftest"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_rotating")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "out.log")
+
+	r := &tailf.RotatingFile{Filename: filename, MaxSize: 12, MaxBackups: 2}
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n", "six\n", "seven\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"out.log":   "seven\n",
+		"out.log.1": "five\nsix\n",
+		"out.log.2": "three\nfour\n",
+	}
+	names, err := filepath.Glob(filename + "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != len(want) {
+		t.Errorf("wanted %d files, got %v", len(want), names)
+	}
+	for name, content := range want {
+		got, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(got) != content {
+			t.Errorf("wanted %s to hold %q, got %q", name, content, got)
+		}
+	}
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_rotating")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "out.log")
+
+	clock := tailftest.NewClock(time.Now())
+	r := &tailf.RotatingFile{Filename: filename, MaxAge: time.Hour, Clock: clock}
+	defer r.Close()
+	if _, err := r.Write([]byte("one\n")); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+	if _, err := r.Write([]byte("two\n")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filename + ".1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "one\n" {
+		t.Errorf("wanted the file rotated once an hour passed, got %q rotated", got)
+	}
+}
+
+func TestForward(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		other := filename + ".other"
+		if err := ioutil.WriteFile(other, []byte("a1\na2\na3"), 0644); err != nil {
+			return err
+		}
+		defer os.Remove(other)
+		if _, err := file.WriteString("b1\nb2\n"); err != nil {
+			return err
+		}
+
+		var followers []*tailf.Follower
+		for _, name := range []string{filename, other} {
+			follow, err := tailf.Follow(name, true)
+			if err != nil {
+				return fmt.Errorf("failed creating tailf.follower: %v", err)
+			}
+			followers = append(followers, follow)
+		}
+
+		out := &tailf.RotatingFile{Filename: filename + ".out"}
+		defer os.Remove(out.Filename)
+		done := make(chan error, 1)
+		go func() { done <- tailf.Forward(out, followers...) }()
+
+		if _, err := file.WriteString("b3\n"); err != nil {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+		for _, follow := range followers {
+			follow.Close()
+		}
+		if err := <-done; err != nil {
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(out.Filename)
+		if err != nil {
+			return err
+		}
+		got := strings.SplitAfter(string(data), "\n")
+		sort.Strings(got)
+		want := []string{"", "a1\n", "a2\n", "a3\n", "b1\n", "b2\n", "b3\n"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("wanted whole lines of both files, got %q", data)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,190 @@
+package tailf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// File is a file opened for following. *os.File implements it, as do the
+// files of many fs.FS implementations, such as testing/fstest.MapFS.
+type File interface {
+	fs.File
+	io.ReaderAt
+	io.Seeker
+}
+
+// NotifyFS is an fs.FS telling when its files change, so that followers
+// of its files don't have to wait for the next poll to notice.
+type NotifyFS interface {
+	fs.FS
+	// Notify calls changed whenever the file called name may have been
+	// written to, replaced or removed, until stop is called.
+	Notify(name string, changed func()) (stop func(), err error)
+}
+
+// SameFileFS is an fs.FS that can tell from their info that two of its
+// files are the same, sparing followers from fingerprinting the file at
+// the followed path whenever it is polled.
+type SameFileFS interface {
+	fs.FS
+	// SameFile tells if fi1 and fi2 surely describe the same file. If it
+	// can't tell, the fingerprints of the files are compared.
+	SameFile(fi1, fi2 fs.FileInfo) bool
+}
+
+// WithFS follows files of fsys instead of the OS filesystem, by their
+// name in fsys. The files must implement File. Writes and rotations are
+// detected by polling, as with WithPolling, and as soon as fsys tells if
+// it is a NotifyFS. Optimizations relying on OS files, such as WithMmap,
+// WithURing, WithFadvise or splicing, have no effect.
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fs = fsys
+		o.polling = true
+	}
+}
+
+// osFS opens files from the OS filesystem, by their OS path. Opens and
+// stats failing for transient reasons, as happens on Windows while a
+// writer rotates the file, are retried as set by retry, up to
+// osOpenAttempts times unless it sets a limit.
+type osFS struct {
+	retry Backoff
+}
+
+const osOpenAttempts = 5
+
+func (o osFS) Open(name string) (fs.File, error) {
+	var file *os.File
+	err := o.retryTransient(name, "open", func() (err error) {
+		file, err = openOS(name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (o osFS) Stat(name string) (fs.FileInfo, error) {
+	var fi fs.FileInfo
+	err := o.retryTransient(name, "stat", func() (err error) {
+		fi, err = statOS(name)
+		return err
+	})
+	return fi, err
+}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+
+// retryTransient calls try until it doesn't fail for a transient reason,
+// or the retries are exhausted. A file still pending deletion then is
+// reported as not existing, as it soon won't.
+func (o osFS) retryTransient(name, op string, try func() error) error {
+	retry := o.retry
+	if retry.MaxAttempts == 0 {
+		retry.MaxAttempts = osOpenAttempts
+	}
+	var err error
+	_ = retry.Retry(context.Background(), func() error {
+		if err = try(); transientOpenError(err) {
+			return err
+		}
+		return nil
+	})
+	if deletePending(err) {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return err
+}
+
+// filesystem returns the filesystem files are opened from with opts.
+func filesystem(opts []Option) fs.FS {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.fs == nil {
+		return osFS{retry: o.retry}
+	}
+	return o.fs
+}
+
+// openFile opens the file called name in fsys for following.
+func openFile(fsys fs.FS, name string) (File, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	ff, ok := file.(File)
+	if !ok {
+		_ = file.Close()
+		return nil, fmt.Errorf("%s: file doesn't support random access", name)
+	}
+	return ff, nil
+}
+
+// filesystem returns the filesystem the followed file is opened from.
+func (f *Follower) filesystem() fs.FS {
+	fsys := f.opts.fs
+	if fsys == nil {
+		fsys = osFS{retry: f.opts.retry}
+	}
+	if f.opts.inject != nil {
+		return faultFS{fsys, f.opts.inject}
+	}
+	return fsys
+}
+
+// osFile returns the followed file if it is an OS file, or nil.
+func (f *Follower) osFile() *os.File {
+	file, _ := f.file.(*os.File)
+	return file
+}
+
+// sameFile tells if prev and cur describe the same file. Files that
+// aren't OS files can't be told apart by their info, unless their FS is a
+// SameFileFS, and are compared by fingerprint with the file being read
+// instead.
+func (f *Follower) sameFile(prev, cur fs.FileInfo) bool {
+	f.mu.Lock()
+	native := f.osFile() != nil
+	if !native && f.gen.FingerprintLen == 0 {
+		// the file was empty when opened, fingerprint what it grew to so
+		// that it can be told apart from others
+		f.gen.Fingerprint, f.gen.FingerprintLen = fingerprint(f.file, fingerprintSize)
+	}
+	gen := f.gen
+	f.mu.Unlock()
+	if native {
+		return os.SameFile(prev, cur)
+	}
+	if sfs, ok := f.opts.fs.(SameFileFS); ok && sfs.SameFile(prev, cur) {
+		return true
+	}
+	file, err := openFile(f.opts.fs, f.filename)
+	if errors.Is(err, fs.ErrPermission) {
+		// can't be read anymore, which reopening it reports
+		return false
+	}
+	if err != nil {
+		// let the next poll tell
+		return true
+	}
+	defer file.Close()
+	if gen.FingerprintLen == 0 {
+		return true
+	}
+	head := make([]byte, gen.FingerprintLen)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		// let the next poll tell, rather than take a file that can't be
+		// read for another
+		return true
+	}
+	return n == gen.FingerprintLen && fnv64a(head[:n]) == gen.Fingerprint
+}
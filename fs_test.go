@@ -0,0 +1,240 @@
+package tailf_test
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"sync"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+// memFS is a tailf.NotifyFS holding its files in memory.
+type memFS struct {
+	mu       sync.Mutex
+	files    map[string]*memData
+	watchers map[string][]func()
+	lost     bool // whether reads fail as if the filesystem went away
+	timeouts int  // how many of the next reads time out
+	denied   bool // whether opens fail for lack of permission
+}
+
+type memData struct {
+	data    []byte
+	modTime time.Time
+}
+
+func newMemFS() *memFS {
+	return &memFS{files: make(map[string]*memData), watchers: make(map[string][]func())}
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lost {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: syscall.ESTALE}
+	}
+	if m.denied {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	d, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &memFile{fs: m, name: name, d: d}, nil
+}
+
+func (m *memFS) Notify(name string, changed func()) (func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.watchers[name] = append(m.watchers[name], changed)
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.watchers, name)
+	}, nil
+}
+
+// write appends data to the file called name, creating it unless it
+// exists or replace is true.
+func (m *memFS) write(name, data string, replace bool) {
+	m.mu.Lock()
+	d, ok := m.files[name]
+	if !ok || replace {
+		d = &memData{}
+		m.files[name] = d
+	}
+	d.data = append(d.data, data...)
+	d.modTime = time.Now()
+	watchers := m.watchers[name]
+	m.mu.Unlock()
+	for _, changed := range watchers {
+		changed()
+	}
+}
+
+// setLost makes the filesystem go away, or come back, and notifies the
+// watchers of all its files.
+func (m *memFS) setLost(lost bool) {
+	m.mu.Lock()
+	m.lost = lost
+	var watchers []func()
+	for _, w := range m.watchers {
+		watchers = append(watchers, w...)
+	}
+	m.mu.Unlock()
+	for _, changed := range watchers {
+		changed()
+	}
+}
+
+// setDenied makes the files unreadable, or readable again, and notifies
+// their watchers.
+func (m *memFS) setDenied(denied bool) {
+	m.mu.Lock()
+	m.denied = denied
+	var watchers []func()
+	for _, w := range m.watchers {
+		watchers = append(watchers, w...)
+	}
+	m.mu.Unlock()
+	for _, changed := range watchers {
+		changed()
+	}
+}
+
+// errTimeout is the error of reads that time out.
+type errTimeout struct{}
+
+func (errTimeout) Error() string { return "i/o timeout" }
+func (errTimeout) Timeout() bool { return true }
+
+type memFile struct {
+	fs   *memFS
+	name string
+	d    *memData
+	pos  int64
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	return memInfo{name: path.Base(f.name), size: int64(len(f.d.data)), modTime: f.d.modTime}, nil
+}
+
+type memInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (fi memInfo) Name() string       { return fi.name }
+func (fi memInfo) Size() int64        { return fi.size }
+func (fi memInfo) Mode() fs.FileMode  { return 0444 }
+func (fi memInfo) ModTime() time.Time { return fi.modTime }
+func (fi memInfo) IsDir() bool        { return false }
+func (fi memInfo) Sys() interface{}   { return nil }
+
+func (f *memFile) ReadAt(b []byte, off int64) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.fs.lost {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: syscall.ESTALE}
+	}
+	if f.fs.timeouts > 0 {
+		f.fs.timeouts--
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: errTimeout{}}
+	}
+	if off >= int64(len(f.d.data)) {
+		return 0, io.EOF
+	}
+	n := copy(b, f.d.data[off:])
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) Read(b []byte) (int, error) {
+	n, err := f.ReadAt(b, f.pos)
+	f.pos += int64(n)
+	if n != 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *memFile) Seek(off int64, whence int) (int64, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	switch whence {
+	case io.SeekCurrent:
+		off += f.pos
+	case io.SeekEnd:
+		off += int64(len(f.d.data))
+	}
+	f.pos = off
+	return off, nil
+}
+
+func (f *memFile) Close() error { return nil }
+
+func TestFollowFS(t *testing.T) {
+	fsys := fstest.MapFS{"logs/a.log": &fstest.MapFile{Data: []byte("hello\nworld\n")}}
+
+	follow, err := tailf.Follow("logs/a.log", true, tailf.WithFS(fsys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := make([]byte, 6)
+	if _, err := io.ReadFull(follow, line); err != nil {
+		t.Fatal(err)
+	}
+	if string(line) != "hello\n" {
+		t.Errorf("wanted to read hello, got %q", line)
+	}
+	if c := follow.Cursor(); c.Offset != 6 || c.Inode != 0 {
+		t.Errorf("wanted a cursor at offset 6 without an inode, got %+v", c)
+	}
+	follow.Close()
+}
+
+func TestFollowNotifyFS(t *testing.T) {
+	fsys := newMemFS()
+	fsys.write("a.log", "hello,", false)
+
+	// only notifications wake the follower up
+	follow, err := tailf.Follow("a.log", true, tailf.WithFS(fsys), tailf.WithPolling(time.Hour, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+	time.Sleep(10 * time.Millisecond)
+
+	fsys.write("a.log", " world!", false)
+	fsys.write("a.log", " bonjour,", true)
+	fsys.write("a.log", " monde!", false)
+
+	want := "hello, world! bonjour, monde!"
+	got := make([]byte, len(want))
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(follow, got)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wanted the follower to be notified of the writes")
+	}
+	if string(got) != want {
+		t.Errorf("wanted %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,258 @@
+// Package tailfgcs follows Google Cloud Storage objects through the JSON
+// API, so that tailf can tail the logs shipped to a bucket like it tails
+// local files:
+//
+//	client, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/devstorage.read_only")
+//	...
+//	bucket := &tailfgcs.Bucket{Name: "my-bucket", Client: client}
+//	follow, err := tailf.Follow("logs/app.log", false, bucket.Option())
+//
+// Objects are polled for their metadata, as configured with
+// tailf.WithPolling, and only the new byte ranges of an object that grew
+// are fetched. An object name ending with a slash names a prefix instead,
+// under which the object with the greatest name is followed, and
+// followed until its end once an object with a greater name appears.
+//
+// A new generation of an object means it may have been replaced by
+// another. As objects are uploaded anew whenever they grow, it is only
+// treated as a rotation if the start of the object changed as well.
+package tailfgcs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+const defaultEndpoint = "https://storage.googleapis.com/storage/v1"
+
+// Bucket is a bucket whose objects can be followed.
+type Bucket struct {
+	Name string
+	// Client makes the requests, and must authorize them, as the clients
+	// of golang.org/x/oauth2/google do.
+	Client *http.Client
+	// Endpoint is the base URL of the JSON API, which can point to an
+	// emulator. It defaults to the Google Cloud Storage API.
+	Endpoint string
+}
+
+// Option returns the option following objects of b, by their name given
+// in place of file names.
+func (b *Bucket) Option() tailf.Option {
+	return tailf.WithFS(gcsFS{b})
+}
+
+func (b *Bucket) client() *http.Client {
+	if b.Client == nil {
+		return http.DefaultClient
+	}
+	return b.Client
+}
+
+// objectURL returns the URL of the object called name.
+func (b *Bucket) objectURL(name string) string {
+	endpoint := b.Endpoint
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	return fmt.Sprintf("%s/b/%s/o/%s", endpoint, url.PathEscape(b.Name), url.PathEscape(name))
+}
+
+// metadata is the part of the metadata of an object that is used.
+type metadata struct {
+	Name       string    `json:"name"`
+	Size       string    `json:"size"`
+	Generation string    `json:"generation"`
+	Updated    time.Time `json:"updated"`
+}
+
+func (m metadata) info() (objectInfo, error) {
+	size, err := strconv.ParseInt(m.Size, 10, 64)
+	if err != nil {
+		return objectInfo{}, fmt.Errorf("%s: invalid size %q", m.Name, m.Size)
+	}
+	return objectInfo{name: m.Name, size: size, generation: m.Generation, modTime: m.Updated}, nil
+}
+
+// get decodes the JSON returned by the API at u into v.
+func (b *Bucket) get(u, name string, v interface{}) error {
+	resp, err := b.client().Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	case resp.StatusCode != http.StatusOK:
+		return &fs.PathError{Op: "stat", Path: name, Err: fmt.Errorf("unexpected status %q", resp.Status)}
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// gcsFS is an fs.FS opening the objects of a bucket.
+type gcsFS struct {
+	b *Bucket
+}
+
+func (g gcsFS) Open(name string) (fs.File, error) {
+	fi, err := g.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return &object{b: g.b, name: fi.(objectInfo).name}, nil
+}
+
+// Stat returns the info of the object called name, or of the latest
+// object under name if it is a prefix.
+func (g gcsFS) Stat(name string) (fs.FileInfo, error) {
+	if strings.HasSuffix(name, "/") {
+		return g.latest(name)
+	}
+	var m metadata
+	if err := g.b.get(g.b.objectURL(name), name, &m); err != nil {
+		return nil, err
+	}
+	return m.info()
+}
+
+// latest returns the info of the object with the greatest name under
+// prefix.
+func (g gcsFS) latest(prefix string) (fs.FileInfo, error) {
+	var last *metadata
+	var page string
+	for {
+		q := url.Values{"prefix": {prefix}}
+		if page != "" {
+			q.Set("pageToken", page)
+		}
+		var list struct {
+			Items         []metadata `json:"items"`
+			NextPageToken string     `json:"nextPageToken"`
+		}
+		if err := g.b.get(strings.TrimSuffix(g.b.objectURL(""), "/")+"?"+q.Encode(), prefix, &list); err != nil {
+			return nil, err
+		}
+		for i, m := range list.Items {
+			if strings.HasSuffix(m.Name, "/") || (last != nil && m.Name <= last.Name) {
+				continue
+			}
+			last = &list.Items[i]
+		}
+		if list.NextPageToken == "" {
+			break
+		}
+		page = list.NextPageToken
+	}
+	if last == nil {
+		return nil, &fs.PathError{Op: "stat", Path: prefix, Err: fs.ErrNotExist}
+	}
+	return last.info()
+}
+
+// SameFile tells if fi1 and fi2 are the same object because they have
+// the same name and generation.
+func (g gcsFS) SameFile(fi1, fi2 fs.FileInfo) bool {
+	a, ok1 := fi1.(objectInfo)
+	b, ok2 := fi2.(objectInfo)
+	return ok1 && ok2 && a.name == b.name && a.generation == b.generation
+}
+
+type objectInfo struct {
+	name       string
+	size       int64
+	generation string
+	modTime    time.Time
+}
+
+func (fi objectInfo) Name() string       { return fi.name }
+func (fi objectInfo) Size() int64        { return fi.size }
+func (fi objectInfo) Mode() fs.FileMode  { return 0444 }
+func (fi objectInfo) ModTime() time.Time { return fi.modTime }
+func (fi objectInfo) IsDir() bool        { return false }
+func (fi objectInfo) Sys() interface{}   { return nil }
+
+// object reads an object with range requests.
+type object struct {
+	b    *Bucket
+	name string
+	pos  int64
+}
+
+func (o *object) Stat() (fs.FileInfo, error) {
+	return gcsFS{o.b}.Stat(o.name)
+}
+
+func (o *object) Read(p []byte) (int, error) {
+	n, err := o.ReadAt(p, o.pos)
+	o.pos += int64(n)
+	if n != 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// ReadAt fetches len(p) bytes from off with a range request.
+func (o *object) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	req, err := http.NewRequest("GET", o.b.objectURL(o.name)+"?alt=media", nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+int64(len(p))-1))
+	resp, err := o.b.client().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusRequestedRangeNotSatisfiable:
+		// nothing was written past off yet
+		return 0, io.EOF
+	case http.StatusOK:
+		// the whole object is sent when the range starts at 0 and covers
+		// it, which is fine
+		if off != 0 {
+			return 0, fmt.Errorf("%s: range request ignored", o.name)
+		}
+	default:
+		return 0, fmt.Errorf("%s: unexpected status %q", o.name, resp.Status)
+	}
+	n, err := io.ReadFull(resp.Body, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (o *object) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += o.pos
+	case io.SeekEnd:
+		fi, err := o.Stat()
+		if err != nil {
+			return 0, err
+		}
+		offset += fi.Size()
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("%s: negative position", o.name)
+	}
+	o.pos = offset
+	return offset, nil
+}
+
+func (o *object) Close() error { return nil }
@@ -0,0 +1,169 @@
+package tailfgcs_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	tailfgcs "github.com/aybabtme/tailf/gcs"
+)
+
+// bucket serves the part of the JSON API used by tailfgcs for an
+// in-memory bucket, giving objects a new generation on every upload.
+type bucket struct {
+	mu          sync.Mutex
+	objects     map[string][]byte
+	generations map[string]int
+	uploads     int
+}
+
+func newBucket() *bucket {
+	return &bucket{objects: make(map[string][]byte), generations: make(map[string]int)}
+}
+
+func (b *bucket) put(name, data string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.uploads++
+	b.objects[name] = []byte(data)
+	b.generations[name] = b.uploads
+}
+
+func (b *bucket) metadata(name string) map[string]string {
+	return map[string]string{
+		"name":       name,
+		"size":       strconv.Itoa(len(b.objects[name])),
+		"generation": strconv.Itoa(b.generations[name]),
+	}
+}
+
+func (b *bucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/b/bucket/o")
+	if path == "" {
+		prefix := r.URL.Query().Get("prefix")
+		var names []string
+		for name := range b.objects {
+			if strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		items := []map[string]string{}
+		for _, name := range names {
+			items = append(items, b.metadata(name))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+		return
+	}
+	name := strings.TrimPrefix(path, "/")
+	if strings.Contains(name, "/") {
+		http.Error(w, "slashes must be escaped", http.StatusBadRequest)
+		return
+	}
+	name = strings.Replace(name, "%2F", "/", -1)
+	data, ok := b.objects[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.URL.Query().Get("alt") != "media" {
+		json.NewEncoder(w).Encode(b.metadata(name))
+		return
+	}
+	var from, to int
+	fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &from, &to)
+	if from >= len(data) {
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	if to >= len(data) {
+		to = len(data) - 1
+	}
+	w.WriteHeader(http.StatusPartialContent)
+	w.Write(data[from : to+1])
+}
+
+func TestBucket(t *testing.T) {
+	b := newBucket()
+	b.put("logs/a.log", "hello,")
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	gcs := &tailfgcs.Bucket{Name: "bucket", Client: srv.Client(), Endpoint: srv.URL}
+	follow, err := tailf.Follow("logs/a.log", true, gcs.Option(), tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+
+	got := make([]byte, len("hello, world!bonjour!"))
+	if _, err := io.ReadFull(follow, got[:6]); err != nil {
+		t.Fatal(err)
+	}
+	// a new generation, uploaded as the object grew
+	b.put("logs/a.log", "hello, world!")
+	if _, err := io.ReadFull(follow, got[6:13]); err != nil {
+		t.Fatal(err)
+	}
+	// a new generation replacing the object
+	b.put("logs/a.log", "bonjour!")
+	if _, err := io.ReadFull(follow, got[13:]); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello, world!bonjour!" {
+		t.Errorf("wanted the generations to be followed in turn, got %q", got)
+	}
+
+	var rotations int
+	for {
+		select {
+		case ev := <-follow.Events():
+			if _, ok := ev.(tailf.Rotated); ok {
+				rotations++
+			}
+			continue
+		default:
+		}
+		break
+	}
+	if rotations != 1 {
+		t.Errorf("wanted the replacement to be the only rotation, got %d", rotations)
+	}
+}
+
+func TestBucketPrefix(t *testing.T) {
+	b := newBucket()
+	b.put("logs/2024-01-01.log", "hello,")
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	gcs := &tailfgcs.Bucket{Name: "bucket", Client: srv.Client(), Endpoint: srv.URL}
+	follow, err := tailf.Follow("logs/", true, gcs.Option(), tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+
+	got := make([]byte, len("hello,bonjour!"))
+	if _, err := io.ReadFull(follow, got[:6]); err != nil {
+		t.Fatal(err)
+	}
+	b.put("logs/2024-01-02.log", "bonjour!")
+	if _, err := io.ReadFull(follow, got[6:]); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello,bonjour!" {
+		t.Errorf("wanted the objects to be followed in turn, got %q", got)
+	}
+}
@@ -0,0 +1,111 @@
+// Package tailfgrpc serves the lines written to files over gRPC, with the
+// Tail service defined in tailf.proto, so that remote agents can follow
+// them.
+package tailfgrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative tailf.proto
+
+import (
+	"context"
+	"io"
+
+	"github.com/aybabtme/tailf"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the Tail service by following files with tailf.
+//
+// TLS is set up on the grpc.Server serving it, for instance with
+// grpc.Creds(credentials.NewTLS(cfg)) and a configuration from
+// tailfnet.ServerTLSConfig.
+type Server struct {
+	UnimplementedTailServer
+
+	// Authorize, if not nil, is called for each request allowed by the
+	// server. Requests for which it returns an error are denied. Over
+	// TLS, peer.FromContext(ctx) holds the certificates the client
+	// presented.
+	Authorize func(ctx context.Context, req *TailRequest) error
+
+	allow func(path string) bool
+	opts  []tailf.Option
+}
+
+// NewServer returns a Server following the files for which allow returns
+// true, configured with opts. Requests for other files are denied.
+func NewServer(allow func(path string) bool, opts ...tailf.Option) *Server {
+	return &Server{allow: allow, opts: opts}
+}
+
+// Tail implements TailServer. Lines are sent along with the cursor right
+// after them, which the client can send back to resume following after
+// the last line it got.
+func (s *Server) Tail(req *TailRequest, stream Tail_TailServer) error {
+	if s.allow == nil || !s.allow(req.Path) {
+		return status.Errorf(codes.PermissionDenied, "not allowed to follow %q", req.Path)
+	}
+	if s.Authorize != nil {
+		if err := s.Authorize(stream.Context(), req); err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+
+	var follow *tailf.Follower
+	var err error
+	if c := req.Cursor; c != nil {
+		follow, err = tailf.Resume(req.Path, cursorFromProto(c), s.opts...)
+	} else {
+		follow, err = tailf.Follow(req.Path, req.FromStart, s.opts...)
+	}
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer follow.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stream.Context().Done():
+			follow.Close()
+		case <-done:
+		}
+	}()
+
+	acker := tailf.NewAcker(follow)
+	for {
+		line, err := acker.ReadLine()
+		if err == io.EOF {
+			return stream.Context().Err()
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		acker.Ack(line.Token)
+		msg := &Line{Data: line.Bytes, Cursor: cursorToProto(acker.Cursor())}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func cursorFromProto(c *Cursor) tailf.Cursor {
+	return tailf.Cursor{
+		Device:         c.Device,
+		Inode:          c.Inode,
+		Fingerprint:    c.Fingerprint,
+		FingerprintLen: int(c.FingerprintLen),
+		Offset:         c.Offset,
+	}
+}
+
+func cursorToProto(c tailf.Cursor) *Cursor {
+	return &Cursor{
+		Device:         c.Device,
+		Inode:          c.Inode,
+		Fingerprint:    c.Fingerprint,
+		FingerprintLen: int64(c.FingerprintLen),
+		Offset:         c.Offset,
+	}
+}
@@ -0,0 +1,83 @@
+package tailfgrpc_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tailfgrpc "github.com/aybabtme/tailf/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestTail(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_grpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "a.log")
+	if err := ioutil.WriteFile(filename, []byte("hello\nworld\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	tailfgrpc.RegisterTailServer(srv, tailfgrpc.NewServer(func(path string) bool { return path == filename }))
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	cc, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	client := tailfgrpc.NewTailClient(cc)
+
+	tail := func(req *tailfgrpc.TailRequest, n int) []*tailfgrpc.Line {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stream, err := client.Tail(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var lines []*tailfgrpc.Line
+		for len(lines) < n {
+			line, err := stream.Recv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+
+	lines := tail(&tailfgrpc.TailRequest{Path: filename, FromStart: true}, 2)
+	if string(lines[0].Data) != "hello" || string(lines[1].Data) != "world" {
+		t.Errorf("wanted hello and world, got %q and %q", lines[0].Data, lines[1].Data)
+	}
+	if off := lines[0].Cursor.Offset; off != 6 {
+		t.Errorf("wanted a cursor at offset 6, got %d", off)
+	}
+
+	resumed := tail(&tailfgrpc.TailRequest{Path: filename, Cursor: lines[0].Cursor}, 1)
+	if string(resumed[0].Data) != "world" {
+		t.Errorf("wanted to resume at world, got %q", resumed[0].Data)
+	}
+
+	stream, err := client.Tail(context.Background(), &tailfgrpc.TailRequest{Path: "/etc/passwd"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("wanted following other files to be denied, got %v", err)
+	}
+}
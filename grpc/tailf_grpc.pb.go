@@ -0,0 +1,140 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: tailf.proto
+
+package tailfgrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Tail_Tail_FullMethodName = "/tailf.Tail/Tail"
+)
+
+// TailClient is the client API for Tail service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TailClient interface {
+	// Tail follows a file and streams its lines, until the client cancels
+	// the call.
+	Tail(ctx context.Context, in *TailRequest, opts ...grpc.CallOption) (Tail_TailClient, error)
+}
+
+type tailClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTailClient(cc grpc.ClientConnInterface) TailClient {
+	return &tailClient{cc}
+}
+
+func (c *tailClient) Tail(ctx context.Context, in *TailRequest, opts ...grpc.CallOption) (Tail_TailClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Tail_ServiceDesc.Streams[0], Tail_Tail_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &tailTailClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Tail_TailClient interface {
+	Recv() (*Line, error)
+	grpc.ClientStream
+}
+
+type tailTailClient struct {
+	grpc.ClientStream
+}
+
+func (x *tailTailClient) Recv() (*Line, error) {
+	m := new(Line)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TailServer is the server API for Tail service.
+// All implementations must embed UnimplementedTailServer
+// for forward compatibility
+type TailServer interface {
+	// Tail follows a file and streams its lines, until the client cancels
+	// the call.
+	Tail(*TailRequest, Tail_TailServer) error
+	mustEmbedUnimplementedTailServer()
+}
+
+// UnimplementedTailServer must be embedded to have forward compatible implementations.
+type UnimplementedTailServer struct {
+}
+
+func (UnimplementedTailServer) Tail(*TailRequest, Tail_TailServer) error {
+	return status.Errorf(codes.Unimplemented, "method Tail not implemented")
+}
+func (UnimplementedTailServer) mustEmbedUnimplementedTailServer() {}
+
+// UnsafeTailServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TailServer will
+// result in compilation errors.
+type UnsafeTailServer interface {
+	mustEmbedUnimplementedTailServer()
+}
+
+func RegisterTailServer(s grpc.ServiceRegistrar, srv TailServer) {
+	s.RegisterService(&Tail_ServiceDesc, srv)
+}
+
+func _Tail_Tail_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TailRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TailServer).Tail(m, &tailTailServer{stream})
+}
+
+type Tail_TailServer interface {
+	Send(*Line) error
+	grpc.ServerStream
+}
+
+type tailTailServer struct {
+	grpc.ServerStream
+}
+
+func (x *tailTailServer) Send(m *Line) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Tail_ServiceDesc is the grpc.ServiceDesc for Tail service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Tail_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "tailf.Tail",
+	HandlerType: (*TailServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Tail",
+			Handler:       _Tail_Tail_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "tailf.proto",
+}
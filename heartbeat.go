@@ -0,0 +1,50 @@
+package tailf
+
+import "time"
+
+// WithHeartbeat emits a Heartbeat event every interval during which
+// nothing was read, so that consumers can tell a quiet file from a stuck
+// follower.
+func WithHeartbeat(interval time.Duration) Option {
+	return func(o *options) {
+		o.heartbeat = interval
+	}
+}
+
+// heartbeat emits Heartbeat events while the follower is idle, until
+// following stops.
+func (f *Follower) heartbeat() {
+	interval := f.opts.heartbeat
+	clock := f.clock()
+	last := clock.Now()
+	for {
+		select {
+		case <-clock.After(interval):
+		case <-f.stopped:
+			return
+		}
+
+		f.statsmu.Lock()
+		if f.stats.LastEvent.After(last) {
+			last = f.stats.LastEvent
+		}
+		f.statsmu.Unlock()
+		if clock.Now().Sub(last) < interval {
+			continue
+		}
+
+		f.mu.Lock()
+		if f.closed {
+			f.mu.Unlock()
+			return
+		}
+		ev := Heartbeat{Cursor: f.cursor(), Size: f.size}
+		if fi, err := f.file.Stat(); err == nil {
+			ev.Size = fi.Size()
+		}
+		f.mu.Unlock()
+
+		f.emit(ev)
+		last = clock.Now()
+	}
+}
@@ -0,0 +1,88 @@
+package tailf
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxHistoryLine is how much of a single line is kept in the history, so
+// that data without newlines doesn't grow it unboundedly.
+const maxHistoryLine = 64 * 1024
+
+// history is a ring of the last lines read from a follower.
+type history struct {
+	mu      sync.Mutex
+	lines   [][]byte
+	next    int  // index of the oldest line, overwritten by the next one
+	full    bool // whether the ring wrapped around
+	partial []byte
+}
+
+func newHistory(n int) *history {
+	return &history{lines: make([][]byte, n)}
+}
+
+// add accounts for data having been read.
+func (h *history) add(data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			room := maxHistoryLine - len(h.partial)
+			if room > len(data) {
+				room = len(data)
+			}
+			if room > 0 {
+				h.partial = append(h.partial, data[:room]...)
+			}
+			return
+		}
+		line := data[:i]
+		if room := maxHistoryLine - len(h.partial); room < len(line) {
+			line = line[:imax(room, 0)]
+		}
+		h.push(append(h.partial, line...))
+		h.partial = nil
+		data = data[i+1:]
+	}
+}
+
+func (h *history) push(line []byte) {
+	h.lines[h.next] = line
+	h.next++
+	if h.next == len(h.lines) {
+		h.next = 0
+		h.full = true
+	}
+}
+
+// last returns up to n of the last lines, oldest first.
+func (h *history) last(n int) [][]byte {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	count := h.next
+	if h.full {
+		count = len(h.lines)
+	}
+	if n > count {
+		n = count
+	}
+	out := make([][]byte, 0, n)
+	for i := n; i > 0; i-- {
+		idx := (h.next - i + len(h.lines)) % len(h.lines)
+		out = append(out, append([]byte(nil), h.lines[idx]...))
+	}
+	return out
+}
+
+// Last returns up to n of the last complete lines read from the follower,
+// oldest first and without their trailing newline, such as to show recent
+// context to a client connecting to a live stream. It returns nil unless
+// the follower was configured with WithHistory.
+func (f *Follower) Last(n int) [][]byte {
+	if f.history == nil || n <= 0 {
+		return nil
+	}
+	return f.history.last(n)
+}
@@ -0,0 +1,46 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestLast(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		data := "one\ntwo\nthree\nfour\nfive\nsix"
+		if _, err := file.WriteString(data); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true, tailf.WithHistory(3))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if _, err := io.ReadFull(follow, make([]byte, len(data))); err != nil {
+			return err
+		}
+
+		for n, want := range map[int][]string{
+			1:  {"five"},
+			2:  {"four", "five"},
+			10: {"three", "four", "five"},
+		} {
+			var got []string
+			for _, line := range follow.Last(n) {
+				got = append(got, string(line))
+			}
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("Last(%d): wanted %q, got %q", n, want, got)
+			}
+		}
+		return nil
+	})
+}
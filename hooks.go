@@ -0,0 +1,30 @@
+package tailf
+
+// Hooks are funcs called on the transitions of a follower, as configured
+// with WithHooks. Any of them can be nil. They are called while the
+// follower is busy, so they shouldn't block or call the methods of the
+// follower.
+type Hooks struct {
+	// OnReopen is called when the followed path was opened again, after
+	// its file was rotated or truncated. From is the end of the file that
+	// was being followed, To the start of the one now at the path.
+	OnReopen func(from, to Cursor)
+	// OnRotate and OnTruncate are called when the reader moves on to the
+	// new file at the followed path, or back to the start of the
+	// truncated one, along with the Rotated and Truncated events.
+	OnRotate   func(from, to Cursor)
+	OnTruncate func(from, to Cursor)
+	// OnError is called when reading fails, along with the Error event.
+	OnError func(err error)
+	// OnUnknownEvent is called with the fsnotify.Op mask of the events
+	// about path that carry operations the follower doesn't know, which
+	// are otherwise ignored.
+	OnUnknownEvent func(path string, op uint32)
+}
+
+// WithHooks calls the funcs of h on the transitions of the follower.
+func WithHooks(h Hooks) Option {
+	return func(o *options) {
+		o.hooks = h
+	}
+}
@@ -0,0 +1,58 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestHooks(t *testing.T) {
+	withTempFile(t, time.Millisecond*500, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello,"); err != nil {
+			return err
+		}
+
+		reopened := make(chan [2]tailf.Cursor, 1)
+		rotated := make(chan [2]tailf.Cursor, 1)
+		follow, err := tailf.Follow(filename, true, tailf.WithHooks(tailf.Hooks{
+			OnReopen: func(from, to tailf.Cursor) { reopened <- [2]tailf.Cursor{from, to} },
+			OnRotate: func(from, to tailf.Cursor) { rotated <- [2]tailf.Cursor{from, to} },
+		}))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if err := os.Rename(filename, filename+".1"); err != nil {
+			return err
+		}
+		file, err = os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := file.WriteString(" world!"); err != nil {
+			return err
+		}
+
+		if _, err := io.ReadFull(follow, make([]byte, 13)); err != nil {
+			return err
+		}
+		for name, c := range map[string]chan [2]tailf.Cursor{"reopen": reopened, "rotate": rotated} {
+			select {
+			case fromTo := <-c:
+				from, to := fromTo[0], fromTo[1]
+				if from.Inode == to.Inode || from.Offset != 6 || to.Offset != 0 {
+					t.Errorf("wanted to %s from offset 6 to offset 0 of another file, got %+v to %+v", name, from, to)
+				}
+			default:
+				t.Errorf("wanted a %s hook call", name)
+			}
+		}
+		return nil
+	})
+}
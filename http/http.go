@@ -0,0 +1,160 @@
+// Package tailfhttp follows files served over HTTP by servers supporting
+// range requests, such as most static file servers:
+//
+//	follow, err := tailf.Follow("https://example.com/logs/app.log", false, tailfhttp.WithClient(http.DefaultClient))
+//
+// Growth is detected by polling the URL with HEAD requests, as
+// configured with tailf.WithPolling, and only the new byte ranges are
+// fetched, so a large read buffer, set with tailf.WithBufferSize, saves
+// requests while catching up.
+//
+// A changed ETag means the file may have been rotated. Since most servers
+// derive ETags from the size and modification time of files, which change
+// as they grow, it is only treated as a rotation if the start of the file
+// changed as well.
+package tailfhttp
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+// WithClient follows URLs, given in place of file names, with client.
+func WithClient(client *http.Client) tailf.Option {
+	return tailf.WithFS(httpFS{client})
+}
+
+// httpFS is an fs.FS opening URLs, which name files served over HTTP.
+type httpFS struct {
+	client *http.Client
+}
+
+func (h httpFS) Open(name string) (fs.File, error) {
+	if _, err := h.Stat(name); err != nil {
+		return nil, err
+	}
+	return &httpFile{client: h.client, url: name}, nil
+}
+
+// Stat returns the info of the file at name from a HEAD request.
+func (h httpFS) Stat(name string) (fs.FileInfo, error) {
+	resp, err := h.client.Head(name)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	case resp.StatusCode != http.StatusOK:
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fmt.Errorf("unexpected status %q", resp.Status)}
+	case resp.ContentLength < 0:
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fmt.Errorf("no Content-Length")}
+	}
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return fileInfo{
+		url:     name,
+		size:    resp.ContentLength,
+		modTime: modTime,
+		etag:    resp.Header.Get("ETag"),
+	}, nil
+}
+
+// SameFile tells if fi1 and fi2 are the same file because they have the
+// same ETag.
+func (h httpFS) SameFile(fi1, fi2 fs.FileInfo) bool {
+	a, ok1 := fi1.(fileInfo)
+	b, ok2 := fi2.(fileInfo)
+	return ok1 && ok2 && a.etag != "" && a.url == b.url && a.etag == b.etag
+}
+
+type fileInfo struct {
+	url     string
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+func (fi fileInfo) Name() string       { return fi.url }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) Mode() fs.FileMode  { return 0444 }
+func (fi fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi fileInfo) IsDir() bool        { return false }
+func (fi fileInfo) Sys() interface{}   { return nil }
+
+// httpFile reads a file served over HTTP with range requests.
+type httpFile struct {
+	client *http.Client
+	url    string
+	pos    int64
+}
+
+func (f *httpFile) Stat() (fs.FileInfo, error) {
+	return httpFS{f.client}.Stat(f.url)
+}
+
+func (f *httpFile) Read(b []byte) (int, error) {
+	n, err := f.ReadAt(b, f.pos)
+	f.pos += int64(n)
+	if n != 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// ReadAt fetches len(b) bytes from off with a range request.
+func (f *httpFile) ReadAt(b []byte, off int64) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	req, err := http.NewRequest("GET", f.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+int64(len(b))-1))
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusRequestedRangeNotSatisfiable:
+		// nothing was written past off yet
+		return 0, io.EOF
+	case http.StatusOK:
+		return 0, fmt.Errorf("%s: server doesn't support range requests", f.url)
+	default:
+		return 0, fmt.Errorf("%s: unexpected status %q", f.url, resp.Status)
+	}
+	n, err := io.ReadFull(resp.Body, b)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f *httpFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		fi, err := f.Stat()
+		if err != nil {
+			return 0, err
+		}
+		offset += fi.Size()
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("%s: negative position", f.url)
+	}
+	f.pos = offset
+	return offset, nil
+}
+
+func (f *httpFile) Close() error { return nil }
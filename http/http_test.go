@@ -0,0 +1,84 @@
+package tailfhttp_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	tailfhttp "github.com/aybabtme/tailf/http"
+)
+
+// logServer serves a file that grows and gets rotated, with an ETag
+// changing as it grows, as most static file servers do.
+type logServer struct {
+	mu   sync.Mutex
+	gen  int
+	data []byte
+}
+
+func (s *logServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	data := s.data
+	w.Header().Set("ETag", fmt.Sprintf(`"%d-%d"`, s.gen, len(data)))
+	s.mu.Unlock()
+	http.ServeContent(w, r, "a.log", time.Time{}, bytes.NewReader(data))
+}
+
+func (s *logServer) write(data string, rotate bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rotate {
+		s.gen++
+		s.data = nil
+	}
+	s.data = append(s.data[:len(s.data):len(s.data)], data...)
+}
+
+func TestWithClient(t *testing.T) {
+	logs := &logServer{}
+	logs.write("hello,", false)
+	srv := httptest.NewServer(logs)
+	defer srv.Close()
+
+	follow, err := tailf.Follow(srv.URL+"/a.log", true, tailfhttp.WithClient(srv.Client()), tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+
+	got := make([]byte, len("hello, world!"))
+	if _, err := io.ReadFull(follow, got[:6]); err != nil {
+		t.Fatal(err)
+	}
+	logs.write(" world!", false)
+	if _, err := io.ReadFull(follow, got[6:]); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello, world!" {
+		t.Errorf("wanted the growth to be read, got %q", got)
+	}
+
+	logs.write("bonjour!", true)
+	got = make([]byte, len("bonjour!"))
+	if _, err := io.ReadFull(follow, got); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "bonjour!" {
+		t.Errorf("wanted the rotated file to be read, got %q", got)
+	}
+	for ev := range follow.Events() {
+		if _, ok := ev.(tailf.Rotated); ok {
+			return
+		}
+		if _, ok := ev.(tailf.Truncated); ok {
+			return
+		}
+		t.Errorf("wanted a rotation, got %#v", ev)
+	}
+}
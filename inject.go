@@ -0,0 +1,51 @@
+package tailf
+
+import (
+	"io/fs"
+	"time"
+)
+
+// injector injects faults into a follower, as set with WithFaults in
+// builds tagged tailffaults.
+type injector interface {
+	// failOpen returns the error the next open of name fails with, if
+	// any.
+	failOpen(name string) error
+	// dropEvent tells if the next event is dropped.
+	dropEvent() bool
+	// reopenDelay returns how long to wait before reopening the file.
+	reopenDelay() time.Duration
+}
+
+// faultFS is an fs.FS whose opens fail as an injector says.
+type faultFS struct {
+	fs.FS
+	inject injector
+}
+
+func (f faultFS) Open(name string) (fs.File, error) {
+	if err := f.inject.failOpen(name); err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return f.FS.Open(name)
+}
+
+func (f faultFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(f.FS, name)
+}
+
+// droppedEvent tells if an injected fault drops the next event.
+func (f *Follower) droppedEvent() bool {
+	return f.opts.inject != nil && f.opts.inject.dropEvent()
+}
+
+// delayReopen waits as long as an injected fault delays reopening.
+func (f *Follower) delayReopen() {
+	if f.opts.inject == nil {
+		return
+	}
+	if d := f.opts.inject.reopenDelay(); d > 0 {
+		f.opts.logger.Debug("delaying reopen, as injected", "path", f.filename, "delay", d)
+		<-f.clock().After(d)
+	}
+}
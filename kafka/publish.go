@@ -0,0 +1,111 @@
+// Package tailfkafka publishes the lines written to a file to Kafka, with
+// at-least-once delivery.
+package tailfkafka
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/aybabtme/tailf"
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers of the messages, telling where their line was read from.
+const (
+	HeaderFile   = "tailf-file"
+	HeaderOffset = "tailf-offset"
+)
+
+// maxBatch is the most lines written to Kafka at once.
+const maxBatch = 1000
+
+// Writer writes messages to Kafka, as *kafka.Writer does.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Publish publishes the lines of f to w, one message per line keyed by
+// the name of the file, until f is closed and drained, ctx is done, or
+// writing fails. Lines are written in batches, of those read while the
+// previous batch was written, so w should be synchronous, and a
+// *kafka.Writer be given a short BatchTimeout.
+//
+// The lines are delivered through a tailf.Acker, and acknowledged once w
+// has written them, so that a tailf.Checkpointer given f saves the
+// position of the last line known to be in Kafka: resuming from it after
+// a crash publishes again whatever may have been lost. f shouldn't be
+// read from otherwise, and must be closed for Publish to stop reading it.
+func Publish(ctx context.Context, f *tailf.Follower, w Writer) error {
+	acker := tailf.NewAcker(f)
+	lines := make(chan tailf.Line, maxBatch)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		for {
+			line, err := acker.ReadLine()
+			if err != nil {
+				if err != io.EOF {
+					readErr <- err
+				}
+				return
+			}
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	key := []byte(f.Filename())
+	batch := make([]kafka.Message, 0, maxBatch)
+	tokens := make([]tailf.Token, 0, maxBatch)
+	for {
+		var line tailf.Line
+		var ok bool
+		select {
+		case line, ok = <-lines:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if !ok {
+			break
+		}
+
+		batch, tokens = batch[:0], tokens[:0]
+		for ok {
+			batch = append(batch, kafka.Message{
+				Key:   key,
+				Value: line.Bytes,
+				Headers: []kafka.Header{
+					{Key: HeaderFile, Value: key},
+					{Key: HeaderOffset, Value: []byte(strconv.FormatInt(line.Offset, 10))},
+				},
+			})
+			tokens = append(tokens, line.Token)
+			if len(batch) == maxBatch {
+				break
+			}
+			select {
+			case line, ok = <-lines:
+			default:
+				ok = false
+			}
+		}
+
+		if err := w.WriteMessages(ctx, batch...); err != nil {
+			return err
+		}
+		for _, t := range tokens {
+			acker.Ack(t)
+		}
+	}
+
+	select {
+	case err := <-readErr:
+		return err
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,95 @@
+package tailfkafka_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	tailfkafka "github.com/aybabtme/tailf/kafka"
+	"github.com/segmentio/kafka-go"
+)
+
+type fakeWriter struct {
+	msgs []kafka.Message
+	fail error
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if w.fail != nil {
+		return w.fail
+	}
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func TestPublish(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_kafka")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "a.log")
+	if err := ioutil.WriteFile(filename, []byte("hello\nworld\npartial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	follow, err := tailf.Follow(filename, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &fakeWriter{}
+	done := make(chan error, 1)
+	go func() { done <- tailfkafka.Publish(context.Background(), follow, w) }()
+	time.Sleep(100 * time.Millisecond)
+	follow.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ value, offset string }{{"hello", "0"}, {"world", "6"}}
+	if len(w.msgs) != len(want) {
+		t.Fatalf("wanted %d messages, got %d", len(want), len(w.msgs))
+	}
+	for i, msg := range w.msgs {
+		if string(msg.Value) != want[i].value || string(msg.Key) != filename ||
+			header(msg, tailfkafka.HeaderFile) != filename || header(msg, tailfkafka.HeaderOffset) != want[i].offset {
+			t.Errorf("wanted line %q at offset %s of %s, got %+v", want[i].value, want[i].offset, filename, msg)
+		}
+	}
+}
+
+func TestPublishFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_kafka")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "a.log")
+	if err := ioutil.WriteFile(filename, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	follow, err := tailf.Follow(filename, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+
+	broken := errors.New("broker is down")
+	if err := tailfkafka.Publish(context.Background(), follow, &fakeWriter{fail: broken}); err != broken {
+		t.Errorf("wanted the write to fail with %v, got %v", broken, err)
+	}
+}
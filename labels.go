@@ -0,0 +1,34 @@
+package tailf
+
+// Labels are key/value pairs describing a followed file, such as the
+// service writing it or its environment, which are carried along with its
+// lines and events so that they can be routed without looking its path
+// up. They mustn't be modified once given to a follower.
+type Labels map[string]string
+
+// WithLabels labels the follower with l, in addition to the labels given
+// in previous options, which l overrides.
+func WithLabels(l Labels) Option {
+	return func(o *options) {
+		labels := make(Labels, len(o.labels)+len(l))
+		for k, v := range o.labels {
+			labels[k] = v
+		}
+		for k, v := range l {
+			labels[k] = v
+		}
+		o.labels = labels
+	}
+}
+
+// Labels returns the labels of the follower, set with WithLabels, which
+// mustn't be modified.
+func (f *Follower) Labels() Labels { return f.opts.labels }
+
+// LabeledEvent is an event of a follower of a FollowerSet, along with the
+// path the follower was added with and its labels.
+type LabeledEvent struct {
+	Event
+	Path   string
+	Labels Labels
+}
@@ -0,0 +1,71 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+type recordingLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (l *recordingLogger) log(level, msg string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, fmt.Sprint(level, " ", msg, " ", args))
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.log("DEBUG", msg, args...) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.log("INFO", msg, args...) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.log("WARN", msg, args...) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.log("ERROR", msg, args...) }
+
+func (l *recordingLogger) logged(want string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, msg := range l.msgs {
+		if msg == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLogger(t *testing.T) {
+	withTempFile(t, time.Millisecond*500, func(t *testing.T, filename string, file *os.File) error {
+		logger := &recordingLogger{}
+		follow, err := tailf.Follow(filename, true, tailf.WithLogger(logger))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if err := os.Rename(filename, filename+".1"); err != nil {
+			return err
+		}
+		file, err = os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := file.WriteString("hello\n"); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(follow, make([]byte, 6)); err != nil {
+			return err
+		}
+
+		want := fmt.Sprint("INFO reopening file ", []interface{}{"path", follow.Filename(), "truncated", false})
+		if !logger.logged(want) {
+			t.Errorf("wanted %q to be logged, got %q", want, logger.msgs)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,271 @@
+package tailf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"time"
+	"unicode/utf8"
+)
+
+// aheadChunk is how much is read at once ahead of the reader.
+const aheadChunk = 512
+
+// ErrPeekTimeout is returned by Peek when fewer bytes than asked for were
+// written before its timeout.
+var ErrPeekTimeout = errors.New("tailf: peek timed out")
+
+var (
+	_ io.ByteReader = (*Follower)(nil)
+	_ io.RuneReader = (*Follower)(nil)
+)
+
+// ReadByte reads the next byte, blocking until it is written like Read
+// does. It implements io.ByteReader, so that the follower can be handed
+// to decoders reading a byte at a time without wrapping it in a
+// bufio.Reader, whose buffer the cursor wouldn't account for.
+func (f *Follower) ReadByte() (byte, error) {
+	var b [1]byte
+	for {
+		n, err := f.Read(b[:])
+		if n != 0 {
+			return b[0], nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// ReadBytes reads until the first occurrence of delim, blocking until it
+// is written, and returns the data up to and including delim, as
+// bufio.Reader does. A line left incomplete at the end of a rotated or
+// truncated file ends there, and is returned without delim, since its
+// writer moved on to the next file. Once the follower is closed and
+// drained, a trailing incomplete line is returned along with io.EOF.
+func (f *Follower) ReadBytes(delim byte) ([]byte, error) {
+	return f.readBytes(context.Background(), delim)
+}
+
+// readBytes reads until the first occurrence of delim like ReadBytes,
+// until ctx is done, in which case what was read comes with its error.
+func (f *Follower) readBytes(ctx context.Context, delim byte) ([]byte, error) {
+	scanned, end := 0, -1
+	ahead, err := f.lookAhead(ctx, func(ahead []byte) bool {
+		if i := bytes.IndexByte(ahead[scanned:], delim); i >= 0 {
+			end = scanned + i + 1
+			return true
+		}
+		scanned = len(ahead)
+		return false
+	})
+	if end < 0 {
+		end = len(ahead)
+	}
+	if end == 0 {
+		return nil, err
+	}
+	line := make([]byte, end)
+	n, rerr := f.read(line, nil)
+	if n < end {
+		// reading stopped within the line, as set by WithStop
+		if n == 0 {
+			return nil, rerr
+		}
+		return line[:n], nil
+	}
+	if end == len(ahead) && line[end-1] != delim {
+		return line, err
+	}
+	return line, nil
+}
+
+// ReadString reads until the first occurrence of delim like ReadBytes,
+// returning the data as a string.
+func (f *Follower) ReadString(delim byte) (string, error) {
+	line, err := f.ReadBytes(delim)
+	return string(line), err
+}
+
+// ReadUntil reads lines until one matches re, and returns it along with
+// all that was read before it, blocking until it is written, as tests
+// waiting for a server to log that it is listening do. Lines are matched
+// without their trailing newline. If ctx is done first, or the follower
+// closed and drained, what was read comes with the error.
+func (f *Follower) ReadUntil(ctx context.Context, re *regexp.Regexp) ([]byte, error) {
+	var read []byte
+	for {
+		line, err := f.readBytes(ctx, '\n')
+		read = append(read, line...)
+		if err != nil {
+			return read, err
+		}
+		if re.Match(bytes.TrimSuffix(line, newline)) {
+			return read, nil
+		}
+	}
+}
+
+// ReadRune reads the next UTF-8 encoded rune and returns it with its size
+// in bytes, blocking until it is written in full like Read does. Invalid
+// encodings are returned as utf8.RuneError of size 1, as bufio.Reader
+// does, and what follows them is left to read. It implements
+// io.RuneReader, and its cursor doesn't count what was read ahead of the
+// rune. A rune left incomplete at the end of a rotated or truncated file
+// is invalid.
+func (f *Follower) ReadRune() (rune, int, error) {
+	ahead, err := f.lookAhead(context.Background(), func(ahead []byte) bool {
+		return len(ahead) != 0 && (ahead[0] < utf8.RuneSelf || utf8.FullRune(ahead))
+	})
+	if len(ahead) == 0 {
+		return 0, 0, err
+	}
+	r, size := utf8.DecodeRune(ahead)
+	var b [utf8.UTFMax]byte
+	n, err := f.read(b[:size], nil)
+	if n < size {
+		// reading stopped within the rune, as set by WithStop
+		if n == 0 {
+			return 0, 0, err
+		}
+		return utf8.RuneError, n, nil
+	}
+	return r, size, nil
+}
+
+// Peek returns the next n bytes without reading them, blocking until they
+// are written or timeout passes, so that consumers sniffing the format of
+// what follows, such as JSON or plain text, needn't buffer it themselves.
+// A non-positive timeout waits for as long as it takes. The bytes are a
+// copy, which stays valid after the next read.
+//
+// Peek returns fewer than n bytes when the file being read ends first, as
+// when the reader is about to move on from a rotated file, in which case
+// the bytes are what is left of that file. Otherwise, fewer bytes come
+// with an error: ErrPeekTimeout if the timeout passed, or io.EOF once the
+// follower is closed and drained.
+func (f *Follower) Peek(n int, timeout time.Duration) ([]byte, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		f.mu.Lock()
+		f.background(func() {
+			select {
+			case <-f.clock().After(timeout):
+				cancel()
+			case <-ctx.Done():
+			case <-f.stopped:
+			}
+		})
+		f.mu.Unlock()
+	}
+	ahead, err := f.lookAhead(ctx, func(ahead []byte) bool {
+		return len(ahead) >= n
+	})
+	if err == context.Canceled {
+		err = ErrPeekTimeout
+	}
+	if len(ahead) > n {
+		ahead = ahead[:n]
+	}
+	return append([]byte(nil), ahead...), err
+}
+
+// lookAhead reads ahead of the reader until enough tells enough was, and
+// returns what was. Reading ahead stops at the end of the file being
+// read, as when the reader moves on from a rotated file, in which case
+// what is left of that file is returned. If reading fails first, as it
+// does once the follower is closed and drained, it returns what was read
+// ahead along with the error, as it does with the error of ctx once it is
+// done. The data returned mustn't be modified.
+func (f *Follower) lookAhead(ctx context.Context, enough func(ahead []byte) bool) ([]byte, error) {
+	if ctx.Done() != nil {
+		returned := make(chan struct{})
+		defer close(returned)
+		f.mu.Lock()
+		f.background(func() {
+			select {
+			case <-ctx.Done():
+				// wakes up the wait for the file to grow
+				f.notify()
+			case <-returned:
+			case <-f.stopped:
+			}
+		})
+		f.mu.Unlock()
+	}
+
+	buf := make([]byte, aheadChunk)
+	for {
+		f.mu.Lock()
+		ahead, end := f.ahead, f.aheadEnd
+		stopped, left := f.sinceStart.reached, f.left()
+		f.mu.Unlock()
+		if stopped {
+			return nil, io.EOF
+		}
+		if end != 0 {
+			ahead = ahead[:end]
+		}
+		if enough(ahead) || end != 0 || int64(len(ahead)) >= left {
+			return ahead, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return ahead, err
+		}
+
+		crossings := f.crossings()
+		n, err := f.fetch(buf, nil)
+		f.mu.Lock()
+		if f.crossings() != crossings && len(f.ahead) != 0 && f.aheadEnd == 0 {
+			// what was read ahead is all there is left of the previous
+			// file
+			f.aheadEnd = len(f.ahead)
+		}
+		f.ahead = append(f.ahead, buf[:n]...)
+		ahead, end = f.ahead, f.aheadEnd
+		f.mu.Unlock()
+		if err != nil && end == 0 {
+			return ahead, err
+		}
+	}
+}
+
+// unread puts data, which was just read, back ahead of the reader, and
+// sets c to the cursor before it if c isn't nil. The follower must be
+// locked.
+func (f *Follower) unread(data []byte, c *Cursor) {
+	f.ahead = append(append([]byte(nil), data...), f.ahead...)
+	if f.aheadEnd != 0 {
+		f.aheadEnd += len(data)
+	}
+	if c != nil {
+		*c = f.cursor()
+	}
+}
+
+// readAhead reads into b what was read ahead of the reader, if anything,
+// and sets c to the cursor right after it if c isn't nil.
+func (f *Follower) readAhead(b []byte, c *Cursor) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.ahead) == 0 {
+		return 0
+	}
+	n := copy(b, f.ahead)
+	f.ahead = f.ahead[n:]
+	if len(f.ahead) == 0 {
+		f.ahead = nil
+	}
+	if f.aheadEnd -= n; f.aheadEnd < 0 {
+		f.aheadEnd = 0
+	}
+	if c != nil {
+		*c = f.cursor()
+	}
+	return n
+}
@@ -0,0 +1,169 @@
+package tailf_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestReadRune(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		// a rune written in two parts, then an invalid encoding
+		if _, err := file.WriteString("h\xe4\xb8"); err != nil {
+			return err
+		}
+		if b, err := follow.ReadByte(); err != nil || b != 'h' {
+			t.Errorf("wanted to read 'h', got %q, %v", b, err)
+		}
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			file.WriteString("\x96\xff!")
+		}()
+		for _, want := range []struct {
+			r      rune
+			size   int
+			offset int64
+		}{{'世', 3, 4}, {utf8.RuneError, 1, 5}, {'!', 1, 6}} {
+			r, size, err := follow.ReadRune()
+			if err != nil {
+				return err
+			}
+			if r != want.r || size != want.size {
+				t.Errorf("wanted %q of size %d, got %q of size %d", want.r, want.size, r, size)
+			}
+			// what was read ahead of the rune isn't counted
+			if c := follow.Cursor(); c.Offset != want.offset {
+				t.Errorf("wanted the cursor at offset %d, got %d", want.offset, c.Offset)
+			}
+		}
+		return nil
+	})
+}
+
+func TestReadString(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello\ntorn"); err != nil {
+			return err
+		}
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+
+		read := func(want string, wantErr error) {
+			t.Helper()
+			if line, err := follow.ReadString('\n'); line != want || err != wantErr {
+				t.Errorf("wanted %q, %v, got %q, %v", want, wantErr, line, err)
+			}
+		}
+		read("hello\n", nil)
+
+		// the writer moved on to a new file without ending its last line
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			if err := os.Rename(filename, filename+".1"); err != nil {
+				t.Error(err)
+			}
+			if err := ioutil.WriteFile(filename, []byte("record\nlast"), 0644); err != nil {
+				t.Error(err)
+			}
+		}()
+		read("torn", nil)
+		read("record\n", nil)
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			follow.Close()
+		}()
+		read("last", io.EOF)
+		return nil
+	})
+}
+
+func TestPeek(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString(`{"msg"`); err != nil {
+			return err
+		}
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		peek := func(n int, timeout time.Duration, want string, wantErr error) {
+			t.Helper()
+			if got, err := follow.Peek(n, timeout); string(got) != want || err != wantErr {
+				t.Errorf("wanted %q, %v, got %q, %v", want, wantErr, got, err)
+			}
+		}
+		peek(1, 0, "{", nil)
+		peek(10, 20*time.Millisecond, `{"msg"`, tailf.ErrPeekTimeout)
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			file.WriteString(`:"hi"}`)
+		}()
+		peek(10, time.Second, `{"msg":"hi`, nil)
+
+		// nothing was read
+		if c := follow.Cursor(); c.Offset != 0 {
+			t.Errorf("wanted the cursor at offset 0, got %d", c.Offset)
+		}
+		got := make([]byte, 12)
+		if _, err := io.ReadFull(follow, got); err != nil {
+			return err
+		}
+		if want := `{"msg":"hi"}`; string(got) != want {
+			t.Errorf("wanted to read %q, got %q", want, got)
+		}
+		return nil
+	})
+}
+
+func TestReadUntil(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("starting\nloading config\n"); err != nil {
+			return err
+		}
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			file.WriteString("listening on :8080\nserving\n")
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		got, err := follow.ReadUntil(ctx, regexp.MustCompile(`^listening on :\d+$`))
+		if want := "starting\nloading config\nlistening on :8080\n"; string(got) != want || err != nil {
+			t.Errorf("wanted %q, got %q, %v", want, got, err)
+		}
+
+		// gives up once ctx is done, with what it read
+		ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		got, err = follow.ReadUntil(ctx, regexp.MustCompile(`stopped`))
+		if want := "serving\n"; string(got) != want || err != context.DeadlineExceeded {
+			t.Errorf("wanted %q, %v, got %q, %v", want, context.DeadlineExceeded, got, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,29 @@
+package tailf
+
+import "runtime/debug"
+
+// mapping is a read-only memory mapping of a file, starting at offset
+// base in it.
+type mapping struct {
+	data []byte
+	base int64
+}
+
+// readAt copies the mapped data found at offset off of the file into b,
+// returning 0 if off isn't mapped. Reading pages of a file that was
+// truncated since it was mapped faults, which is recovered from and also
+// returns 0, rather than crashing the process.
+func (m *mapping) readAt(b []byte, off int64) (n int) {
+	off -= m.base
+	if off < 0 || off >= int64(len(m.data)) {
+		return 0
+	}
+	old := debug.SetPanicOnFault(true)
+	defer func() {
+		debug.SetPanicOnFault(old)
+		if recover() != nil {
+			n = 0
+		}
+	}()
+	return copy(b, m.data[off:])
+}
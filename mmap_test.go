@@ -0,0 +1,52 @@
+package tailf_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestCanCatchUpFromMapping(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		backlog := make([]byte, 1<<20+123)
+		for i := range backlog {
+			backlog[i] = byte('a' + rand.Intn(26))
+		}
+		if _, err := file.Write(backlog); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true, tailf.WithMmap())
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		got := make([]byte, len(backlog))
+		if _, err := io.ReadFull(follow, got); err != nil {
+			return err
+		}
+		if !bytes.Equal(backlog, got) {
+			t.Errorf("backlog read differs from backlog written")
+		}
+
+		want := "hello, world!\n"
+		if _, err := file.WriteString(want); err != nil {
+			return err
+		}
+		data := make([]byte, len(want))
+		if _, err := io.ReadFull(follow, data); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
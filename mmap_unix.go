@@ -0,0 +1,33 @@
+//go:build !windows
+// +build !windows
+
+package tailf
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps the content of file from offset pos up to its current
+// size, or returns nil if there is nothing to map or it can't be mapped.
+func mapFile(file *os.File, pos int64) *mapping {
+	fi, err := file.Stat()
+	if err != nil {
+		return nil
+	}
+	// mappings start on a page boundary
+	base := pos - pos%int64(os.Getpagesize())
+	size := fi.Size() - base
+	if fi.Size() <= pos || size != int64(int(size)) {
+		return nil
+	}
+	data, err := syscall.Mmap(int(file.Fd()), base, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil
+	}
+	return &mapping{data: data, base: base}
+}
+
+func (m *mapping) unmap() {
+	_ = syscall.Munmap(m.data)
+}
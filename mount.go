@@ -0,0 +1,145 @@
+package tailf
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"syscall"
+)
+
+// ErrMountLost signifies the filesystem of the followed file went away,
+// as when a network share drops or removable media are unmounted. The
+// FileError of this kind wraps the error that reads of the file failed
+// with.
+var ErrMountLost = errors.New("tailf: filesystem was lost")
+
+// WithRemount keeps following a file whose filesystem went away, instead
+// of failing with ErrMountLost: the followed path is reopened once it is
+// back, retrying as set by WithRetry. The file is read on from where it
+// was left if it is still the same, and from its start otherwise, as if
+// it was rotated while it was gone.
+func WithRemount() Option {
+	return func(o *options) {
+		o.remount = true
+	}
+}
+
+// mountLost tells if err tells that the filesystem of a file went away.
+func mountLost(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	for _, lost := range mountLostErrnos {
+		if errno == lost {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMount returns err as an ErrMountLost if it tells the filesystem of
+// the followed file went away. With WithRemount, it returns nil instead,
+// and the followed path is reopened once it is back. The follower must be
+// locked.
+func (f *Follower) checkMount(err error) error {
+	if !mountLost(err) {
+		return err
+	}
+	if !f.opts.remount {
+		return f.fileError(ErrMountLost, err)
+	}
+	if !f.remounting {
+		f.remounting = true
+		f.opts.logger.Warn("filesystem lost, waiting for it to come back", "path", f.filename, "err", err)
+		f.background(f.remount)
+	}
+	return nil
+}
+
+// remount reopens the followed path once it can be opened again, until
+// following stops. If the retry policy gives up, the loss of the
+// filesystem is reported to the reader.
+func (f *Follower) remount() {
+	err := f.opts.retry.retry(f.stopping, f.clock(), func() error {
+		if f.isClosed() {
+			return nil
+		}
+		file, err := openFile(f.filesystem(), f.filename)
+		if err != nil {
+			return err
+		}
+		return f.remounted(file)
+	})
+	if f.stopping.Err() != nil {
+		// following stopped while waiting, there's nothing left to reopen
+		return
+	}
+	if err != nil {
+		f.opts.logger.Warn("gave up reopening file after its filesystem was lost", "path", f.filename, "err", err)
+		f.mu.Lock()
+		f.opts.remount = false
+		f.remounting = false
+		f.mu.Unlock()
+	} else if !f.isClosed() {
+		f.opts.logger.Info("filesystem came back, reopened file", "path", f.filename)
+		if !f.opts.polling {
+			// the watch went away with the filesystem
+			_ = f.watch.Add(longPath(filepath.Dir(f.filename)))
+		}
+	}
+	f.notify()
+}
+
+// remounted moves on to file, opened at the followed path once its
+// filesystem came back.
+func (f *Follower) remounted(file File) error {
+	f.mu.Lock()
+	fi, err := file.Stat()
+	if err != nil {
+		f.mu.Unlock()
+		_ = file.Close()
+		return err
+	}
+	if f.released || !sameFingerprint(f.gen, file) || fi.Size() < f.position.pos {
+		// read what was buffered from the lost file, then the new file
+		// from its start
+		stale := f.file
+		f.file, f.position.file = lostFile{}, lostFile{}
+		f.remounting = false
+		f.mu.Unlock()
+		_ = stale.Close()
+		_ = file.Close()
+		return f.reopenFile(false)
+	}
+	defer f.mu.Unlock()
+
+	if _, err := file.Seek(f.position.pos, io.SeekStart); err != nil {
+		_ = file.Close()
+		return err
+	}
+	gen, err := identify(file)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	// the handle of the lost file is stale
+	_ = f.file.Close()
+	f.position.unmap()
+	f.file, f.position.file = file, file
+	f.gen.Device, f.gen.Inode = gen.Device, gen.Inode
+	f.adviseOpen()
+	f.remounting = false
+	return nil
+}
+
+// lostFile stands for a file whose filesystem went away, so that what
+// was read from it can be drained.
+type lostFile struct{}
+
+func (lostFile) Read([]byte) (int, error)          { return 0, io.EOF }
+func (lostFile) ReadAt([]byte, int64) (int, error) { return 0, io.EOF }
+func (lostFile) Seek(int64, int) (int64, error)    { return 0, nil }
+func (lostFile) Stat() (fs.FileInfo, error)        { return nil, fs.ErrNotExist }
+func (lostFile) Close() error                      { return nil }
@@ -0,0 +1,99 @@
+package tailf_test
+
+import (
+	"errors"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	"go.uber.org/goleak"
+)
+
+func TestMountLost(t *testing.T) {
+	fsys := newMemFS()
+	fsys.write("a.log", "hello,", false)
+
+	follow, err := tailf.Follow("a.log", true, tailf.WithFS(fsys), tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+	if _, err := io.ReadFull(follow, make([]byte, 6)); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys.setLost(true)
+	_, err = io.ReadFull(follow, make([]byte, 1))
+	var ferr *tailf.FileError
+	if !errors.Is(err, tailf.ErrMountLost) || !errors.Is(err, syscall.ESTALE) || !errors.As(err, &ferr) || ferr.Path != "a.log" || ferr.Offset != 6 {
+		t.Errorf("wanted the loss of the filesystem to be reported, got %v", err)
+	}
+}
+
+func TestRemount(t *testing.T) {
+	fsys := newMemFS()
+	fsys.write("a.log", "hello,", false)
+
+	follow, err := tailf.Follow("a.log", true, tailf.WithFS(fsys), tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond), tailf.WithRemount(), tailf.WithRetry(tailf.Backoff{Initial: 5 * time.Millisecond, Max: 5 * time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+
+	got := make([]byte, len("hello, world!bonjour!"))
+	if _, err := io.ReadFull(follow, got[:6]); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys.setLost(true)
+	time.Sleep(20 * time.Millisecond)
+	fsys.write("a.log", " world!", false)
+	fsys.setLost(false)
+	if _, err := io.ReadFull(follow, got[6:13]); err != nil {
+		t.Fatal(err)
+	}
+
+	// replaced while the filesystem was gone
+	fsys.setLost(true)
+	time.Sleep(20 * time.Millisecond)
+	fsys.write("a.log", "bonjour!", true)
+	fsys.setLost(false)
+	if _, err := io.ReadFull(follow, got[13:]); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello, world!bonjour!" {
+		t.Errorf("wanted to follow the file across the losses of its filesystem, got %q", got)
+	}
+}
+
+func TestRemountWaitClosed(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	fsys := newMemFS()
+	fsys.write("a.log", "hello,", false)
+	follow, err := tailf.Follow("a.log", true, tailf.WithFS(fsys), tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond), tailf.WithRemount(), tailf.WithRetry(tailf.Backoff{Initial: time.Hour, Max: time.Hour}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(follow, make([]byte, 6)); err != nil {
+		t.Fatal(err)
+	}
+
+	// waiting for the filesystem to come back ends with the follower
+	read := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(follow, make([]byte, 1))
+		read <- err
+	}()
+	fsys.setLost(true)
+	time.Sleep(20 * time.Millisecond)
+	if err := follow.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-read; err != io.EOF {
+		t.Errorf("wanted the pending read to reach EOF, got %v", err)
+	}
+	follow.WaitClosed()
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package tailf
+
+import "syscall"
+
+// mountLostErrnos are the errors of reads and stats of files whose
+// filesystem went away: stale NFS handles, I/O errors of devices that
+// were removed, and FUSE filesystems whose daemon is gone.
+var mountLostErrnos = []syscall.Errno{syscall.ESTALE, syscall.EIO, syscall.ENOTCONN, syscall.ENODEV}
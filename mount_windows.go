@@ -0,0 +1,14 @@
+package tailf
+
+import "syscall"
+
+// mountLostErrnos are the errors of reads and stats of files whose
+// filesystem went away: network shares that dropped, and removable
+// media that were removed.
+var mountLostErrnos = []syscall.Errno{
+	21, // ERROR_NOT_READY
+	53, // ERROR_BAD_NETPATH
+	55, // ERROR_DEV_NOT_EXIST
+	59, // ERROR_UNEXP_NET_ERR
+	64, // ERROR_NETNAME_DELETED
+}
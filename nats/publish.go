@@ -0,0 +1,92 @@
+// Package tailfnats publishes the lines written to a file to NATS
+// JetStream, with at-least-once delivery.
+package tailfnats
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/aybabtme/tailf"
+	"github.com/nats-io/nats.go"
+)
+
+// Headers of the messages, telling where their line was read from.
+const (
+	HeaderFile   = "Tailf-File"
+	HeaderOffset = "Tailf-Offset"
+)
+
+// maxPending is the most lines published and waiting to be acknowledged
+// by JetStream.
+const maxPending = 1000
+
+// Publisher publishes messages to JetStream asynchronously, as
+// nats.JetStreamContext does.
+type Publisher interface {
+	PublishMsgAsync(m *nats.Msg, opts ...nats.PubOpt) (nats.PubAckFuture, error)
+}
+
+type pendingLine struct {
+	future nats.PubAckFuture
+	token  tailf.Token
+}
+
+// Publish publishes the lines of f to subject with js, one message per
+// line, until f is closed and drained, ctx is done, or publishing fails.
+//
+// The lines are delivered through a tailf.Acker, and acknowledged once
+// JetStream has stored them, so that a tailf.Checkpointer given f saves
+// the position of the last line known to be stored: resuming from it
+// after a crash publishes again whatever may have been lost. f shouldn't
+// be read from otherwise, and must be closed for Publish to stop reading
+// it.
+func Publish(ctx context.Context, f *tailf.Follower, js Publisher, subject string) error {
+	acker := tailf.NewAcker(f)
+	pending := make(chan pendingLine, maxPending)
+	acked := make(chan error, 1)
+	go func() {
+		for p := range pending {
+			select {
+			case <-p.future.Ok():
+				acker.Ack(p.token)
+			case err := <-p.future.Err():
+				acked <- err
+				return
+			}
+		}
+		acked <- nil
+	}()
+
+	file := f.Filename()
+	for {
+		line, err := acker.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			close(pending)
+			return err
+		}
+
+		msg := nats.NewMsg(subject)
+		msg.Data = line.Bytes
+		msg.Header.Set(HeaderFile, file)
+		msg.Header.Set(HeaderOffset, strconv.FormatInt(line.Offset, 10))
+		future, err := js.PublishMsgAsync(msg)
+		if err != nil {
+			close(pending)
+			return err
+		}
+		select {
+		case pending <- pendingLine{future: future, token: line.Token}:
+		case err := <-acked:
+			return err
+		case <-ctx.Done():
+			close(pending)
+			return ctx.Err()
+		}
+	}
+	close(pending)
+	return <-acked
+}
@@ -0,0 +1,106 @@
+package tailfnats_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	tailfnats "github.com/aybabtme/tailf/nats"
+	"github.com/nats-io/nats.go"
+)
+
+// future is a nats.PubAckFuture resolved as soon as it is created.
+type future struct {
+	msg *nats.Msg
+	ok  chan *nats.PubAck
+	err chan error
+}
+
+func (f *future) Ok() <-chan *nats.PubAck { return f.ok }
+func (f *future) Err() <-chan error       { return f.err }
+func (f *future) Msg() *nats.Msg          { return f.msg }
+
+type fakeJetStream struct {
+	msgs []*nats.Msg
+	fail error
+}
+
+func (js *fakeJetStream) PublishMsgAsync(m *nats.Msg, opts ...nats.PubOpt) (nats.PubAckFuture, error) {
+	js.msgs = append(js.msgs, m)
+	f := &future{msg: m, ok: make(chan *nats.PubAck, 1), err: make(chan error, 1)}
+	if js.fail != nil {
+		f.err <- js.fail
+	} else {
+		f.ok <- &nats.PubAck{Stream: "logs", Sequence: uint64(len(js.msgs))}
+	}
+	return f, nil
+}
+
+func TestPublish(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_nats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "a.log")
+	if err := ioutil.WriteFile(filename, []byte("hello\nworld\npartial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	follow, err := tailf.Follow(filename, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	js := &fakeJetStream{}
+	done := make(chan error, 1)
+	go func() { done <- tailfnats.Publish(context.Background(), follow, js, "logs.a") }()
+	time.Sleep(100 * time.Millisecond)
+	follow.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ data, offset string }{{"hello", "0"}, {"world", "6"}}
+	if len(js.msgs) != len(want) {
+		t.Fatalf("wanted %d messages, got %d", len(want), len(js.msgs))
+	}
+	for i, msg := range js.msgs {
+		if string(msg.Data) != want[i].data || msg.Subject != "logs.a" ||
+			msg.Header.Get(tailfnats.HeaderFile) != filename || msg.Header.Get(tailfnats.HeaderOffset) != want[i].offset {
+			t.Errorf("wanted line %q at offset %s of %s, got %+v", want[i].data, want[i].offset, filename, msg)
+		}
+	}
+}
+
+func TestPublishFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_nats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "a.log")
+	if err := ioutil.WriteFile(filename, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	follow, err := tailf.Follow(filename, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+
+	broken := errors.New("no responders")
+	done := make(chan error, 1)
+	go func() {
+		done <- tailfnats.Publish(context.Background(), follow, &fakeJetStream{fail: broken}, "logs.a")
+	}()
+	time.Sleep(100 * time.Millisecond)
+	follow.Close()
+	if err := <-done; err != broken {
+		t.Errorf("wanted publishing to fail with %v, got %v", broken, err)
+	}
+}
@@ -0,0 +1,253 @@
+// Package tailfnet streams followed files to clients over TCP and unix
+// sockets, optionally over TLS with client certificates, which
+// ServerTLSConfig sets up for the servers of the other packages as well.
+//
+// Files are streamed as raw lines: browsers are served by the websocket
+// package rather than with server-sent events, which aren't supported.
+package tailfnet
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+// defaultWriteTimeout is how long a Server waits for a client to accept
+// a write, by default.
+const defaultWriteTimeout = 10 * time.Second
+
+// Server streams followed files to the clients connecting to it over TCP
+// or unix sockets, like `tail -f | nc -l` does. Each client gets followers
+// of its own, so that it reads at its own pace from where it connected.
+// When several files are streamed, a header naming the file is written
+// before its lines whenever the file changes, as tail(1) does.
+type Server struct {
+	// Files are the files to stream.
+	Files []string
+	// FromStart streams the files from their start, rather than from
+	// their end at the time the client connects.
+	FromStart bool
+	// WriteTimeout is how long a client has to accept a write before
+	// it's disconnected, so that slow clients don't hold on to resources.
+	// 0 means 10 seconds.
+	WriteTimeout time.Duration
+	// Options configure the followers.
+	Options []tailf.Option
+	// TLSConfig, if not nil, makes the server serve TLS, and
+	// authenticate clients if it asks for their certificates.
+	TLSConfig *tls.Config
+	// Authorize, if not nil, is called for each client once the TLS
+	// handshake completed, if any. Clients for which it returns an error
+	// are disconnected. With TLS, conn is a *tls.Conn holding the
+	// certificates the client presented.
+	Authorize func(conn net.Conn) error
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+// ListenAndServe listens on the given network, such as "tcp" or "unix",
+// and address, and serves the clients connecting to it until the server
+// is closed.
+func (s *Server) ListenAndServe(network, addr string) error {
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve serves the clients accepted from l until the server is closed. l
+// is closed when Serve returns.
+func (s *Server) Serve(l net.Listener) error {
+	if s.TLSConfig != nil {
+		l = tls.NewListener(l, s.TLSConfig)
+	}
+	if !s.track(l, nil) {
+		_ = l.Close()
+		return fmt.Errorf("server closed")
+	}
+	defer s.untrack(l, nil)
+	defer l.Close()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return nil
+			}
+			return err
+		}
+		if !s.track(nil, conn) {
+			_ = conn.Close()
+			return nil
+		}
+		go func() {
+			defer s.untrack(nil, conn)
+			s.serveConn(conn)
+		}()
+	}
+}
+
+// Close stops the server, closing its listeners and disconnecting its
+// clients.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for l := range s.listeners {
+		_ = l.Close()
+	}
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	return nil
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// track adds l or conn to what the server closes when it's closed,
+// unless it's already closed.
+func (s *Server) track(l net.Listener, conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	if s.listeners == nil {
+		s.listeners = make(map[net.Listener]struct{})
+		s.conns = make(map[net.Conn]struct{})
+	}
+	if l != nil {
+		s.listeners[l] = struct{}{}
+	}
+	if conn != nil {
+		s.conns[conn] = struct{}{}
+	}
+	return true
+}
+
+func (s *Server) untrack(l net.Listener, conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.listeners, l)
+	delete(s.conns, conn)
+}
+
+// fileLine is a line read from one of the files streamed to a client.
+type fileLine struct {
+	filename string
+	line     []byte
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	timeout := s.WriteTimeout
+	if timeout <= 0 {
+		timeout = defaultWriteTimeout
+	}
+	if tc, ok := conn.(*tls.Conn); ok {
+		if err := tc.SetDeadline(time.Now().Add(timeout)); err != nil {
+			return
+		}
+		if err := tc.Handshake(); err != nil {
+			return
+		}
+		if err := tc.SetDeadline(time.Time{}); err != nil {
+			return
+		}
+	}
+	if s.Authorize != nil {
+		if err := s.Authorize(conn); err != nil {
+			fmt.Fprintf(conn, "tailf: %v\n", err)
+			return
+		}
+	}
+
+	var followers []*tailf.Follower
+	defer func() {
+		for _, f := range followers {
+			_ = f.Close()
+		}
+	}()
+	for _, filename := range s.Files {
+		f, err := tailf.Follow(filename, s.FromStart, s.Options...)
+		if err != nil {
+			fmt.Fprintf(conn, "tailf: %v\n", err)
+			return
+		}
+		followers = append(followers, f)
+	}
+
+	lines := make(chan fileLine)
+	done := make(chan struct{})
+	defer close(done)
+	for i, f := range followers {
+		go readLines(s.Files[i], f, lines, done)
+	}
+
+	// the client going away is noticed by reading from it
+	gone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(ioutil.Discard, conn)
+		close(gone)
+	}()
+
+	var last string
+	for {
+		var l fileLine
+		select {
+		case l = <-lines:
+		case <-gone:
+			return
+		}
+
+		if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+			return
+		}
+		if len(s.Files) > 1 && l.filename != last {
+			header := fmt.Sprintf("==> %s <==\n", l.filename)
+			if last != "" {
+				header = "\n" + header
+			}
+			l.line = append([]byte(header), l.line...)
+			last = l.filename
+		}
+		if _, err := conn.Write(l.line); err != nil {
+			// a slow client timed out, or the client went away
+			return
+		}
+	}
+}
+
+// readLines sends the complete lines read from f to lines, until f is
+// closed or done is.
+func readLines(filename string, f *tailf.Follower, lines chan<- fileLine, done <-chan struct{}) {
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			// a trailing incomplete line is dropped
+			return
+		}
+		select {
+		case lines <- fileLine{filename: filename, line: line}:
+		case <-done:
+			return
+		}
+	}
+}
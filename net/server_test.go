@@ -0,0 +1,86 @@
+package tailfnet_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	tailfnet "github.com/aybabtme/tailf/net"
+)
+
+func TestServer(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello\n"); err != nil {
+			return err
+		}
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return err
+		}
+		srv := &tailfnet.Server{Files: []string{filename}, FromStart: true}
+		served := make(chan error, 1)
+		go func() { served <- srv.Serve(l) }()
+
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		lines := bufio.NewReader(conn)
+
+		if _, err := file.WriteString("world\n"); err != nil {
+			return err
+		}
+		for _, want := range []string{"hello\n", "world\n"} {
+			got, err := lines.ReadString('\n')
+			if err != nil {
+				return err
+			}
+			if got != want {
+				t.Errorf("wanted %q, got %q", want, got)
+			}
+		}
+
+		if err := srv.Close(); err != nil {
+			return err
+		}
+		if err := <-served; err != nil {
+			return fmt.Errorf("serving failed: %v", err)
+		}
+		if _, err := lines.ReadString('\n'); err != io.EOF {
+			t.Errorf("wanted the client to be disconnected, got %v", err)
+		}
+		return nil
+	})
+}
+
+func withTempFile(t *testing.T, timeout time.Duration, action func(t *testing.T, filename string, file *os.File) error) {
+	dir, err := ioutil.TempDir(os.TempDir(), "tailfnet_test_dir")
+	if err != nil {
+		t.Fatalf("couldn't create temp dir: '%v'", err)
+	}
+	defer os.RemoveAll(dir)
+	file, err := ioutil.TempFile(dir, "tailfnet_test")
+	if err != nil {
+		t.Fatalf("couldn't create temp file: '%v'", err)
+	}
+	defer file.Close()
+
+	errc := make(chan error)
+	go func() { errc <- action(t, file.Name(), file) }()
+
+	select {
+	case err = <-errc:
+		if err != nil {
+			t.Errorf("failure: %v", err)
+		}
+	case <-time.After(timeout):
+		t.Error("test took too long :(")
+	}
+}
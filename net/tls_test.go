@@ -0,0 +1,158 @@
+package tailfnet_test
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tailfnet "github.com/aybabtme/tailf/net"
+)
+
+// testCA issues certificates for tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tailf test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a certificate and key for name, PEM encoded.
+func (ca *testCA) issue(t *testing.T, name string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestServerMutualTLS(t *testing.T) {
+	withTempFile(t, time.Second*2, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("secret\n"); err != nil {
+			return err
+		}
+
+		dir, err := ioutil.TempDir("", "tailf_tls")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		ca := newTestCA(t)
+		certPEM, keyPEM := ca.issue(t, "server")
+		for name, data := range map[string][]byte{"ca.pem": ca.pem, "cert.pem": certPEM, "key.pem": keyPEM} {
+			if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+				return err
+			}
+		}
+		cfg, err := tailfnet.ServerTLSConfig(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem"))
+		if err != nil {
+			return err
+		}
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return err
+		}
+		srv := &tailfnet.Server{
+			Files:     []string{filename},
+			FromStart: true,
+			TLSConfig: cfg,
+			Authorize: func(conn net.Conn) error {
+				certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+				if len(certs) == 0 || certs[0].Subject.CommonName != "agent" {
+					return fmt.Errorf("unauthorized")
+				}
+				return nil
+			},
+		}
+		defer srv.Close()
+		go srv.Serve(l)
+
+		roots := x509.NewCertPool()
+		roots.AppendCertsFromPEM(ca.pem)
+		firstLine := func(client string) (string, error) {
+			clientCfg := &tls.Config{RootCAs: roots, ServerName: "server"}
+			if client != "" {
+				certPEM, keyPEM := ca.issue(t, client)
+				cert, err := tls.X509KeyPair(certPEM, keyPEM)
+				if err != nil {
+					return "", err
+				}
+				clientCfg.Certificates = []tls.Certificate{cert}
+			}
+			conn, err := tls.Dial("tcp", l.Addr().String(), clientCfg)
+			if err != nil {
+				return "", err
+			}
+			defer conn.Close()
+			return bufio.NewReader(conn).ReadString('\n')
+		}
+
+		if line, err := firstLine("agent"); err != nil || line != "secret\n" {
+			t.Errorf("wanted the authorized client to read the file, got %q, %v", line, err)
+		}
+		if line, _ := firstLine("intruder"); !strings.HasPrefix(line, "tailf: unauthorized") {
+			t.Errorf("wanted the unauthorized client to be turned away, got %q", line)
+		}
+		if line, err := firstLine(""); err == nil {
+			t.Errorf("wanted the client without a certificate to be turned away, got %q", line)
+		}
+		return nil
+	})
+}
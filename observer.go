@@ -0,0 +1,21 @@
+package tailf
+
+// Observer is notified of the activity of the followers it was given to
+// with WithObserver, to instrument them. Its methods are called while
+// the follower is busy, so they shouldn't block or call the methods of
+// the follower, except from other goroutines.
+type Observer interface {
+	// Followed is called once f started following its file, and Closed
+	// once it was closed.
+	Followed(f *Follower)
+	Closed(f *Follower)
+	// Reopening is called when f is about to open the followed path
+	// again, after its file was rotated or truncated. The returned func
+	// is called with the outcome once it's done.
+	Reopening(f *Follower, truncated bool) (done func(error))
+	// Read is called with the data read from f.
+	Read(f *Follower, data []byte)
+}
+
+// Filename returns the absolute path of the followed file.
+func (f *Follower) Filename() string { return f.filename }
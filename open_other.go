@@ -0,0 +1,23 @@
+//go:build !windows
+// +build !windows
+
+package tailf
+
+import (
+	"io/fs"
+	"os"
+)
+
+// openOS opens the file called name for reading.
+func openOS(name string) (*os.File, error) { return os.Open(name) }
+
+// statOS returns the info of the file called name.
+func statOS(name string) (fs.FileInfo, error) { return os.Stat(name) }
+
+// transientOpenError tells if opening or stating a file failed for a
+// reason that goes away by itself, which never happens here.
+func transientOpenError(err error) bool { return false }
+
+// deletePending tells if err tells the file is being deleted, which
+// never happens here as files are deleted at once.
+func deletePending(err error) bool { return false }
@@ -0,0 +1,76 @@
+package tailf
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+const (
+	errorSharingViolation syscall.Errno = 32
+	errorLockViolation    syscall.Errno = 33
+	errorDeletePending    syscall.Errno = 303
+
+	statusDeletePending = 0xC0000056
+)
+
+var procRtlGetLastNtStatus = syscall.NewLazyDLL("ntdll.dll").NewProc("RtlGetLastNtStatus")
+
+// openOS opens the file called name for reading, sharing it for deletion
+// so that writers can rename and delete it while it's followed, as they
+// can on other platforms.
+func openOS(name string) (*os.File, error) {
+	path, err := syscall.UTF16PtrFromString(longPath(name))
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	// loading ntdll.dll would clobber the status of the open
+	canTellPending := procRtlGetLastNtStatus.Find() == nil
+
+	// the status is per thread
+	runtime.LockOSThread()
+	h, err := syscall.CreateFile(path, syscall.GENERIC_READ,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err == syscall.ERROR_ACCESS_DENIED && canTellPending {
+		// files pending deletion can't be opened, and only the status
+		// tells them from files that can't be read
+		if status, _, _ := procRtlGetLastNtStatus.Call(); status == statusDeletePending {
+			err = errorDeletePending
+		}
+	}
+	runtime.UnlockOSThread()
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return os.NewFile(uintptr(h), name), nil
+}
+
+// statOS returns the info of the file called name.
+func statOS(name string) (fs.FileInfo, error) {
+	fi, err := os.Stat(name)
+	if errors.Is(err, syscall.ERROR_ACCESS_DENIED) {
+		// tell files pending deletion from files that can't be read
+		if file, oerr := openOS(name); oerr == nil {
+			file.Close()
+		} else if deletePending(oerr) {
+			return nil, oerr
+		}
+	}
+	return fi, err
+}
+
+// transientOpenError tells if opening or stating a file failed for a
+// reason that goes away by itself, as when a rotating writer briefly
+// holds the file exclusively, or deleted it while it is still open.
+func transientOpenError(err error) bool {
+	return errors.Is(err, errorSharingViolation) || errors.Is(err, errorLockViolation) || deletePending(err)
+}
+
+// deletePending tells if err tells the file is being deleted, until
+// every handle to it is closed.
+func deletePending(err error) bool {
+	return errors.Is(err, errorDeletePending)
+}
@@ -0,0 +1,284 @@
+package tailf
+
+import (
+	"io"
+	"io/fs"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+// Option configures optional behavior of a follower.
+type Option func(*options)
+
+type options struct {
+	decoding bool
+	encoding encoding.Encoding
+
+	transforms []func() transform.Transformer
+
+	binary BinaryPolicy
+
+	checkpoints CheckpointPolicy
+
+	eventStream bool
+	eventBuffer int
+
+	bufferSize        int
+	rotationBufferMin int
+	rotationBufferMax int
+	spillDir          string
+	dropOldest        bool
+
+	mmap bool
+	ring *URing
+
+	coalesce time.Duration
+
+	fadvise bool
+
+	limiter *RateLimiter
+
+	maxLag int64
+
+	stop   StopPolicy
+	writer int
+	tee    io.Writer
+
+	history int
+
+	polling          bool
+	pollMin, pollMax time.Duration
+
+	fs fs.FS
+
+	fifo FIFOPolicy
+
+	replace ReplacePolicy
+
+	inject injector
+
+	clock Clock
+
+	snapshots        SnapshotMode
+	snapshotInterval time.Duration
+
+	remount         bool
+	permissionRetry bool
+	retry           Backoff
+
+	labels Labels
+
+	observer Observer
+	hooks    Hooks
+	logger   Logger
+
+	heartbeat time.Duration
+
+	// set by FollowRange
+	bounded bool
+	limit   int64
+}
+
+// WithDecoding transcodes the followed data to UTF-8. The encoding of
+// each file is sniffed from its byte order mark, so UTF-16 files written
+// by Windows services are decoded transparently. Files without a byte
+// order mark are decoded using enc, or passed through untouched if enc
+// is nil.
+func WithDecoding(enc encoding.Encoding) Option {
+	return func(o *options) {
+		o.decoding = true
+		o.encoding = enc
+	}
+}
+
+// WithBinary sets the policy applied to non-text content found in the
+// followed data, after it has been decoded by WithDecoding, if at all.
+// Without decoding, any byte that isn't valid UTF-8 is considered
+// non-text.
+func WithBinary(p BinaryPolicy) Option {
+	return func(o *options) {
+		o.binary = p
+	}
+}
+
+// WithTransform passes the followed data through a transformer returned
+// by newT, after it has been decoded and filtered by WithDecoding and
+// WithBinary, if at all. Transforms given in multiple options are applied
+// in order. A new transformer is made for each file the follower moves
+// to, after the data left in the previous file has been flushed through
+// the previous one, so that followers sharing options, such as those of a
+// FollowerSet, don't share the state of their transformers.
+func WithTransform(newT func() transform.Transformer) Option {
+	return func(o *options) {
+		o.transforms = append(o.transforms, newT)
+	}
+}
+
+// CheckpointPolicy decides how often Checkpoint events are emitted. A
+// checkpoint is emitted as soon as any of the non-zero thresholds is
+// reached since the last one.
+type CheckpointPolicy struct {
+	// Bytes is the number of bytes read between checkpoints.
+	Bytes int64
+	// Lines is the number of lines read between checkpoints.
+	Lines int
+	// Interval is the time between checkpoints. Checkpoints are only
+	// emitted when data is read, not while the file is idle.
+	Interval time.Duration
+}
+
+// WithCheckpoints makes the follower emit Checkpoint events on its Events
+// channel according to p.
+func WithCheckpoints(p CheckpointPolicy) Option {
+	return func(o *options) {
+		o.checkpoints = p
+	}
+}
+
+// WithEventStream delivers the followed data as DataChunk events on the
+// Events channel, interleaved with the other events, instead of through
+// Read. Events are then never dropped: the channel must be drained until
+// it is closed, which happens once the follower was closed and its data
+// delivered, or it failed.
+func WithEventStream() Option {
+	return func(o *options) {
+		o.eventStream = true
+	}
+}
+
+// WithEventBuffer sets the capacity of the Events channel, 64 by default.
+// With WithEventStream, a full channel stops the follower from reading
+// the file until the consumer catches up, so the capacity bounds how much
+// data is held in memory for a slow consumer.
+func WithEventBuffer(n int) Option {
+	return func(o *options) {
+		o.eventBuffer = n
+	}
+}
+
+// WithBufferSize sets the size of the buffer through which the file is
+// read, 4096 bytes by default. Small buffers suit constrained targets,
+// while large ones cut the number of reads on busy files.
+func WithBufferSize(n int) Option {
+	return func(o *options) {
+		o.bufferSize = n
+	}
+}
+
+// WithRotationBuffer sets the initial capacity and the ceiling of the
+// buffer holding the data left unread in a file when it is rotated or
+// truncated. Past max bytes, the data is spilled to a temporary file,
+// which is read back transparently. A max of 0 means the default of
+// 4MiB, and a negative max keeps everything in memory.
+func WithRotationBuffer(initial, max int) Option {
+	return func(o *options) {
+		o.rotationBufferMin = initial
+		o.rotationBufferMax = max
+	}
+}
+
+// WithSpillDir sets the directory in which the data left unread in a
+// rotated file is spilled when it doesn't fit in the rotation buffer. It
+// defaults to os.TempDir.
+func WithSpillDir(dir string) Option {
+	return func(o *options) {
+		o.spillDir = dir
+	}
+}
+
+// WithDropOldest bounds the memory used to hold the data left unread in
+// a rotated file, when the consumer can't keep up: rather than spilling
+// to disk past the ceiling set by WithRotationBuffer, the oldest lines
+// are dropped. Drops are reported by Follower.Dropped and Dropped events.
+func WithDropOldest() Option {
+	return func(o *options) {
+		o.dropOldest = true
+	}
+}
+
+// WithMmap memory maps the part of the file already written when
+// following starts, and reads it from the mapping while catching up,
+// which saves copies when slurping a large backlog. Data written later is
+// read normally. Where files can't be mapped, this has no effect.
+func WithMmap() Option {
+	return func(o *options) {
+		o.mmap = true
+	}
+}
+
+// WithURing reads the file through the io_uring backend r, which can be
+// shared by many followers. This is experimental and only available on
+// Linux.
+func WithURing(r *URing) Option {
+	return func(o *options) {
+		o.ring = r
+	}
+}
+
+// WithCoalescing merges the write notifications received within window
+// of the first one into a single wake up of the reader, which saves work
+// when a busy file sees storms of writes. A window of a few milliseconds
+// is usually enough, and adds as much latency.
+func WithCoalescing(window time.Duration) Option {
+	return func(o *options) {
+		o.coalesce = window
+	}
+}
+
+// WithFadvise tells the kernel that the file is read sequentially, and
+// that what was read won't be read again, so that following huge files on
+// busy hosts doesn't evict the working set of other applications from the
+// page cache. This is only effective on Linux.
+func WithFadvise() Option {
+	return func(o *options) {
+		o.fadvise = true
+	}
+}
+
+// WithRateLimit holds back reads to keep under the rates allowed by l.
+// Followers given the same RateLimiter share its rates.
+func WithRateLimit(l *RateLimiter) Option {
+	return func(o *options) {
+		o.limiter = l
+	}
+}
+
+// WithMaxLag makes the follower skip to the end of the file, as with
+// Follower.SkipToLive, whenever a write leaves it more than max bytes
+// behind.
+func WithMaxLag(max int64) Option {
+	return func(o *options) {
+		o.maxLag = max
+	}
+}
+
+// WithHistory keeps the last n lines read from the follower in memory,
+// to be retrieved with Follower.Last.
+func WithHistory(n int) Option {
+	return func(o *options) {
+		o.history = n
+	}
+}
+
+// WithPolling detects writes and rotations by polling the file rather
+// than relying on filesystem notifications, which some filesystems, such
+// as network mounts, don't deliver. The file is polled every min while it
+// changes, and up to every max while it is idle, so that many idle files
+// cost little. On Windows, files on network shares are always polled.
+func WithPolling(min, max time.Duration) Option {
+	return func(o *options) {
+		o.polling = true
+		o.pollMin = min
+		o.pollMax = max
+	}
+}
+
+// WithObserver notifies o of the activity of the follower, so that it can
+// be instrumented.
+func WithObserver(o Observer) Option {
+	return func(opts *options) {
+		opts.observer = o
+	}
+}
@@ -0,0 +1,87 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestSmallBufferSize(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		want := strings.Repeat("hello, world!\n", 10)
+		if _, err := file.WriteString(want); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true, tailf.WithBufferSize(16))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		data := make([]byte, len(want))
+		if _, err := io.ReadAtLeast(follow, data, len(want)); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
+
+func TestCoalescedWrites(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		follow, err := tailf.Follow(filename, false, tailf.WithCoalescing(5*time.Millisecond))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		line := "hello, world!\n"
+		for i := 0; i < 1000; i++ {
+			if _, err := file.WriteString(line); err != nil {
+				return err
+			}
+		}
+
+		want := strings.Repeat(line, 1000)
+		data := make([]byte, len(want))
+		if _, err := io.ReadFull(follow, data); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("data read differs from data written")
+		}
+		return nil
+	})
+}
+
+func TestFadvise(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		want := strings.Repeat("hello, world!\n", 300000)
+		if _, err := file.WriteString(want); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true, tailf.WithFadvise())
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		data := make([]byte, len(want))
+		if _, err := io.ReadFull(follow, data); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("data read differs from data written")
+		}
+		return nil
+	})
+}
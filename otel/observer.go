@@ -0,0 +1,125 @@
+// Package tailfotel instruments tailf followers with OpenTelemetry: spans
+// around the handling of rotations and truncations, and metrics for the
+// throughput and lag of the followers.
+package tailfotel
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aybabtme/tailf"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/aybabtme/tailf/otel"
+
+// Observer is a tailf.Observer recording the activity of followers with
+// OpenTelemetry. A single Observer is meant to be shared by the followers
+// of a process.
+type Observer struct {
+	tracer  trace.Tracer
+	bytes   metric.Int64Counter
+	lines   metric.Int64Counter
+	reopens metric.Int64Counter
+
+	mu        sync.Mutex
+	followers map[*tailf.Follower]struct{}
+}
+
+// New returns an Observer recording metrics with mp and spans with tp.
+func New(mp metric.MeterProvider, tp trace.TracerProvider) (*Observer, error) {
+	o := &Observer{
+		tracer:    tp.Tracer(instrumentationName),
+		followers: make(map[*tailf.Follower]struct{}),
+	}
+	meter := mp.Meter(instrumentationName)
+
+	var err error
+	if o.bytes, err = meter.Int64Counter("tailf.read.bytes",
+		metric.WithDescription("Bytes read."), metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	if o.lines, err = meter.Int64Counter("tailf.read.lines",
+		metric.WithDescription("Lines read.")); err != nil {
+		return nil, err
+	}
+	if o.reopens, err = meter.Int64Counter("tailf.reopens",
+		metric.WithDescription("Times the followed path was opened again.")); err != nil {
+		return nil, err
+	}
+	if _, err = meter.Int64ObservableGauge("tailf.lag",
+		metric.WithDescription("Bytes left to read."), metric.WithUnit("By"),
+		metric.WithInt64Callback(o.observeLag)); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Option returns the option instrumenting a follower with o.
+func (o *Observer) Option() tailf.Option { return tailf.WithObserver(o) }
+
+// Followed implements tailf.Observer.
+func (o *Observer) Followed(f *tailf.Follower) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.followers[f] = struct{}{}
+}
+
+// Closed implements tailf.Observer.
+func (o *Observer) Closed(f *tailf.Follower) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.followers, f)
+}
+
+// Reopening implements tailf.Observer.
+func (o *Observer) Reopening(f *tailf.Follower, truncated bool) func(error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("path", f.Filename()),
+		attribute.Bool("truncated", truncated),
+	}
+	ctx, span := o.tracer.Start(context.Background(), "tailf.reopen", trace.WithAttributes(attrs...))
+	return func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		o.reopens.Add(ctx, 1, metric.WithAttributes(attrs...))
+	}
+}
+
+// Read implements tailf.Observer.
+func (o *Observer) Read(f *tailf.Follower, data []byte) {
+	ctx := context.Background()
+	attrs := metric.WithAttributes(attribute.String("path", f.Filename()))
+	o.bytes.Add(ctx, int64(len(data)), attrs)
+	var lines int64
+	for _, c := range data {
+		if c == '\n' {
+			lines++
+		}
+	}
+	o.lines.Add(ctx, lines, attrs)
+}
+
+func (o *Observer) observeLag(ctx context.Context, obs metric.Int64Observer) error {
+	o.mu.Lock()
+	followers := make([]*tailf.Follower, 0, len(o.followers))
+	for f := range o.followers {
+		followers = append(followers, f)
+	}
+	o.mu.Unlock()
+
+	for _, f := range followers {
+		lag, err := f.Lag()
+		if err != nil {
+			continue
+		}
+		obs.Observe(lag, metric.WithAttributes(attribute.String("path", f.Filename())))
+	}
+	return nil
+}
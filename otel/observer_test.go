@@ -0,0 +1,84 @@
+package tailfotel_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	tailfotel "github.com/aybabtme/tailf/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestObserver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_otel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	obs, err := tailfotel.New(mp, tp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(dir, "a.log")
+	if err := ioutil.WriteFile(filename, []byte("hello\nworld\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	follow, err := tailf.Follow(filename, true, obs.Option())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+	if _, err := io.ReadFull(follow, make([]byte, 6)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Rename(filename, filename+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filename, []byte("hi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(spans.Ended()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if ended := spans.Ended(); len(ended) != 1 || ended[0].Name() != "tailf.reopen" {
+		t.Errorf("wanted a tailf.reopen span, got %v", ended)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				got[m.Name] = data.DataPoints[0].Value
+			case metricdata.Gauge[int64]:
+				got[m.Name] = data.DataPoints[0].Value
+			}
+		}
+	}
+	if got["tailf.read.bytes"] != 6 || got["tailf.read.lines"] != 1 || got["tailf.reopens"] != 1 {
+		t.Errorf("wanted 6 bytes and 1 line read and 1 reopen, got %v", got)
+	}
+	if _, ok := got["tailf.lag"]; !ok {
+		t.Errorf("wanted the lag of the follower, got %v", got)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package tailf
+
+// longPath returns path, which is never too long here.
+func longPath(path string) string { return path }
+
+// shortPath returns path, which is never in a long form here.
+func shortPath(path string) string { return path }
+
+// remotePath tells if path is on a network share, which can't be told
+// here.
+func remotePath(path string) bool { return false }
@@ -0,0 +1,57 @@
+package tailf
+
+import (
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// maxShortPath is the length past which paths must be in the \\?\ form
+// to be used with Windows APIs, which is shorter than MAX_PATH since
+// creating a directory leaves room for a file name.
+const maxShortPath = 248
+
+// longPath returns path in the \\?\ form, which lifts the length limit
+// of Windows APIs, if it is absolute and too long for them.
+func longPath(path string) string {
+	if len(path) < maxShortPath || !filepath.IsAbs(path) || strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	// paths in the \\?\ form are used as is, without being cleaned
+	path = filepath.Clean(path)
+	if strings.HasPrefix(path, `\\`) {
+		// \\server\share\file
+		return `\\?\UNC\` + path[2:]
+	}
+	return `\\?\` + path
+}
+
+// shortPath returns path without the \\?\ prefix, if it has it.
+func shortPath(path string) string {
+	switch {
+	case strings.HasPrefix(path, `\\?\UNC\`):
+		return `\\` + path[len(`\\?\UNC\`):]
+	case strings.HasPrefix(path, `\\?\`):
+		return path[len(`\\?\`):]
+	}
+	return path
+}
+
+// remotePath tells if path is on a network share, named by its UNC path
+// or through a mapped drive.
+func remotePath(path string) bool {
+	vol := filepath.VolumeName(shortPath(path))
+	if strings.HasPrefix(vol, `\\`) {
+		return true
+	}
+	if vol == "" {
+		return false
+	}
+	root, err := syscall.UTF16PtrFromString(vol + `\`)
+	if err != nil {
+		return false
+	}
+	return windows.GetDriveType(root) == windows.DRIVE_REMOTE
+}
@@ -0,0 +1,47 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestFollowLongPath(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		// deeper than MAX_PATH allows
+		dir := filepath.Join(filepath.Dir(filename), strings.Repeat("a", 100), strings.Repeat("b", 100), strings.Repeat("c", 100))
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+		filename = filepath.Join(dir, "a.log")
+		file, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if _, err := file.WriteString("hello, world!"); err != nil {
+			return err
+		}
+		got := make([]byte, len("hello, world!"))
+		if _, err := io.ReadFull(follow, got); err != nil {
+			return err
+		}
+		if string(got) != "hello, world!" {
+			t.Errorf("wanted to follow the file under a long path, got %q", got)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,134 @@
+package tailf
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// ErrPermission signifies the followed file can't be read for lack of
+// permission, as when it was made unreadable, or rotated for a file that
+// is. The FileError of this kind wraps the error opening or reading the
+// file failed with.
+var ErrPermission = errors.New("tailf: permission denied")
+
+// WithPermissionRetry keeps following a file that can't be reopened for
+// lack of permission, instead of failing with ErrPermission: it is tried
+// again as set by WithRetry, until it can be read. An Error event
+// carrying the ErrPermission is emitted meanwhile.
+func WithPermissionRetry() Option {
+	return func(o *options) {
+		o.permissionRetry = true
+	}
+}
+
+// permissionError returns err as an ErrPermission if it tells the file
+// can't be read for lack of permission. The follower must be locked.
+func (f *Follower) permissionError(err error) error {
+	if err == nil || !errors.Is(err, fs.ErrPermission) || errors.Is(err, ErrPermission) {
+		return err
+	}
+	return f.fileError(ErrPermission, err)
+}
+
+// checkPermission returns err as an ErrPermission if the followed path
+// couldn't be reopened for lack of permission. With WithPermissionRetry,
+// it returns nil instead, and the path is reopened once it can be read.
+// The follower must be locked.
+func (f *Follower) checkPermission(err error, truncated bool) error {
+	err = f.permissionError(err)
+	if !errors.Is(err, ErrPermission) || !f.opts.permissionRetry {
+		return err
+	}
+	if !f.denied {
+		f.denied = true
+		f.opts.logger.Warn("can't reopen file, waiting for it to be readable", "path", f.filename, "err", err)
+		f.queue(Error{Err: err})
+		f.background(func() { f.awaitPermission(truncated) })
+	}
+	return nil
+}
+
+// awaitPermission reopens the followed path once it can be read, until
+// the follower is closed. The file is read on from where it was left if
+// it is still the same, and reopened as set by truncated otherwise. If
+// the retry policy gives up, following fails.
+func (f *Follower) awaitPermission(truncated bool) {
+	var file File
+	err := f.opts.retry.retry(f.stopping, f.clock(), func() error {
+		if f.isClosed() {
+			return nil
+		}
+		var err error
+		file, err = openFile(f.filesystem(), f.filename)
+		return err
+	})
+	if f.stopping.Err() != nil {
+		// following stopped while waiting, there's nothing left to reopen
+		if file != nil {
+			_ = file.Close()
+		}
+		return
+	}
+	if f.isClosed() {
+		if file != nil {
+			_ = file.Close()
+		}
+		return
+	}
+	if err == nil {
+		f.opts.logger.Info("file readable again", "path", f.filename)
+		f.mu.Lock()
+		same := f.stillSame(file)
+		f.denied = false
+		f.mu.Unlock()
+		_ = file.Close()
+		if !same {
+			err = f.reopenFile(truncated)
+		}
+	} else {
+		f.mu.Lock()
+		f.denied = false
+		err = f.permissionError(err)
+		f.mu.Unlock()
+	}
+	if err != nil {
+		f.fail(err)
+	}
+	f.notify()
+}
+
+// stillSame tells if file, opened at the followed path, is the file
+// being read. The follower must be locked.
+func (f *Follower) stillSame(file File) bool {
+	if f.released {
+		return false
+	}
+	if native := f.osFile(); native != nil {
+		cur, err := native.Stat()
+		if err != nil {
+			return false
+		}
+		next, err := file.Stat()
+		return err == nil && os.SameFile(cur, next)
+	}
+	fi, err := file.Stat()
+	return err == nil && f.gen.FingerprintLen != 0 && sameFingerprint(f.gen, file) && fi.Size() >= f.position.pos
+}
+
+// checkReadable emits an ErrPermission as an Error event if the followed
+// path can't be read anymore, which is noticed once it is reopened.
+func (f *Follower) checkReadable() {
+	file, err := openFile(f.filesystem(), f.filename)
+	if err == nil {
+		_ = file.Close()
+		return
+	}
+	f.mu.Lock()
+	err = f.permissionError(err)
+	f.mu.Unlock()
+	if errors.Is(err, ErrPermission) {
+		f.opts.logger.Warn("file made unreadable", "path", f.filename, "err", err)
+		f.emit(Error{Err: err})
+	}
+}
@@ -0,0 +1,83 @@
+package tailf_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestPermissionDenied(t *testing.T) {
+	fsys := newMemFS()
+	fsys.write("a.log", "hello,", false)
+
+	follow, err := tailf.Follow("a.log", true, tailf.WithFS(fsys), tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+	if _, err := io.ReadFull(follow, make([]byte, 6)); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys.setDenied(true)
+	_, err = io.ReadFull(follow, make([]byte, 1))
+	if !errors.Is(err, tailf.ErrPermission) || !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("wanted the lack of permission to be reported, got %v", err)
+	}
+}
+
+func TestPermissionRetry(t *testing.T) {
+	fsys := newMemFS()
+	fsys.write("a.log", "hello,", false)
+
+	follow, err := tailf.Follow("a.log", true, tailf.WithFS(fsys), tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond), tailf.WithPermissionRetry(), tailf.WithRetry(tailf.Backoff{Initial: 5 * time.Millisecond, Max: 5 * time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+
+	got := make([]byte, len("hello, world!bonjour!"))
+	if _, err := io.ReadFull(follow, got[:6]); err != nil {
+		t.Fatal(err)
+	}
+
+	awaitDenied := func() {
+		t.Helper()
+		timeout := time.After(time.Second)
+		for {
+			select {
+			case ev := <-follow.Events():
+				if e, ok := ev.(tailf.Error); ok && errors.Is(e.Err, tailf.ErrPermission) {
+					return
+				}
+			case <-timeout:
+				t.Fatal("wanted the lack of permission to be reported")
+			}
+		}
+	}
+
+	fsys.setDenied(true)
+	awaitDenied()
+	fsys.write("a.log", " world!", false)
+	fsys.setDenied(false)
+	if _, err := io.ReadFull(follow, got[6:13]); err != nil {
+		t.Fatal(err)
+	}
+
+	// replaced while it couldn't be read, once found readable again
+	time.Sleep(20 * time.Millisecond)
+	fsys.setDenied(true)
+	awaitDenied()
+	fsys.write("a.log", "bonjour!", true)
+	fsys.setDenied(false)
+	if _, err := io.ReadFull(follow, got[13:]); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello, world!bonjour!" {
+		t.Errorf("wanted to follow the file once readable again, got %q", got)
+	}
+}
@@ -0,0 +1,201 @@
+package tailf
+
+import (
+	"bufio"
+	"io"
+	"sync"
+)
+
+// Record is a unit of data flowing through a Pipeline. Records start out
+// as single lines, which stages may filter, merge or decode.
+type Record struct {
+	// Bytes is the content of the record, without its trailing newline.
+	Bytes []byte
+	// Value is the result of the last Decode stage, if any.
+	Value interface{}
+	// Err is the error returned by the last Decode stage, if any.
+	Err error
+}
+
+// Pipeline composes line-oriented stages over a stream, typically a
+// Follower. Stages are applied in the order they were added, and records
+// are delivered on the channel returned by Lines.
+type Pipeline struct {
+	src    io.Reader
+	stages []stage
+	buffer int
+	done   chan struct{} // closed by Close
+
+	mu     sync.Mutex
+	err    error
+	closed bool
+}
+
+// maxRecord is the length past which a line ends the pipeline with
+// bufio.ErrTooLong.
+const maxRecord = 1024 * 1024
+
+// stage is a step of a Pipeline. push is called for every record going
+// through the stage, and flush when the source is exhausted.
+type stage interface {
+	push(r Record, emit func(Record))
+	flush(emit func(Record))
+}
+
+// New returns a Pipeline reading lines from src.
+func New(src io.Reader) *Pipeline {
+	return &Pipeline{src: src, done: make(chan struct{})}
+}
+
+// Filter drops the records for which keep returns false.
+func (p *Pipeline) Filter(keep func(Record) bool) *Pipeline {
+	return p.add(stageFunc(func(r Record, emit func(Record)) {
+		if keep(r) {
+			emit(r)
+		}
+	}))
+}
+
+// Multiline merges lines into a single record, such as the lines of a
+// stack trace. Lines for which isContinuation returns true are appended,
+// newline separated, to the record before them. Since a record can't be
+// known to be complete before the next one starts, the last record is
+// held back until another one starts or the source is exhausted.
+func (p *Pipeline) Multiline(isContinuation func(line []byte) bool) *Pipeline {
+	return p.add(&multiline{isContinuation: isContinuation})
+}
+
+// Decode sets the Value and Err of each record to the result of calling
+// decode on its Bytes, for instance to parse JSON lines.
+func (p *Pipeline) Decode(decode func([]byte) (interface{}, error)) *Pipeline {
+	return p.add(stageFunc(func(r Record, emit func(Record)) {
+		r.Value, r.Err = decode(r.Bytes)
+		emit(r)
+	}))
+}
+
+// Buffer sets the capacity of the channel returned by Lines, which is
+// unbuffered by default. The source is only read while the channel has
+// room, so a slow consumer holds back the follower rather than records
+// piling up in memory.
+func (p *Pipeline) Buffer(n int) *Pipeline {
+	p.buffer = n
+	return p
+}
+
+// Lines starts reading the source and returns the channel on which the
+// records coming out of the pipeline are delivered. The channel is closed
+// once the source returns an error, which is then reported by Err, or the
+// pipeline is closed. Lines longer than a megabyte end the pipeline with
+// bufio.ErrTooLong.
+func (p *Pipeline) Lines() <-chan Record {
+	out := make(chan Record, p.buffer)
+	go p.run(out)
+	return out
+}
+
+// Err returns the error that ended the pipeline, if it wasn't io.EOF.
+func (p *Pipeline) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Close stops the pipeline, for consumers that stop reading its records
+// before the source ends: records aren't delivered anymore, and the
+// channel returned by Lines is closed. Since reading a source such as a
+// Follower blocks until data is written, the source is closed if it is an
+// io.Closer.
+func (p *Pipeline) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	if c, ok := p.src.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (p *Pipeline) add(s stage) *Pipeline {
+	p.stages = append(p.stages, s)
+	return p
+}
+
+func (p *Pipeline) run(out chan<- Record) {
+	defer close(out)
+
+	// chain the stages back to front, the last one delivering to out
+	emit := func(r Record) {
+		select {
+		case out <- r:
+		case <-p.done:
+		}
+	}
+	emitters := make([]func(Record), len(p.stages)+1)
+	emitters[len(p.stages)] = emit
+	for i := len(p.stages) - 1; i >= 0; i-- {
+		s, next := p.stages[i], emitters[i+1]
+		emitters[i] = func(r Record) { s.push(r, next) }
+	}
+
+	scanner := bufio.NewScanner(p.src)
+	scanner.Buffer(nil, maxRecord)
+	for scanner.Scan() {
+		select {
+		case <-p.done:
+			return
+		default:
+		}
+		line := append([]byte(nil), scanner.Bytes()...)
+		emitters[0](Record{Bytes: line})
+	}
+
+	for i, s := range p.stages {
+		s.flush(emitters[i+1])
+	}
+
+	p.mu.Lock()
+	if !p.closed {
+		p.err = scanner.Err()
+	}
+	p.mu.Unlock()
+}
+
+type stageFunc func(r Record, emit func(Record))
+
+func (fn stageFunc) push(r Record, emit func(Record)) { fn(r, emit) }
+func (fn stageFunc) flush(emit func(Record))          {}
+
+type multiline struct {
+	isContinuation func(line []byte) bool
+	pending        *Record
+}
+
+func (m *multiline) push(r Record, emit func(Record)) {
+	if m.pending != nil && m.isContinuation(r.Bytes) {
+		m.pending.Bytes = append(append(m.pending.Bytes, '\n'), r.Bytes...)
+		return
+	}
+	m.flush(emit)
+	m.pending = &r
+}
+
+func (m *multiline) flush(emit func(Record)) {
+	if m.pending == nil {
+		return
+	}
+	r := *m.pending
+	m.pending = nil
+	emit(r)
+}
+
+// IsIndented tells if a line starts with whitespace, which is how most
+// stack traces mark their continuation lines. It is meant to be used with
+// Pipeline.Multiline.
+func IsIndented(line []byte) bool {
+	return len(line) != 0 && (line[0] == ' ' || line[0] == '\t')
+}
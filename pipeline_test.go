@@ -0,0 +1,122 @@
+package tailf_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestPipeline(t *testing.T) {
+	src := strings.NewReader(strings.Join([]string{
+		`{"msg": "starting"}`,
+		`debug: noise`,
+		`{"msg": "panic",`,
+		`  "stack": "main.go:12"}`,
+		`{"msg": "done"}`,
+	}, "\n"))
+
+	lines := tailf.New(src).
+		Filter(func(r tailf.Record) bool { return !bytes.HasPrefix(r.Bytes, []byte("debug:")) }).
+		Multiline(tailf.IsIndented).
+		Decode(func(b []byte) (interface{}, error) {
+			var v map[string]string
+			err := json.Unmarshal(b, &v)
+			return v["msg"], err
+		}).
+		Lines()
+
+	var got []interface{}
+	for r := range lines {
+		if r.Err != nil {
+			t.Errorf("couldn't decode %q: %v", r.Bytes, r.Err)
+		}
+		got = append(got, r.Value)
+	}
+
+	want := []interface{}{"starting", "panic", "done"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+}
+
+func TestPipelineBackpressure(t *testing.T) {
+	r := &countingReader{r: strings.NewReader(strings.Repeat("hello, world!\n", 100000))}
+	lines := tailf.New(r).Buffer(10).Lines()
+	if got := cap(lines); got != 10 {
+		t.Fatalf("wanted a channel of capacity 10, got %d", got)
+	}
+
+	// without a consumer, reading stops once the channel is full
+	<-lines
+	time.Sleep(50 * time.Millisecond)
+	if n := r.count(); n >= 100000*14 {
+		t.Errorf("wanted the source to be held back, %d bytes were read", n)
+	}
+	for range lines {
+	}
+}
+
+type countingReader struct {
+	r  io.Reader
+	mu sync.Mutex
+	n  int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.mu.Lock()
+	c.n += n
+	c.mu.Unlock()
+	return n, err
+}
+
+func (c *countingReader) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
+func TestPipelineClose(t *testing.T) {
+	r, w := io.Pipe()
+	p := tailf.New(r)
+	lines := p.Lines()
+	go w.Write([]byte("hello\n"))
+	if r := <-lines; string(r.Bytes) != "hello" {
+		t.Errorf("wanted %q, got %q", "hello", r.Bytes)
+	}
+
+	// the source blocks, and is closed along with the pipeline
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case _, open := <-lines:
+		if open {
+			t.Error("wanted no record once closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wanted the channel closed")
+	}
+	if err := p.Err(); err != nil {
+		t.Errorf("wanted no error, got %v", err)
+	}
+}
+
+func TestPipelineLongLine(t *testing.T) {
+	long := strings.Repeat("x", 100*1024)
+	lines := tailf.New(strings.NewReader(long + "\nshort\n")).Lines()
+	var got []string
+	for r := range lines {
+		got = append(got, string(r.Bytes))
+	}
+	if want := []string{long, "short"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wanted a %d bytes line then %q, got %d lines", len(long), "short", len(got))
+	}
+}
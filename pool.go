@@ -0,0 +1,103 @@
+package tailf
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Buffers are pooled across followers, so that processes following
+// thousands of files don't each hold on to buffers of their own once
+// they're done with them.
+
+const (
+	defaultBufferSize = 4096
+	// maxPooledBuffer is the capacity past which a rotation buffer, grown
+	// by a large backlog, is left to the garbage collector instead.
+	maxPooledBuffer = 1 << 20
+	// copyBufferSize is the size of the buffers WriteTo copies through.
+	copyBufferSize = 256 * 1024
+)
+
+var (
+	readerPools sync.Map // buffer size -> *sync.Pool of *bufio.Reader
+	bufferPool  = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+	copyPool    = sync.Pool{New: func() interface{} {
+		buf := make([]byte, copyBufferSize)
+		return &buf
+	}}
+)
+
+func readerPool(size int) *sync.Pool {
+	if p, ok := readerPools.Load(size); ok {
+		return p.(*sync.Pool)
+	}
+	p, _ := readerPools.LoadOrStore(size, &sync.Pool{New: func() interface{} {
+		return bufio.NewReaderSize(nil, size)
+	}})
+	return p.(*sync.Pool)
+}
+
+// getReader returns a bufio.Reader of the given size reading from r, or
+// of the default size if size isn't positive.
+func getReader(r io.Reader, size int) *bufio.Reader {
+	if size <= 0 {
+		size = defaultBufferSize
+	}
+	br := readerPool(size).Get().(*bufio.Reader)
+	br.Reset(r)
+	return br
+}
+
+func putReader(br *bufio.Reader) {
+	br.Reset(nil)
+	readerPool(br.Size()).Put(br)
+}
+
+// getBuffer returns an empty buffer with room for at least capacity
+// bytes.
+func getBuffer(capacity int) *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	buf.Grow(capacity)
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// getCopyBuffer returns a buffer of at least size bytes to copy data
+// through.
+func getCopyBuffer(size int) *[]byte {
+	if size > copyBufferSize {
+		buf := make([]byte, size)
+		return &buf
+	}
+	return copyPool.Get().(*[]byte)
+}
+
+func putCopyBuffer(buf *[]byte) {
+	if len(*buf) == copyBufferSize {
+		copyPool.Put(buf)
+	}
+}
+
+// release returns the buffers of the follower to the pools, once it was
+// closed and everything it buffered was read. The cursor is kept, since
+// it can still be asked for.
+func (f *Follower) release() {
+	if f.released {
+		return
+	}
+	f.final = f.cursor()
+	f.released = true
+	putReader(f.fileReader)
+	f.rotationBuffer.close()
+	f.fileReader, f.rotationBuffer, f.transformer, f.reader = nil, nil, nil, nil
+}
@@ -0,0 +1,45 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestReadsAfterCloseReachEOF(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		want := "hello, world!\n"
+		if _, err := file.WriteString(want); err != nil {
+			return err
+		}
+
+		for i := 0; i < 100; i++ {
+			follow, err := tailf.Follow(filename, true)
+			if err != nil {
+				return fmt.Errorf("failed creating tailf.follower: %v", err)
+			}
+			data := make([]byte, len(want))
+			if _, err := io.ReadFull(follow, data); err != nil {
+				return err
+			}
+			if got := string(data); got != want {
+				t.Errorf("wanted '%v', got '%v'", want, got)
+			}
+			if err := follow.Close(); err != nil {
+				return err
+			}
+
+			if n, err := follow.Read(data); n != 0 || err != io.EOF {
+				t.Errorf("wanted EOF after close, got %d bytes and %v", n, err)
+			}
+			if off := follow.Cursor().Offset; off != int64(len(want)) {
+				t.Errorf("wanted cursor at %d after close, got %d", len(want), off)
+			}
+		}
+		return nil
+	})
+}
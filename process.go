@@ -0,0 +1,34 @@
+package tailf
+
+import "time"
+
+// writerPoll is how often the writer set with WithWriter is checked for
+// having exited.
+const writerPoll = 100 * time.Millisecond
+
+// WithWriter ties following to the process of pid writing the file, such
+// as the Pid of an os.Process started to run a command: once it exited,
+// reads return what is left to read of the file, and then io.EOF. The
+// follower must still be closed.
+func WithWriter(pid int) Option {
+	return func(o *options) {
+		o.writer = pid
+	}
+}
+
+// watchWriter checks on the writer set with WithWriter until it exits,
+// waking up the reader to drain the file, or until following stops.
+func (f *Follower) watchWriter() {
+	for !processExited(f.opts.writer) {
+		select {
+		case <-f.clock().After(writerPoll):
+		case <-f.stopped:
+			return
+		}
+	}
+	f.mu.Lock()
+	f.writerExited = true
+	f.mu.Unlock()
+	f.opts.logger.Info("writer exited", "path", f.filename, "pid", f.opts.writer)
+	f.notify()
+}
@@ -0,0 +1,59 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestWriterExit(t *testing.T) {
+	withTempFile(t, 2*time.Second, func(t *testing.T, filename string, file *os.File) error {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestWriterProcess$")
+		cmd.Env = append(os.Environ(), "TAILF_WRITER_FILE="+filename)
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		defer cmd.Wait()
+
+		follow, err := tailf.Follow(filename, true, tailf.WithWriter(cmd.Process.Pid))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		// reads until the writer exited, without waiting on it
+		got, err := ioutil.ReadAll(follow)
+		if err != nil {
+			return err
+		}
+		if want := "hello\nworld\n"; string(got) != want {
+			t.Errorf("wanted to read %q, got %q", want, got)
+		}
+		return nil
+	})
+}
+
+// TestWriterProcess is the writer of TestWriterExit, run in a process of
+// its own.
+func TestWriterProcess(t *testing.T) {
+	filename := os.Getenv("TAILF_WRITER_FILE")
+	if filename == "" {
+		t.Skip("run by TestWriterExit")
+	}
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	for _, line := range []string{"hello\n", "world\n"} {
+		time.Sleep(50 * time.Millisecond)
+		if _, err := file.WriteString(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
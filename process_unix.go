@@ -0,0 +1,27 @@
+//go:build !windows
+// +build !windows
+
+package tailf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strconv"
+	"syscall"
+)
+
+// processExited tells if the process of pid exited. Processes that
+// exited but weren't waited for by their parent yet are found exited
+// where /proc tells their state.
+func processExited(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil && err != syscall.EPERM {
+		return true
+	}
+	stat, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return false
+	}
+	// the state follows the command, which is in parentheses
+	i := bytes.LastIndexByte(stat, ')')
+	return i >= 0 && i+2 < len(stat) && (stat[i+2] == 'Z' || stat[i+2] == 'X')
+}
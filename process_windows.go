@@ -0,0 +1,15 @@
+package tailf
+
+import "golang.org/x/sys/windows"
+
+// processExited tells if the process of pid exited.
+func processExited(pid int) bool {
+	h, err := windows.OpenProcess(windows.SYNCHRONIZE, false, uint32(pid))
+	if err != nil {
+		// there is no such process, unless it can't be opened
+		return err != windows.ERROR_ACCESS_DENIED
+	}
+	defer windows.CloseHandle(h)
+	ev, err := windows.WaitForSingleObject(h, 0)
+	return err == nil && ev == windows.WAIT_OBJECT_0
+}
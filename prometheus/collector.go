@@ -0,0 +1,94 @@
+// Package tailfprometheus exports the stats of tailf followers as Prometheus
+// metrics.
+package tailfprometheus
+
+import (
+	"github.com/aybabtme/tailf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector exporting the stats of the
+// followers of a tailf.Checkpointer, labeled by followed path.
+type Collector struct {
+	group *tailf.Checkpointer
+
+	lag       *prometheus.Desc
+	bytes     *prometheus.Desc
+	lines     *prometheus.Desc
+	rotations *prometheus.Desc
+	truncates *prometheus.Desc
+	reopens   *prometheus.Desc
+	errors    *prometheus.Desc
+	openFiles *prometheus.Desc
+	latency   *prometheus.Desc
+	readTime  *prometheus.Desc
+}
+
+// NewCollector returns a Collector for the followers of group, with
+// metric names starting with namespace.
+func NewCollector(namespace string, group *tailf.Checkpointer) *Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, []string{"path"}, nil)
+	}
+	return &Collector{
+		group:     group,
+		lag:       desc("lag_bytes", "Bytes left to read."),
+		bytes:     desc("read_bytes_total", "Bytes read."),
+		lines:     desc("read_lines_total", "Lines read."),
+		rotations: desc("rotations_total", "Rotations of the followed file."),
+		truncates: desc("truncations_total", "Truncations of the followed file."),
+		reopens:   desc("reopens_total", "Times the followed path was opened again."),
+		errors:    desc("errors_total", "Failed reads."),
+		openFiles: desc("open_files", "File descriptors held by the follower."),
+		latency:   desc("latency_seconds", "Time from a write being noticed to data being read."),
+		readTime:  desc("read_duration_seconds", "Time spent in reads returning data."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.lag
+	ch <- c.bytes
+	ch <- c.lines
+	ch <- c.rotations
+	ch <- c.truncates
+	ch <- c.reopens
+	ch <- c.errors
+	ch <- c.openFiles
+	ch <- c.latency
+	ch <- c.readTime
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for path, s := range c.group.FollowerStats() {
+		gauge := func(desc *prometheus.Desc, v float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, path)
+		}
+		counter := func(desc *prometheus.Desc, v int64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(v), path)
+		}
+		gauge(c.lag, float64(s.Lag))
+		counter(c.bytes, s.Bytes)
+		counter(c.lines, s.Lines)
+		counter(c.rotations, s.Rotations)
+		counter(c.truncates, s.Truncations)
+		counter(c.reopens, s.Reopens)
+		counter(c.errors, s.Errors)
+		gauge(c.openFiles, float64(s.OpenFiles))
+		ch <- histogram(c.latency, s.Latency, path)
+		ch <- histogram(c.readTime, s.ReadTime, path)
+	}
+}
+
+// histogram converts h to a Prometheus histogram, whose buckets are
+// cumulative and in seconds.
+func histogram(desc *prometheus.Desc, h tailf.Histogram, path string) prometheus.Metric {
+	buckets := make(map[float64]uint64, len(tailf.LatencyBuckets))
+	var cumulative uint64
+	for i, bound := range tailf.LatencyBuckets {
+		cumulative += uint64(h.Counts[i])
+		buckets[bound.Seconds()] = cumulative
+	}
+	return prometheus.MustNewConstHistogram(desc, uint64(h.Count), h.Sum.Seconds(), buckets, path)
+}
@@ -0,0 +1,58 @@
+package tailfprometheus_test
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	tailfprometheus "github.com/aybabtme/tailf/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_prometheus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := tailf.NewCheckpointer(tailf.NewFileStore(filepath.Join(dir, "state")), time.Hour)
+	defer c.Close()
+
+	filename := filepath.Join(dir, "a.log")
+	if err := ioutil.WriteFile(filename, []byte("hello\nworld\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	follow, err := tailf.Follow(filename, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+	if _, err := io.ReadFull(follow, make([]byte, 6)); err != nil {
+		t.Fatal(err)
+	}
+	c.Add(follow)
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(tailfprometheus.NewCollector("tailf", c)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `
+# HELP tailf_read_lines_total Lines read.
+# TYPE tailf_read_lines_total counter
+tailf_read_lines_total{path="` + filename + `"} 1
+# HELP tailf_lag_bytes Bytes left to read.
+# TYPE tailf_lag_bytes gauge
+tailf_lag_bytes{path="` + filename + `"} 6
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "tailf_read_lines_total", "tailf_lag_bytes"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,36 @@
+package tailf
+
+import (
+	"fmt"
+	"os"
+)
+
+// FollowRange follows filename from offset from. If thenLive is false,
+// reads reach io.EOF once offset to is read, even if the file was rotated
+// or truncated in the meantime, so that a range of the file can be
+// replayed. Otherwise, following goes on past to like any Follower would,
+// which allows backfilling a range and then tailing live writes with the
+// same reader.
+func FollowRange(filename string, from, to int64, thenLive bool, opts ...Option) (*Follower, error) {
+	if from < 0 || to < from {
+		return nil, fmt.Errorf("invalid range [%d, %d)", from, to)
+	}
+
+	file, err := openFile(filesystem(opts), filename)
+	if err != nil {
+		return nil, err
+	}
+	pos, err := file.Seek(from, os.SEEK_SET)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	if !thenLive {
+		opts = append(opts[:len(opts):len(opts)], func(o *options) {
+			o.bounded = true
+			o.limit = to
+		})
+	}
+	return follow(filename, file, pos, opts)
+}
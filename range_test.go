@@ -0,0 +1,76 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestFollowRange(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!\n"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.FollowRange(filename, 7, 12, false)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		// rotate the file before the range is read
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+		file, err = os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := file.WriteString("goodbye\n"); err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadAll(follow)
+		if err != nil {
+			return err
+		}
+		if want, got := "world", string(data); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
+
+func TestFollowRangeThenLive(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!\n"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.FollowRange(filename, 7, 14, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if _, err := file.WriteString("goodbye\n"); err != nil {
+			return err
+		}
+
+		want := "world!\ngoodbye\n"
+		data := make([]byte, len(want))
+		if _, err := io.ReadAtLeast(follow, data, len(want)); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
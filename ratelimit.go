@@ -0,0 +1,87 @@
+package tailf
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// RateLimiter caps the rate at which data is read from the followers
+// using it, with token buckets allowing bursts of up to a second worth of
+// data. Sharing a RateLimiter between followers caps their combined rate.
+// Followers are held back on their Clock, as set with WithClock.
+type RateLimiter struct {
+	mu        sync.Mutex
+	bytes     bucket
+	lines     bucket
+	throttled time.Duration
+}
+
+// NewRateLimiter returns a RateLimiter allowing bytesPerSec bytes and
+// linesPerSec lines to be read every second. A rate of 0 means no limit.
+func NewRateLimiter(bytesPerSec, linesPerSec float64) *RateLimiter {
+	return &RateLimiter{
+		bytes: bucket{rate: bytesPerSec, tokens: bytesPerSec},
+		lines: bucket{rate: linesPerSec, tokens: linesPerSec},
+	}
+}
+
+// Throttled returns how long readers were held back by the limiter in
+// total.
+func (l *RateLimiter) Throttled() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.throttled
+}
+
+// wait accounts for data having been read, sleeping on clock as long as
+// needed to keep under the limits. It returns how long it slept.
+func (l *RateLimiter) wait(data []byte, clock Clock) time.Duration {
+	if len(data) == 0 {
+		return 0
+	}
+	l.mu.Lock()
+	now := clock.Now()
+	d := l.bytes.take(float64(len(data)), now)
+	if l.lines.rate > 0 {
+		if dl := l.lines.take(float64(bytes.Count(data, []byte{'\n'})), now); dl > d {
+			d = dl
+		}
+	}
+	l.throttled += d
+	l.mu.Unlock()
+
+	if d > 0 {
+		<-clock.After(d)
+	}
+	return d
+}
+
+// bucket is a token bucket refilled at rate tokens per second, holding up
+// to rate tokens. Tokens can be taken ahead of time, the taker then waits
+// for them to be refilled.
+type bucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time // when tokens were last taken, zero until then
+}
+
+// take removes n tokens, returning how long to wait until they are
+// refilled.
+func (b *bucket) take(n float64, now time.Time) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+	}
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
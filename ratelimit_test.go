@@ -0,0 +1,47 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestRateLimit(t *testing.T) {
+	withTempFile(t, 2*time.Second, func(t *testing.T, filename string, file *os.File) error {
+		want := strings.Repeat("hello, world!\n", 1500)
+		if _, err := file.WriteString(want); err != nil {
+			return err
+		}
+
+		limiter := tailf.NewRateLimiter(0, 1000)
+		follow, err := tailf.Follow(filename, true, tailf.WithRateLimit(limiter))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		start := time.Now()
+		data := make([]byte, len(want))
+		if _, err := io.ReadFull(follow, data); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("data read differs from data written")
+		}
+
+		// a second worth of lines is allowed right away, the rest takes
+		// half a second
+		if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+			t.Errorf("wanted reading to take about 500ms, took %v", elapsed)
+		}
+		if throttled := limiter.Throttled(); throttled < 400*time.Millisecond {
+			t.Errorf("wanted about 500ms of throttling, got %v", throttled)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,22 @@
+package tailf
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// readv fills bufs in order from the offset of file with a single
+// readv(2), advancing the offset.
+func readv(file *os.File, bufs [][]byte) (int, error) {
+	for {
+		n, err := unix.Readv(int(file.Fd()), bufs)
+		if err == unix.EINTR {
+			continue
+		}
+		if n < 0 {
+			n = 0
+		}
+		return n, err
+	}
+}
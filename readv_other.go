@@ -0,0 +1,21 @@
+//go:build !linux
+// +build !linux
+
+package tailf
+
+import "os"
+
+// readv(2) is only used on Linux, elsewhere the buffers are read into one
+// after the other.
+
+func readv(file *os.File, bufs [][]byte) (int, error) {
+	var total int
+	for _, b := range bufs {
+		n, err := file.Read(b)
+		total += n
+		if err != nil || n < len(b) {
+			return total, err
+		}
+	}
+	return total, nil
+}
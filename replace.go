@@ -0,0 +1,68 @@
+package tailf
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+)
+
+// ReplacePolicy decides where the file that replaced the followed file is
+// read from, as when editors save a file by writing a new one and
+// renaming it over the old one.
+type ReplacePolicy int
+
+const (
+	// ReplaceRereads reads the new file from its start, as a rotated
+	// file.
+	ReplaceRereads ReplacePolicy = iota
+	// ReplaceDiffs reads the new file from where the old one ended, if it
+	// starts as the old one did and is at least as long, so that only
+	// what was appended is delivered. It is read from its start otherwise.
+	ReplaceDiffs
+)
+
+// WithReplacePolicy sets where a file that replaced the followed file is
+// read from, ReplaceRereads by default.
+func WithReplacePolicy(p ReplacePolicy) Option {
+	return func(o *options) {
+		o.replace = p
+	}
+}
+
+// errFileReplaced tells that another file was moved to the followed path,
+// rather than the followed file being truncated.
+var errFileReplaced = errors.New("file was replaced")
+
+// replaced tells if fi, the info of the followed path, describes another
+// file than the one being read.
+func (f *Follower) replaced(fi fs.FileInfo) bool {
+	f.mu.Lock()
+	cur, err := f.file.Stat()
+	f.mu.Unlock()
+	return err == nil && !f.sameFile(cur, fi)
+}
+
+// replacedOffset returns where to read next, the file that replaced the
+// file prev, from. The follower must be locked.
+func (f *Follower) replacedOffset(prev Cursor, next File) int64 {
+	if f.opts.replace != ReplaceDiffs || prev.FingerprintLen == 0 {
+		return 0
+	}
+	if prev.FingerprintLen < fingerprintSize && int64(prev.FingerprintLen) < prev.Offset {
+		// what was read past the fingerprint of a short file can't be
+		// compared, as when it shrank before being replaced
+		return 0
+	}
+	if !sameFingerprint(prev, next) {
+		return 0
+	}
+	fi, err := next.Stat()
+	if err != nil || fi.Size() < prev.Offset {
+		return 0
+	}
+	if _, err := next.Seek(prev.Offset, io.SeekStart); err != nil {
+		return 0
+	}
+	f.opts.logger.Debug("file replaced, reading what was appended", "path", f.filename, "offset", prev.Offset)
+	return prev.Offset
+}
@@ -0,0 +1,76 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+// save replaces filename with a file holding data, as editors save files.
+func save(filename, data string) error {
+	tmp := filepath.Join(filepath.Dir(filename), "."+filepath.Base(filename)+".swp")
+	if err := ioutil.WriteFile(tmp, []byte(data), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+func TestReplacePolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy tailf.ReplacePolicy
+		saved  string
+		want   string
+	}{
+		{"rereads", tailf.ReplaceRereads, "hello\nworld\n", "hello\nworld\n"},
+		{"diffs", tailf.ReplaceDiffs, "hello\nworld\n", "world\n"},
+		{"diffs edited", tailf.ReplaceDiffs, "howdy\nworld\n", "howdy\nworld\n"},
+		{"shortened", tailf.ReplaceDiffs, "bye\n", "bye\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+				if _, err := file.WriteString("hello\n"); err != nil {
+					return err
+				}
+				// the writer is done, as editors are once they saved
+				if err := file.Close(); err != nil {
+					return err
+				}
+
+				follow, err := tailf.Follow(filename, true, tailf.WithReplacePolicy(tc.policy))
+				if err != nil {
+					return fmt.Errorf("failed creating tailf.follower: %v", err)
+				}
+				defer follow.Close()
+				if _, err := io.ReadFull(follow, make([]byte, len("hello\n"))); err != nil {
+					return err
+				}
+
+				if err := save(filename, tc.saved); err != nil {
+					return err
+				}
+				got := make([]byte, len(tc.want))
+				if _, err := io.ReadFull(follow, got); err != nil {
+					return err
+				}
+				if string(got) != tc.want {
+					t.Errorf("wanted %q once saved, got %q", tc.want, got)
+				}
+				for {
+					switch ev := (<-follow.Events()).(type) {
+					case tailf.Rotated:
+						return nil
+					case tailf.Truncated:
+						return fmt.Errorf("wanted the saved file to replace the followed one, got %#v", ev)
+					}
+				}
+			})
+		})
+	}
+}
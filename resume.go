@@ -0,0 +1,111 @@
+package tailf
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Resume continues following filename from where c was taken, such as
+// after the process restarted:
+//
+//   - if filename is still the file c points in, following resumes at
+//     c.Offset, or from the start if the file was truncated since.
+//   - if filename has the inode c points in but not the fingerprinted
+//     content, the inode was reused by a new file, which is followed from
+//     its start.
+//   - if the file was rotated and can still be found next to filename
+//     under a name starting with the same base name, the rest of it is
+//     read before following resumes from the start of filename.
+//   - otherwise, filename is followed from its start.
+func Resume(filename string, c Cursor, opts ...Option) (*Follower, error) {
+	fsys := filesystem(opts)
+	file, err := openFile(fsys, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if sameFile(c, file) {
+		pos, err := resumeAt(file, c.Offset)
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		return follow(filename, file, pos, opts)
+	}
+
+	rotated := findRotated(fsys, filename, c)
+	if rotated == nil {
+		// can't tell where the cursor was, start over
+		return follow(filename, file, 0, opts)
+	}
+	_ = file.Close()
+
+	pos, err := resumeAt(rotated, c.Offset)
+	if err != nil {
+		_ = rotated.Close()
+		return nil, err
+	}
+	f, err := follow(filename, rotated, pos, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.reopenFile(false); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// resumeAt seeks to offset in file, or to its start if the file is too
+// small to contain it, returning the resulting position.
+func resumeAt(file File, offset int64) (int64, error) {
+	fi, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if fi.Size() < offset {
+		offset = 0
+	}
+	return file.Seek(offset, os.SEEK_SET)
+}
+
+// sameFile tells if c points in file.
+func sameFile(c Cursor, file File) bool {
+	id, err := identify(file)
+	if err != nil || id.Device != c.Device || id.Inode != c.Inode {
+		return false
+	}
+	return sameFingerprint(c, file)
+}
+
+// findRotated looks for the file c points in among the siblings of
+// filename that look like rotated versions of it, returning nil if there
+// is none.
+func findRotated(fsys fs.FS, filename string, c Cursor) File {
+	dir, base, join := filepath.Dir(filename), filepath.Base(filename), filepath.Join
+	if _, ok := fsys.(osFS); !ok {
+		// names in an fs.FS are slash separated
+		dir, base, join = path.Dir(filename), path.Base(filename), path.Join
+	}
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.Name() == base || !strings.HasPrefix(entry.Name(), base) || !entry.Type().IsRegular() {
+			continue
+		}
+		file, err := openFile(fsys, join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		if sameFile(c, file) {
+			return file
+		}
+		_ = file.Close()
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestCanResumeFromCursor(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		if _, err := io.ReadAtLeast(follow, make([]byte, 7), 7); err != nil {
+			return err
+		}
+		cursor := follow.Cursor()
+		if err := follow.Close(); err != nil {
+			return err
+		}
+
+		follow, err = tailf.Resume(filename, cursor)
+		if err != nil {
+			return fmt.Errorf("failed resuming tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		want := "world!"
+		data := make([]byte, len(want))
+		if _, err := io.ReadAtLeast(follow, data, len(want)); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
+
+func TestCanResumeAfterRotation(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello,"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		if _, err := io.ReadAtLeast(follow, make([]byte, 3), 3); err != nil {
+			return err
+		}
+		cursor := follow.Cursor()
+		if err := follow.Close(); err != nil {
+			return err
+		}
+
+		// rotate while nobody is following
+		if err := os.Rename(filename, filename+".1"); err != nil {
+			return err
+		}
+		file, err = os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := file.WriteString(" world!"); err != nil {
+			return err
+		}
+
+		follow, err = tailf.Resume(filename, cursor)
+		if err != nil {
+			return fmt.Errorf("failed resuming tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		want := "lo, world!"
+		data := make([]byte, len(want))
+		if _, err := io.ReadAtLeast(follow, data, len(want)); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
+
+func TestResumeDetectsInodeReuse(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		if _, err := io.ReadAtLeast(follow, make([]byte, 7), 7); err != nil {
+			return err
+		}
+		cursor := follow.Cursor()
+		if err := follow.Close(); err != nil {
+			return err
+		}
+
+		// same inode, but another file as far as the content goes
+		if _, err := file.WriteAt([]byte("HELLO, WORLD!"), 0); err != nil {
+			return err
+		}
+
+		follow, err = tailf.Resume(filename, cursor)
+		if err != nil {
+			return fmt.Errorf("failed resuming tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		want := "HELLO, WORLD!"
+		data := make([]byte, len(want))
+		if _, err := io.ReadAtLeast(follow, data, len(want)); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
+
+func TestCanResumeFromFileCursorAfterRotation(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!"); err != nil {
+			return err
+		}
+		cursor, err := tailf.FileCursor(file, 7)
+		if err != nil {
+			return err
+		}
+
+		// rotated once the cursor was taken
+		if err := os.Rename(filename, filename+".1"); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filename, []byte(" bonjour!"), 0644); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Resume(filename, cursor)
+		if err != nil {
+			return fmt.Errorf("failed resuming tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		want := "world! bonjour!"
+		data := make([]byte, len(want))
+		if _, err := io.ReadFull(follow, data); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,137 @@
+package tailf
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"syscall"
+	"time"
+)
+
+// Backoff is the policy of retrying operations that failed, such as
+// reads failing for transient reasons, or reopening a file once its
+// filesystem is back with WithRemount. The delay between tries starts at
+// Initial, and doubles after each try up to Max.
+type Backoff struct {
+	// Initial is the delay before the first retry, 100ms by default.
+	Initial time.Duration
+	// Max is the longest delay between tries, 10s by default.
+	Max time.Duration
+	// Jitter is the fraction of each delay, between 0 and 1, by which it
+	// is randomly shortened, so that the retries of many clients spread.
+	Jitter float64
+	// MaxAttempts is how many times the operation is tried before giving
+	// up, or 0 to try until it succeeds.
+	MaxAttempts int
+}
+
+const (
+	defaultBackoffInitial = 100 * time.Millisecond
+	defaultBackoffMax     = 10 * time.Second
+)
+
+// WithRetry sets the policy of the operations the follower retries, the
+// default Backoff if not set.
+func WithRetry(b Backoff) Option {
+	return func(o *options) {
+		o.retry = b
+	}
+}
+
+// Retry calls try until it returns nil, the policy gives up, or ctx is
+// done. It returns the last error of try.
+func (b Backoff) Retry(ctx context.Context, try func() error) error {
+	return b.retry(ctx, systemClock{}, try)
+}
+
+// retry is Retry, waiting between tries on clock.
+func (b Backoff) retry(ctx context.Context, clock Clock, try func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := try()
+		if err == nil || (b.MaxAttempts > 0 && attempt+1 >= b.MaxAttempts) {
+			return err
+		}
+		select {
+		case <-clock.After(b.Delay(attempt)):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// Delay returns how long to wait after the try numbered attempt, counting
+// from 0, failed.
+func (b Backoff) Delay(attempt int) time.Duration {
+	initial, max := b.Initial, b.Max
+	if initial <= 0 {
+		initial = defaultBackoffInitial
+	}
+	if max <= 0 {
+		max = imaxDuration(defaultBackoffMax, initial)
+	}
+	d := initial
+	for i := 0; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	if b.Jitter > 0 {
+		d -= time.Duration(b.Jitter * rand.Float64() * float64(d))
+	}
+	return d
+}
+
+// readAttempts is how many times a read failing for transient reasons is
+// tried, unless the retry policy sets a limit.
+const readAttempts = 5
+
+// retryRead tells if the read that failed with err is tried again, as
+// set by WithRetry, in which case the reader is woken up to try it once
+// the delay is over. Only failures that are likely to go away by
+// themselves are retried, until they persist past the retries. The
+// follower must be locked.
+func (f *Follower) retryRead(err error) bool {
+	if !transientReadError(err) {
+		return false
+	}
+	retry := f.opts.retry
+	if retry.MaxAttempts == 0 {
+		retry.MaxAttempts = readAttempts
+	}
+	if f.readFailures+1 >= retry.MaxAttempts {
+		f.readFailures = 0
+		return false
+	}
+	delay := retry.Delay(f.readFailures)
+	f.readFailures++
+	f.opts.logger.Debug("read failed, retrying", "path", f.filename, "err", err, "delay", delay)
+	f.background(func() {
+		select {
+		case <-f.clock().After(delay):
+			f.notify()
+		case <-f.stopped:
+		}
+	})
+	return true
+}
+
+// transientReadError tells if reads failing with err are likely to
+// succeed when tried again: interrupted reads, the blips of network
+// filesystems, and the timeouts of remote filesystems.
+func transientReadError(err error) bool {
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return true
+	}
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	for _, transient := range transientReadErrnos {
+		if errno == transient {
+			return true
+		}
+	}
+	return false
+}
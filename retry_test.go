@@ -0,0 +1,97 @@
+package tailf_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := tailf.Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond}
+	for attempt, want := range []time.Duration{1, 2, 4, 5, 5} {
+		if got := b.Delay(attempt); got != want*time.Millisecond {
+			t.Errorf("wanted a delay of %dms after try %d, got %v", want, attempt, got)
+		}
+	}
+
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := b.Delay(10); got < 2500*time.Microsecond || got > 5*time.Millisecond {
+			t.Fatalf("wanted a delay between 2.5ms and 5ms, got %v", got)
+		}
+	}
+}
+
+func TestBackoffRetry(t *testing.T) {
+	b := tailf.Backoff{Initial: time.Millisecond, MaxAttempts: 3}
+	failed := errors.New("failed")
+
+	var tries int
+	err := b.Retry(context.Background(), func() error {
+		tries++
+		return failed
+	})
+	if err != failed || tries != 3 {
+		t.Errorf("wanted to give up after 3 tries, got %d tries and %v", tries, err)
+	}
+
+	tries = 0
+	err = b.Retry(context.Background(), func() error {
+		if tries++; tries < 2 {
+			return failed
+		}
+		return nil
+	})
+	if err != nil || tries != 2 {
+		t.Errorf("wanted to succeed on the second try, got %d tries and %v", tries, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tries = 0
+	err = tailf.Backoff{Initial: time.Hour}.Retry(ctx, func() error {
+		tries++
+		return failed
+	})
+	if err != failed || tries != 1 {
+		t.Errorf("wanted to stop once the context is done, got %d tries and %v", tries, err)
+	}
+}
+
+func TestRetryTransientReadErrors(t *testing.T) {
+	fsys := newMemFS()
+	fsys.write("a.log", "hello,", false)
+
+	retry := tailf.WithRetry(tailf.Backoff{Initial: time.Millisecond, MaxAttempts: 3})
+	follow, err := tailf.Follow("a.log", true, tailf.WithFS(fsys), tailf.WithPolling(time.Millisecond, time.Millisecond), retry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+	if _, err := io.ReadFull(follow, make([]byte, 6)); err != nil {
+		t.Fatal(err)
+	}
+
+	// blips are retried
+	fsys.mu.Lock()
+	fsys.timeouts = 2
+	fsys.mu.Unlock()
+	fsys.write("a.log", " world!", false)
+	got := make([]byte, len(" world!"))
+	if _, err := io.ReadFull(follow, got); err != nil || string(got) != " world!" {
+		t.Fatalf("wanted the read to be retried, got %q and %v", got, err)
+	}
+
+	// while persistent failures are reported
+	fsys.mu.Lock()
+	fsys.timeouts = 100
+	fsys.mu.Unlock()
+	fsys.write("a.log", " bonjour!", false)
+	if _, err := io.ReadFull(follow, got); err == nil {
+		t.Errorf("wanted the failure to be reported once it persisted")
+	}
+}
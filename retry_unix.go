@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package tailf
+
+import "syscall"
+
+// transientReadErrnos are the errors of reads that are likely to succeed
+// when tried again. I/O errors of network filesystems are often blips,
+// and are reported as the loss of the filesystem only if they persist.
+var transientReadErrnos = []syscall.Errno{syscall.EINTR, syscall.EAGAIN, syscall.EIO, syscall.ETIMEDOUT}
@@ -0,0 +1,13 @@
+package tailf
+
+import "syscall"
+
+// transientReadErrnos are the errors of reads that are likely to succeed
+// when tried again. Network errors are often blips, and are reported as
+// the loss of the filesystem only if they persist.
+var transientReadErrnos = []syscall.Errno{
+	33,  // ERROR_LOCK_VIOLATION, while a writer locks the range
+	54,  // ERROR_NETWORK_BUSY
+	59,  // ERROR_UNEXP_NET_ERR
+	121, // ERROR_SEM_TIMEOUT
+}
@@ -0,0 +1,240 @@
+package tailf
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// defaultRotationBufferMax is how much of the data left unread in a
+// rotated file is kept in memory by default.
+const defaultRotationBufferMax = 4 << 20
+
+// rotationBuffer holds the data left unread in rotated files. Up to max
+// bytes are kept in memory, the rest is spilled to a temporary file,
+// which is removed once it was read. If dropOldest is set, the oldest
+// lines are dropped instead of spilling.
+type rotationBuffer struct {
+	mem *bytes.Buffer
+	max int // negative for no limit
+
+	dropOldest                 bool
+	droppedBytes, droppedLines int64 // since last taken
+	midLine                    bool  // whether the reader is halfway through a line
+
+	spill      *os.File
+	spillDir   string
+	rOff, wOff int64 // read and write offsets in spill
+}
+
+func newRotationBuffer(opts options) *rotationBuffer {
+	max := opts.rotationBufferMax
+	if max == 0 {
+		max = defaultRotationBufferMax
+	}
+	return &rotationBuffer{
+		mem:        getBuffer(opts.rotationBufferMin),
+		max:        max,
+		spillDir:   opts.spillDir,
+		dropOldest: opts.dropOldest,
+	}
+}
+
+// Len returns how many bytes are left to read.
+func (b *rotationBuffer) Len() int {
+	return b.mem.Len() + int(b.wOff-b.rOff)
+}
+
+// Write appends p, spilling it to disk or dropping the oldest lines once
+// the memory is full.
+func (b *rotationBuffer) Write(p []byte) (int, error) {
+	if b.dropOldest && b.max >= 0 {
+		b.writeDropping(p)
+		return len(p), nil
+	}
+	if b.spill == nil {
+		room := len(p)
+		if b.max >= 0 && b.max-b.mem.Len() < room {
+			room = imax(b.max-b.mem.Len(), 0)
+		}
+		b.mem.Write(p[:room])
+		p = p[room:]
+		if len(p) == 0 {
+			return room, nil
+		}
+		if err := b.openSpill(); err != nil {
+			return room, err
+		}
+		n, err := b.writeSpill(p)
+		return room + n, err
+	}
+	return b.writeSpill(p)
+}
+
+// writeDropping appends p, dropping the oldest lines until it fits, and
+// the start of p itself if need be. Whole lines are dropped, so that
+// reading resumes at the start of a line, and the rest of the line the
+// reader is halfway through is kept, so that its start isn't followed by
+// the end of another line.
+func (b *rotationBuffer) writeDropping(p []byte) {
+	excess := b.mem.Len() + len(p) - b.max
+	if excess <= 0 {
+		b.mem.Write(p)
+		return
+	}
+
+	mem := b.mem.Bytes()
+	keep := 0
+	if b.midLine {
+		keep = lineCut(mem, 1)
+	}
+	if lines := mem[keep:]; len(lines) != 0 {
+		c := lineCut(lines, imin(excess, len(lines)))
+		b.countDropped(lines[:c])
+		if c < len(lines) {
+			// what is kept moves up to the lines left
+			copy(mem[c:], mem[:keep])
+			b.mem.Next(c)
+			b.mem.Write(p)
+			return
+		}
+		b.mem.Truncate(keep)
+		excess -= c
+		if lines[c-1] != '\n' {
+			// p starts in the middle of a dropped line
+			excess = imax(excess, 1)
+		}
+	} else if b.midLine {
+		// p starts with the rest of the line of the reader
+		rest := lineCut(p, 1)
+		b.mem.Write(p[:rest])
+		p = p[rest:]
+	}
+
+	c := lineCut(p, imin(imax(excess, 0), len(p)))
+	b.countDropped(p[:c])
+	b.mem.Write(p[c:])
+}
+
+// delivered records that data was handed to the reader, to know whether
+// it is halfway through a line.
+func (b *rotationBuffer) delivered(data []byte) {
+	if len(data) != 0 {
+		b.midLine = data[len(data)-1] != '\n'
+	}
+}
+
+// lineCut returns the smallest c >= n at which a line of data starts, or
+// len(data) if there is none. data is assumed to start with a line.
+func lineCut(data []byte, n int) int {
+	if n == 0 {
+		return 0
+	}
+	if j := bytes.IndexByte(data[n-1:], '\n'); j >= 0 {
+		return n + j
+	}
+	return len(data)
+}
+
+func (b *rotationBuffer) countDropped(data []byte) {
+	b.droppedBytes += int64(len(data))
+	b.droppedLines += int64(bytes.Count(data, []byte{'\n'}))
+}
+
+// takeDropped returns how many bytes and lines were dropped since it was
+// last called.
+func (b *rotationBuffer) takeDropped() (int64, int64) {
+	n, lines := b.droppedBytes, b.droppedLines
+	b.droppedBytes, b.droppedLines = 0, 0
+	return n, lines
+}
+
+// ReadFrom appends everything read from r until io.EOF.
+func (b *rotationBuffer) ReadFrom(r io.Reader) (int64, error) {
+	var n int64
+	chunk := make([]byte, 32*1024)
+	for {
+		m, err := r.Read(chunk)
+		if m != 0 {
+			if _, werr := b.Write(chunk[:m]); werr != nil {
+				return n, werr
+			}
+			n += int64(m)
+		}
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+func (b *rotationBuffer) Read(p []byte) (int, error) {
+	if b.mem.Len() != 0 {
+		return b.mem.Read(p)
+	}
+	if b.spill == nil {
+		return 0, io.EOF
+	}
+	if left := b.wOff - b.rOff; int64(len(p)) > left {
+		p = p[:left]
+	}
+	n, err := b.spill.ReadAt(p, b.rOff)
+	b.rOff += int64(n)
+	if b.rOff == b.wOff {
+		// everything spilled was read, go back to memory
+		b.closeSpill()
+		return n, nil
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// reset discards what's left in the buffer.
+func (b *rotationBuffer) reset() {
+	b.closeSpill()
+	b.mem.Reset()
+}
+
+// close discards what's left in the buffer, which can't be used anymore.
+func (b *rotationBuffer) close() {
+	b.closeSpill()
+	putBuffer(b.mem)
+	b.mem = nil
+}
+
+func (b *rotationBuffer) openSpill() error {
+	file, err := ioutil.TempFile(b.spillDir, "tailf-rotation-")
+	if err != nil {
+		return err
+	}
+	b.spill, b.rOff, b.wOff = file, 0, 0
+	return nil
+}
+
+func (b *rotationBuffer) writeSpill(p []byte) (int, error) {
+	n, err := b.spill.WriteAt(p, b.wOff)
+	b.wOff += int64(n)
+	return n, err
+}
+
+func (b *rotationBuffer) closeSpill() {
+	if b.spill == nil {
+		return
+	}
+	_ = b.spill.Close()
+	_ = os.Remove(b.spill.Name())
+	b.spill, b.rOff, b.wOff = nil, 0, 0
+}
+
+// Dropped returns how many bytes and lines were dropped so far, as
+// configured with WithDropOldest.
+func (f *Follower) Dropped() (n, lines int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.droppedBytes, f.droppedLines
+}
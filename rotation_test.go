@@ -0,0 +1,191 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	"github.com/aybabtme/tailf/tailftest"
+)
+
+func TestRotationBufferSpillsToDisk(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		spillDir, err := ioutil.TempDir("", "tailf_spill")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(spillDir)
+
+		backlog := strings.Repeat("hello, world!\n", 10000)
+		if _, err := file.WriteString(backlog); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true, tailf.WithRotationBuffer(0, 1024), tailf.WithSpillDir(spillDir))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+		file, err = os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := file.WriteString("goodbye\n"); err != nil {
+			return err
+		}
+		// let the follower notice the rotation before reading
+		time.Sleep(100 * time.Millisecond)
+
+		want := backlog + "goodbye\n"
+		data := make([]byte, len(want))
+		if _, err := io.ReadFull(follow, data); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("data read differs from data written")
+		}
+
+		spilled, err := ioutil.ReadDir(spillDir)
+		if err != nil {
+			return err
+		}
+		if len(spilled) != 0 {
+			t.Errorf("wanted spilled data to be removed once read, found %d files", len(spilled))
+		}
+		return nil
+	})
+}
+
+func TestDropOldest(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		var lines []string
+		for i := 0; i < 1000; i++ {
+			lines = append(lines, fmt.Sprintf("line %d\n", i))
+		}
+		backlog := strings.Join(lines, "")
+		if _, err := file.WriteString(backlog); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true, tailf.WithRotationBuffer(0, 100), tailf.WithDropOldest())
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+		file, err = os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		// let the follower notice the rotation before reading
+		time.Sleep(100 * time.Millisecond)
+
+		ev := <-follow.Events()
+		dropped, ok := ev.(tailf.Dropped)
+		if !ok {
+			return fmt.Errorf("wanted a Dropped event, got %#v", ev)
+		}
+		if n, lines := follow.Dropped(); n != dropped.Bytes || lines != dropped.Lines {
+			t.Errorf("wanted %d bytes and %d lines dropped, got %d and %d", dropped.Bytes, dropped.Lines, n, lines)
+		}
+
+		want := strings.Join(lines[dropped.Lines:], "")
+		if len(want) > 100 || dropped.Bytes+int64(len(want)) != int64(len(backlog)) {
+			t.Errorf("wanted at most 100 bytes kept out of %d, %d were dropped", len(backlog), dropped.Bytes)
+		}
+		if _, err := file.WriteString("goodbye\n"); err != nil {
+			return err
+		}
+		want += "goodbye\n"
+		data := make([]byte, len(want))
+		if _, err := io.ReadFull(follow, data); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
+
+func TestDropOldestMidLine(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		for i := 0; i < 1000; i++ {
+			fmt.Fprintf(file, "line %d\n", i)
+		}
+
+		follow, err := tailf.Follow(filename, true, tailf.WithRotationBuffer(0, 100), tailf.WithDropOldest())
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+		start := make([]byte, 3)
+		if _, err := io.ReadFull(follow, start); err != nil {
+			return err
+		}
+
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+		file, err = os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		time.Sleep(100 * time.Millisecond)
+		if _, err := file.WriteString("goodbye\n"); err != nil {
+			return err
+		}
+
+		// the line being read is read to its end, and whole lines after it
+		read := string(start)
+		for !strings.HasSuffix(read, "goodbye\n") {
+			line, err := follow.ReadString('\n')
+			if err != nil {
+				return err
+			}
+			read += line
+		}
+		lines := strings.Split(strings.TrimSuffix(read, "\n"), "\n")
+		if lines[0] != "line 0" {
+			t.Errorf("wanted the line being read kept, got %q", lines[0])
+		}
+		for _, line := range lines[1 : len(lines)-1] {
+			var n int
+			if _, err := fmt.Sscanf(line, "line %d", &n); err != nil || line != fmt.Sprintf("line %d", n) {
+				t.Errorf("wanted whole lines, got %q", line)
+			}
+		}
+		return nil
+	})
+}
+
+func TestRotationScripts(t *testing.T) {
+	fsys := tailftest.NewFS()
+	for name, script := range map[string]string{
+		// the rest of the rotated file is read before the new one
+		"logrotate": `append "hello,"; rotate; append " world!"; expect "hello, world!"; expect rotated`,
+		// replaced once it grew from empty, as fingerprinted when opened
+		"from empty":   `append 3B; expect "aaa"; remove; append 2B; expect "bb"; expect rotated`,
+		"copytruncate": `append 10B; expect "aaaaaaaaaa"; truncate; append 2B; expect "bb"; expect truncated`,
+		"restart":      `append 3B; expect "aaa"; restart; append 2B; expect "bb"`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			tailftest.RunScript(t, fsys, script)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestFollowRetriesSharingViolations(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!"); err != nil {
+			return err
+		}
+		file.Close()
+
+		// held exclusively, as some writers do while they rotate files
+		path, err := syscall.UTF16PtrFromString(filename)
+		if err != nil {
+			return err
+		}
+		h, err := syscall.CreateFile(path, syscall.GENERIC_READ, 0, nil, syscall.OPEN_EXISTING, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+		if err != nil {
+			return err
+		}
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			syscall.CloseHandle(h)
+		}()
+
+		follow, err := tailf.Follow(filename, true, tailf.WithRetry(tailf.Backoff{Initial: 10 * time.Millisecond}))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		got := make([]byte, len("hello, world!"))
+		if _, err := io.ReadFull(follow, got); err != nil {
+			return err
+		}
+		if string(got) != "hello, world!" {
+			t.Errorf("wanted the file once it was released, got %q", got)
+		}
+		return nil
+	})
+}
+
+func TestRotateWhileFollowed(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello,"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		// the followed file can be renamed and deleted while it's open
+		file.Close()
+		if err := os.Rename(filename, filename+".1"); err != nil {
+			return err
+		}
+		file, err = os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := file.WriteString(" world!"); err != nil {
+			return err
+		}
+		if err := os.Remove(filename + ".1"); err != nil {
+			return err
+		}
+
+		got := make([]byte, len("hello, world!"))
+		if _, err := io.ReadFull(follow, got); err != nil {
+			return err
+		}
+		if string(got) != "hello, world!" {
+			t.Errorf("wanted both files, got %q", got)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,217 @@
+// Package tailfs3 follows S3 objects, so that tailf can tail the logs
+// shipped to an object store like it tails local files:
+//
+//	follow, err := tailf.Follow("logs/app.log", false, tailfs3.WithClient(s3.NewFromConfig(cfg), "my-bucket"))
+//
+// Objects are polled with HEAD requests, as configured with
+// tailf.WithPolling, and only the new byte ranges of an object that grew
+// are fetched. A key ending with a slash names a prefix instead, under
+// which the object with the greatest key is followed, and followed until
+// its end once an object with a greater key appears, as when logs are
+// rotated to objects named after their date.
+//
+// A changed ETag means the object may have been replaced by another. As
+// objects are uploaded anew whenever they grow, it is only treated as a
+// rotation if the start of the object changed as well.
+package tailfs3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aybabtme/tailf"
+)
+
+// API is the part of the S3 API used to follow objects, as implemented by
+// *s3.Client.
+type API interface {
+	HeadObject(ctx context.Context, in *s3.HeadObjectInput, opts ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	GetObject(ctx context.Context, in *s3.GetObjectInput, opts ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	ListObjectsV2(ctx context.Context, in *s3.ListObjectsV2Input, opts ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+}
+
+// WithClient follows objects of bucket with api, by their key given in
+// place of file names.
+func WithClient(api API, bucket string) tailf.Option {
+	return tailf.WithFS(s3FS{api: api, bucket: bucket})
+}
+
+// s3FS is an fs.FS opening the objects of a bucket.
+type s3FS struct {
+	api    API
+	bucket string
+}
+
+// statusCode returns the HTTP status of the response that failed with
+// err, or 0 if there is none.
+func statusCode(err error) int {
+	var resp interface{ HTTPStatusCode() int }
+	if errors.As(err, &resp) {
+		return resp.HTTPStatusCode()
+	}
+	return 0
+}
+
+func (s s3FS) Open(name string) (fs.File, error) {
+	fi, err := s.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return &object{fs: s, key: fi.(objectInfo).key}, nil
+}
+
+// Stat returns the info of the object called name, or of the latest
+// object under name if it is a prefix.
+func (s s3FS) Stat(name string) (fs.FileInfo, error) {
+	if strings.HasSuffix(name, "/") {
+		return s.latest(name)
+	}
+	return s.head(name)
+}
+
+func (s s3FS) head(key string) (fs.FileInfo, error) {
+	out, err := s.api.HeadObject(context.Background(), &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if statusCode(err) == http.StatusNotFound {
+		return nil, &fs.PathError{Op: "stat", Path: key, Err: fs.ErrNotExist}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return objectInfo{
+		key:     key,
+		size:    aws.ToInt64(out.ContentLength),
+		etag:    aws.ToString(out.ETag),
+		modTime: aws.ToTime(out.LastModified),
+	}, nil
+}
+
+// latest returns the info of the object with the greatest key under
+// prefix.
+func (s s3FS) latest(prefix string) (fs.FileInfo, error) {
+	var last *objectInfo
+	in := &s3.ListObjectsV2Input{Bucket: aws.String(s.bucket), Prefix: aws.String(prefix)}
+	for {
+		out, err := s.api.ListObjectsV2(context.Background(), in)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range out.Contents {
+			key := aws.ToString(obj.Key)
+			if strings.HasSuffix(key, "/") || (last != nil && key <= last.key) {
+				continue
+			}
+			last = &objectInfo{
+				key:     key,
+				size:    aws.ToInt64(obj.Size),
+				etag:    aws.ToString(obj.ETag),
+				modTime: aws.ToTime(obj.LastModified),
+			}
+		}
+		if !aws.ToBool(out.IsTruncated) {
+			break
+		}
+		in.ContinuationToken = out.NextContinuationToken
+	}
+	if last == nil {
+		return nil, &fs.PathError{Op: "stat", Path: prefix, Err: fs.ErrNotExist}
+	}
+	return *last, nil
+}
+
+// SameFile tells if fi1 and fi2 are the same object because they have
+// the same key and ETag.
+func (s s3FS) SameFile(fi1, fi2 fs.FileInfo) bool {
+	a, ok1 := fi1.(objectInfo)
+	b, ok2 := fi2.(objectInfo)
+	return ok1 && ok2 && a.etag != "" && a.key == b.key && a.etag == b.etag
+}
+
+type objectInfo struct {
+	key     string
+	size    int64
+	etag    string
+	modTime time.Time
+}
+
+func (fi objectInfo) Name() string       { return fi.key }
+func (fi objectInfo) Size() int64        { return fi.size }
+func (fi objectInfo) Mode() fs.FileMode  { return 0444 }
+func (fi objectInfo) ModTime() time.Time { return fi.modTime }
+func (fi objectInfo) IsDir() bool        { return false }
+func (fi objectInfo) Sys() interface{}   { return nil }
+
+// object reads an object with range requests.
+type object struct {
+	fs  s3FS
+	key string
+	pos int64
+}
+
+func (o *object) Stat() (fs.FileInfo, error) {
+	return o.fs.head(o.key)
+}
+
+func (o *object) Read(b []byte) (int, error) {
+	n, err := o.ReadAt(b, o.pos)
+	o.pos += int64(n)
+	if n != 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// ReadAt fetches len(b) bytes from off with a range request.
+func (o *object) ReadAt(b []byte, off int64) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	out, err := o.fs.api.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(o.fs.bucket),
+		Key:    aws.String(o.key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", off, off+int64(len(b))-1)),
+	})
+	if statusCode(err) == http.StatusRequestedRangeNotSatisfiable {
+		// nothing was written past off yet
+		return 0, io.EOF
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer out.Body.Close()
+	n, err := io.ReadFull(out.Body, b)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (o *object) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += o.pos
+	case io.SeekEnd:
+		fi, err := o.Stat()
+		if err != nil {
+			return 0, err
+		}
+		offset += fi.Size()
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("%s: negative position", o.key)
+	}
+	o.pos = offset
+	return offset, nil
+}
+
+func (o *object) Close() error { return nil }
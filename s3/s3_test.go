@@ -0,0 +1,125 @@
+package tailfs3_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aybabtme/tailf"
+	tailfs3 "github.com/aybabtme/tailf/s3"
+)
+
+type statusError int
+
+func (e statusError) Error() string       { return http.StatusText(int(e)) }
+func (e statusError) HTTPStatusCode() int { return int(e) }
+
+// bucket is an in-memory tailfs3.API.
+type bucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	uploads int
+	etags   map[string]string
+}
+
+func newBucket() *bucket {
+	return &bucket{objects: make(map[string][]byte), etags: make(map[string]string)}
+}
+
+func (b *bucket) put(key, data string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.uploads++
+	b.objects[key] = []byte(data)
+	b.etags[key] = fmt.Sprintf(`"%d"`, b.uploads)
+}
+
+func (b *bucket) HeadObject(ctx context.Context, in *s3.HeadObjectInput, opts ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data, ok := b.objects[*in.Key]
+	if !ok {
+		return nil, statusError(http.StatusNotFound)
+	}
+	return &s3.HeadObjectOutput{ContentLength: aws.Int64(int64(len(data))), ETag: aws.String(b.etags[*in.Key])}, nil
+}
+
+func (b *bucket) GetObject(ctx context.Context, in *s3.GetObjectInput, opts ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data, ok := b.objects[*in.Key]
+	if !ok {
+		return nil, statusError(http.StatusNotFound)
+	}
+	var from, to int
+	fmt.Sscanf(*in.Range, "bytes=%d-%d", &from, &to)
+	if from >= len(data) {
+		return nil, statusError(http.StatusRequestedRangeNotSatisfiable)
+	}
+	if to >= len(data) {
+		to = len(data) - 1
+	}
+	return &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewReader(data[from : to+1]))}, nil
+}
+
+func (b *bucket) ListObjectsV2(ctx context.Context, in *s3.ListObjectsV2Input, opts ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var keys []string
+	for key := range b.objects {
+		if len(key) >= len(*in.Prefix) && key[:len(*in.Prefix)] == *in.Prefix {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	out := &s3.ListObjectsV2Output{IsTruncated: aws.Bool(false)}
+	for _, key := range keys {
+		out.Contents = append(out.Contents, types.Object{
+			Key:  aws.String(key),
+			Size: aws.Int64(int64(len(b.objects[key]))),
+			ETag: aws.String(b.etags[key]),
+		})
+	}
+	return out, nil
+}
+
+func TestWithClient(t *testing.T) {
+	b := newBucket()
+	b.put("logs/2024-01-01.log", "hello,")
+
+	follow, err := tailf.Follow("logs/", true, tailfs3.WithClient(b, "bucket"), tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+
+	got := make([]byte, len("hello, world!bonjour!"))
+	if _, err := io.ReadFull(follow, got[:6]); err != nil {
+		t.Fatal(err)
+	}
+	// uploaded again as it grew
+	b.put("logs/2024-01-01.log", "hello, world!")
+	if _, err := io.ReadFull(follow, got[6:13]); err != nil {
+		t.Fatal(err)
+	}
+	b.put("logs/2024-01-02.log", "bonjour!")
+	if _, err := io.ReadFull(follow, got[13:]); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello, world!bonjour!" {
+		t.Errorf("wanted the objects to be followed in turn, got %q", got)
+	}
+	if c := follow.Cursor(); c.Offset != 8 {
+		t.Errorf("wanted to be at the end of the latest object, got %+v", c)
+	}
+}
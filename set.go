@@ -0,0 +1,307 @@
+package tailf
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrSetClosed is returned when adding a file to a FollowerSet that was
+// closed.
+var ErrSetClosed = errors.New("tailf: follower set is closed")
+
+// FollowerSet owns the followers of many files, keyed by the path they
+// were added with, so that consumers of several files don't manage them
+// by hand: files are added and removed while others are followed, their
+// activity is added up, and they are all closed together.
+type FollowerSet struct {
+	opts []Option
+
+	mu        sync.Mutex
+	followers map[string]*Follower
+	extra     map[string][]Option  // options files were added with, by path
+	since     map[string]time.Time // when followers were added or resumed
+	closed    bool
+	clock     Clock // timing discovery and eviction
+
+	events      chan LabeledEvent // nil until Events is called
+	discoveries chan LabeledEvent // nil until Discoveries is called
+	forwarding  sync.WaitGroup    // goroutines sending to either
+	reports     []LabeledEvent    // discoveries not sent yet
+	reporting   bool              // whether a goroutine sends them
+
+	discovery *Discovery           // nil until Discover is called
+	scanmu    sync.Mutex           // held while scanning for files
+	found     map[string]bool      // files found by discovery, by path
+	ignored   map[string]Cursor    // files skipped for being old, where they ended then
+	deleted   map[string]time.Time // when files found were first seen deleted
+	done      chan struct{}        // closed once the set is closed
+	loops     sync.WaitGroup       // goroutines discovering, draining, evicting and resuming
+
+	maxOpen      int                 // 0 unless SetMaxOpen is called
+	openInterval time.Duration       // how often evicted files are checked
+	openmu       sync.Mutex          // held while evicting and resuming followers
+	evicted      map[string]*evicted // files whose follower was evicted, by path
+	retired      map[string]Stats    // activity of the followers evicted, by path
+
+	backlog backlog
+}
+
+// NewFollowerSet returns an empty set, whose followers follow their files
+// with opts.
+func NewFollowerSet(opts ...Option) *FollowerSet {
+	s := &FollowerSet{
+		opts:      opts,
+		followers: make(map[string]*Follower),
+		extra:     make(map[string][]Option),
+		since:     make(map[string]time.Time),
+		done:      make(chan struct{}),
+		evicted:   make(map[string]*evicted),
+		retired:   make(map[string]Stats),
+		clock:     systemClock{},
+	}
+	s.backlog.turn = sync.NewCond(&s.backlog.mu)
+	return s
+}
+
+// SetClock times the discovery and eviction of the files of the set with
+// c instead of the system clock. The followers of the set are timed by
+// the clock their options set, with WithClock.
+func (s *FollowerSet) SetClock(c Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = c
+}
+
+// timing returns the clock timing the set.
+func (s *FollowerSet) timing() Clock {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clock
+}
+
+// Add follows filename with the options of the set, followed by opts, and
+// adds the follower to the set. It fails if filename was already added.
+func (s *FollowerSet) Add(filename string, fromStart bool, opts ...Option) (*Follower, error) {
+	f, err := s.add(filename, fromStart, nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.evict()
+	return f, nil
+}
+
+// add follows filename, from where from points if it isn't nil, and adds
+// the follower to the set.
+func (s *FollowerSet) add(filename string, fromStart bool, from *Cursor, opts []Option) (*Follower, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrSetClosed
+	}
+	if s.has(filename) {
+		return nil, fmt.Errorf("tailf: %s is already followed", filename)
+	}
+	all := append(s.opts[:len(s.opts):len(s.opts)], opts...)
+	var f *Follower
+	var err error
+	if from != nil {
+		f, err = Resume(filename, *from, all...)
+	} else {
+		f, err = Follow(filename, fromStart, all...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.followers[filename] = f
+	s.extra[filename] = opts
+	s.since[filename] = s.clock.Now()
+	if s.events != nil {
+		s.forward(filename, f)
+	}
+	return f, nil
+}
+
+// has tells if filename is in the set, whether or not its follower was
+// evicted. The set must be locked.
+func (s *FollowerSet) has(filename string) bool {
+	_, followed := s.followers[filename]
+	_, evicted := s.evicted[filename]
+	return followed || evicted
+}
+
+// forget removes filename from the set, and returns its follower, if it
+// wasn't evicted. The set must be locked.
+func (s *FollowerSet) forget(filename string) *Follower {
+	f := s.followers[filename]
+	delete(s.followers, filename)
+	delete(s.extra, filename)
+	delete(s.since, filename)
+	delete(s.evicted, filename)
+	delete(s.retired, filename)
+	return f
+}
+
+// Remove closes the follower of filename and removes it from the set,
+// once it stopped following. It does nothing if filename isn't in the
+// set.
+func (s *FollowerSet) Remove(filename string) error {
+	s.mu.Lock()
+	f := s.forget(filename)
+	s.mu.Unlock()
+	if f == nil {
+		return nil
+	}
+	return closeFollower(f)
+}
+
+// Get returns the follower of filename, or nil if it isn't in the set or
+// its follower was evicted, as set by SetMaxOpen.
+func (s *FollowerSet) Get(filename string) *Follower {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.followers[filename]
+}
+
+// List returns the paths the followers of the set were added with, in
+// order, including those of the followers that were evicted.
+func (s *FollowerSet) List() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paths := make([]string, 0, len(s.followers)+len(s.evicted))
+	for path := range s.followers {
+		paths = append(paths, path)
+	}
+	for path := range s.evicted {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Stats returns the activity of the followers of the set, added up.
+func (s *FollowerSet) Stats() Stats {
+	var stats Stats
+	for _, st := range s.FollowerStats() {
+		stats.add(st)
+	}
+	return stats
+}
+
+// FollowerStats returns the activity of each follower of the set, by the
+// path it was added with. The activity of a file includes that of the
+// followers of it that were evicted.
+func (s *FollowerSet) FollowerStats() map[string]Stats {
+	s.mu.Lock()
+	stats := make(map[string]Stats, len(s.retired))
+	for path, retired := range s.retired {
+		stats[path] = retired
+	}
+	followers := make(map[string]*Follower, len(s.followers))
+	for path, f := range s.followers {
+		followers[path] = f
+	}
+	for path, e := range s.evicted {
+		if e.closing != nil {
+			followers[path] = e.closing
+		}
+	}
+	s.mu.Unlock()
+	for path, f := range followers {
+		st := stats[path]
+		st.add(f.Stats())
+		stats[path] = st
+	}
+	return stats
+}
+
+// Events returns the channel on which the events of the followers of the
+// set are reported, along with the path and labels of their follower.
+// Once it was called, the events of the followers, including those added
+// later, are forwarded to it, and their own channels mustn't be read. It
+// must be drained until it is closed, which happens once the set was
+// closed and every event forwarded.
+func (s *FollowerSet) Events() <-chan LabeledEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.events != nil {
+		return s.events
+	}
+	s.events = make(chan LabeledEvent, eventBufferSize)
+	if s.closed {
+		close(s.events)
+		return s.events
+	}
+	for path, f := range s.followers {
+		s.forward(path, f)
+	}
+	return s.events
+}
+
+// forward forwards the events of f, added with path, to the channel of
+// the set, until f is closed, waiting for its turn while it catches up on
+// its backlog. The set must be locked.
+func (s *FollowerSet) forward(path string, f *Follower) {
+	s.backlog.join(path, f)
+	s.forwarding.Add(1)
+	go func() {
+		defer s.forwarding.Done()
+		defer s.backlog.leave(path)
+		for ev := range f.Events() {
+			if _, data := ev.(DataChunk); data {
+				s.backlog.wait(path)
+			}
+			s.events <- LabeledEvent{Event: ev, Path: path, Labels: f.Labels()}
+			s.backlog.forwarded(path, f, ev)
+		}
+	}()
+}
+
+// Close closes every follower of the set and waits for them to stop
+// following, after which files can't be added anymore. It returns the
+// first error closing them failed with, if any.
+func (s *FollowerSet) Close() error {
+	s.mu.Lock()
+	wasClosed := s.closed
+	s.closed = true
+	followers := s.followers
+	s.followers = make(map[string]*Follower)
+	s.evicted = make(map[string]*evicted)
+	if !wasClosed {
+		close(s.done)
+	}
+	s.mu.Unlock()
+
+	var first error
+	for _, f := range followers {
+		if err := closeFollower(f); err != nil && first == nil {
+			first = err
+		}
+	}
+	s.loops.Wait()
+
+	s.mu.Lock()
+	if !wasClosed && (s.events != nil || s.discoveries != nil) {
+		events, discoveries := s.events, s.discoveries
+		go func() {
+			s.forwarding.Wait()
+			if events != nil {
+				close(events)
+			}
+			if discoveries != nil {
+				close(discoveries)
+			}
+		}()
+	}
+	s.mu.Unlock()
+	return first
+}
+
+// closeFollower closes f and waits for it to stop following.
+func closeFollower(f *Follower) error {
+	err := f.Close()
+	f.WaitClosed()
+	return err
+}
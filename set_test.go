@@ -0,0 +1,280 @@
+package tailf_test
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestFollowerSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	for _, name := range []string{a, b} {
+		if err := ioutil.WriteFile(name, []byte("hello\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	set := tailf.NewFollowerSet()
+	for _, name := range []string{b, a} {
+		follow, err := set.Add(name, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.ReadFull(follow, make([]byte, 6)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := set.Add(a, true); err == nil {
+		t.Errorf("wanted an error adding %s again", a)
+	}
+	if got, want := set.List(), []string{a, b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+	if s := set.Stats(); s.Bytes != 12 || s.Lines != 2 {
+		t.Errorf("wanted 12 bytes and 2 lines read, got %d and %d", s.Bytes, s.Lines)
+	}
+
+	// a removed file stops being followed
+	removed := set.Get(a)
+	if err := set.Remove(a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := removed.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("wanted io.EOF reading a removed follower, got %v", err)
+	}
+	if got, want := set.List(), []string{b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+
+	left := set.Get(b)
+	if err := set.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := left.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("wanted io.EOF reading a closed set, got %v", err)
+	}
+	if _, err := set.Add(a, true); err != tailf.ErrSetClosed {
+		t.Errorf("wanted %v, got %v", tailf.ErrSetClosed, err)
+	}
+}
+
+func TestFollowerSetLabels(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	api, db := filepath.Join(dir, "api.log"), filepath.Join(dir, "db.log")
+	for _, name := range []string{api, db} {
+		if err := ioutil.WriteFile(name, []byte("hello\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	set := tailf.NewFollowerSet(tailf.WithEventStream(), tailf.WithLabels(tailf.Labels{"env": "prod"}))
+	events := set.Events()
+	for name, service := range map[string]string{api: "api", db: "db"} {
+		if _, err := set.Add(name, true, tailf.WithLabels(tailf.Labels{"service": service})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the data of each file comes with its labels
+	for seen := map[string]bool{}; len(seen) != 2; {
+		ev := <-events
+		chunk, ok := ev.Event.(tailf.DataChunk)
+		if !ok {
+			continue
+		}
+		want := tailf.Labels{"env": "prod", "service": strings.TrimSuffix(filepath.Base(ev.Path), ".log")}
+		if string(chunk.Bytes) != "hello\n" || !reflect.DeepEqual(ev.Labels, want) {
+			t.Errorf("wanted %q labeled %v, got %q labeled %v", "hello\n", want, chunk.Bytes, ev.Labels)
+		}
+		seen[ev.Path] = true
+	}
+
+	go set.Close()
+	for range events {
+	}
+}
+
+func TestFollowerSetMaxOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	for _, name := range []string{a, b} {
+		if err := ioutil.WriteFile(name, []byte("hello\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	set := tailf.NewFollowerSet()
+	defer set.Close()
+	set.SetMaxOpen(1, 10*time.Millisecond)
+	follow, err := set.Add(a, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(follow, make([]byte, 6)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a was read to the end, and gets evicted for b
+	if _, err := set.Add(b, true); err != nil {
+		t.Fatal(err)
+	}
+	if set.Get(a) != nil {
+		t.Errorf("wanted the follower of %s evicted", a)
+	}
+	if got, want := set.List(), []string{a, b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+	if _, err := io.ReadFull(set.Get(b), make([]byte, 6)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a is resumed where it was once written to, and b evicted for it
+	file, err := os.OpenFile(a, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString("world\n"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for set.Get(a) == nil || set.Get(b) != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("wanted %s resumed and %s evicted", a, b)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	line := make([]byte, 6)
+	if _, err := io.ReadFull(set.Get(a), line); err != nil {
+		t.Fatal(err)
+	}
+	if string(line) != "world\n" {
+		t.Errorf("wanted %q, got %q", "world\n", line)
+	}
+	if s := set.Stats(); s.Bytes != 18 || s.Lines != 3 {
+		t.Errorf("wanted 18 bytes and 3 lines read, got %d and %d", s.Bytes, s.Lines)
+	}
+}
+
+func TestFollowerSetBacklogOrder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sizes := map[string]int{"small.log": 1000, "large.log": 5000, "medium.log": 3000}
+	for name, lines := range sizes {
+		data := strings.Repeat(name+"\n", lines)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	set := tailf.NewFollowerSet(tailf.WithEventStream())
+	set.SetBacklogOrder(tailf.BacklogLargestFirst)
+	for name := range sizes {
+		if _, err := set.Add(filepath.Join(dir, name), true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the backlogs are forwarded one after the other, the largest first
+	var order []string
+	read := map[string]int{}
+	for events := set.Events(); len(read) != len(sizes) || read["small.log"] != sizes["small.log"]*len("small.log\n"); {
+		ev := <-events
+		chunk, ok := ev.Event.(tailf.DataChunk)
+		if !ok {
+			continue
+		}
+		name := filepath.Base(ev.Path)
+		if len(order) == 0 || order[len(order)-1] != name {
+			order = append(order, name)
+		}
+		read[name] += len(chunk.Bytes)
+	}
+	if want := []string{"large.log", "medium.log", "small.log"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("wanted the backlogs forwarded in order %v, got %v", want, order)
+	}
+
+	go set.Close()
+	for range set.Events() {
+	}
+}
+
+func TestFollowerSetBacklogBounded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	busy, quiet := filepath.Join(dir, "busy.log"), filepath.Join(dir, "quiet.log")
+	backlog := strings.Repeat("busy\n", 800000)
+	if err := ioutil.WriteFile(busy, []byte(backlog), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(quiet, []byte("quiet\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	set := tailf.NewFollowerSet(tailf.WithEventStream())
+	set.SetBacklogOrder(tailf.BacklogLargestFirst)
+	for _, path := range []string{busy, quiet} {
+		if _, err := set.Add(path, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	events := set.Events()
+
+	// what is written to the busy file once its turn started waits for
+	// the quiet file
+	file, err := os.OpenFile(busy, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(backlog); err != nil {
+		t.Fatal(err)
+	}
+
+	var forwarded int
+	for ev := range events {
+		chunk, ok := ev.Event.(tailf.DataChunk)
+		if !ok {
+			continue
+		}
+		if ev.Path == quiet {
+			break
+		}
+		forwarded += len(chunk.Bytes)
+		// consumed slower than read, so that the files take turns
+		time.Sleep(100 * time.Microsecond)
+	}
+	if forwarded >= 2*len(backlog) {
+		t.Errorf("wanted the quiet file forwarded once the backlog of the busy one was, got %d bytes of it first", forwarded)
+	}
+
+	go set.Close()
+	for range set.Events() {
+	}
+}
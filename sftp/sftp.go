@@ -0,0 +1,52 @@
+// Package tailfsftp follows files on remote hosts over SFTP, without
+// installing anything on them, which comes in handy to debug a fleet:
+//
+//	conn, err := ssh.Dial("tcp", "web-1:22", config)
+//	...
+//	client, err := sftp.NewClient(conn)
+//	...
+//	follow, err := tailf.Follow("/var/log/nginx/access.log", false, tailfsftp.WithClient(client))
+//
+// Growth and rotations are detected by polling the file, as configured
+// with tailf.WithPolling, and only the data past the read position is
+// fetched.
+package tailfsftp
+
+import (
+	"io/fs"
+
+	"github.com/aybabtme/tailf"
+	"github.com/pkg/sftp"
+)
+
+// WithClient follows files on the host client is connected to, by their
+// path there. Since the remote files have no inode the follower can see,
+// rotations are told apart by fingerprint, as Cursor describes.
+func WithClient(client *sftp.Client) tailf.Option {
+	return tailf.WithFS(sftpFS{client})
+}
+
+// sftpFS is an fs.FS opening remote files, which implement tailf.File.
+type sftpFS struct {
+	client *sftp.Client
+}
+
+func (s sftpFS) Open(name string) (fs.File, error) {
+	return s.client.Open(name)
+}
+
+func (s sftpFS) Stat(name string) (fs.FileInfo, error) {
+	return s.client.Stat(name)
+}
+
+func (s sftpFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	infos, err := s.client.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fs.DirEntry, len(infos))
+	for i, fi := range infos {
+		entries[i] = fs.FileInfoToDirEntry(fi)
+	}
+	return entries, nil
+}
@@ -0,0 +1,86 @@
+package tailfsftp_test
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	tailfsftp "github.com/aybabtme/tailf/sftp"
+	"github.com/pkg/sftp"
+)
+
+// newClient returns a client connected to an in-memory SFTP server.
+func newClient(t *testing.T) *sftp.Client {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	server := sftp.NewRequestServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverR, serverW}, sftp.InMemHandler())
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientR, clientW)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		// the client waits for the server to hang up
+		server.Close()
+		client.Close()
+	})
+	return client
+}
+
+func writeFile(t *testing.T, client *sftp.Client, name, data string) {
+	file, err := client.OpenFile(name, os.O_WRONLY|os.O_CREATE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	// the in-memory server doesn't append by itself
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithClient(t *testing.T) {
+	client := newClient(t)
+	writeFile(t, client, "/a.log", "hello,")
+
+	follow, err := tailf.Follow("/a.log", true, tailfsftp.WithClient(client), tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+
+	writeFile(t, client, "/a.log", " world!")
+	time.Sleep(50 * time.Millisecond)
+	if err := client.Rename("/a.log", "/a.log.1"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, client, "/a.log", " bonjour!")
+
+	want := "hello, world! bonjour!"
+	got := make([]byte, len(want))
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(follow, got)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("wanted the remote file to be followed, got %q", got)
+	}
+	if string(got) != want {
+		t.Errorf("wanted %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,164 @@
+package tailf
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// TimeParser returns the timestamp of a line, or false if the line has
+// none, such as the continuation lines of a stack trace.
+type TimeParser func(line []byte) (time.Time, bool)
+
+// LayoutParser returns a TimeParser for lines starting with a timestamp
+// formatted in one of layouts, as understood by time.Parse. Timestamps
+// without a time zone are taken to be in local time.
+func LayoutParser(layouts ...string) TimeParser {
+	return func(line []byte) (time.Time, bool) {
+		for _, layout := range layouts {
+			fields := len(strings.Fields(layout))
+			value := strings.Join(leadingFields(line, fields), " ")
+			if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+				return t, true
+			}
+		}
+		return time.Time{}, false
+	}
+}
+
+// leadingFields returns the first n whitespace separated fields of line.
+func leadingFields(line []byte, n int) []string {
+	var fields []string
+	for _, field := range strings.Fields(string(line[:imin(len(line), 256)])) {
+		if len(fields) == n {
+			break
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+const (
+	// sinceScanWindow is the size of the range left to scan line by line
+	// once the binary search narrowed it down.
+	sinceScanWindow = 64 * 1024
+	// sinceProbeLimit is how far past a probe the binary search looks for
+	// a line with a timestamp.
+	sinceProbeLimit = 1024 * 1024
+)
+
+// FollowSince follows filename from the first line whose timestamp, as
+// returned by parse, isn't before since. If no such line was written yet,
+// following starts at the end of the file, or at the start of its last
+// line if it isn't complete yet, since it may be that line.
+//
+// The line is found by binary search, so timestamps must increase along
+// the file, although lines without a timestamp are fine.
+func FollowSince(filename string, since time.Time, parse TimeParser, opts ...Option) (*Follower, error) {
+	file, err := openFile(filesystem(opts), filename)
+	if err != nil {
+		return nil, err
+	}
+
+	pos, err := searchSince(file, since, parse)
+	if err == nil {
+		_, err = file.Seek(pos, os.SEEK_SET)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return follow(filename, file, pos, opts)
+}
+
+// searchSince returns the offset of the first line of file stamped at or
+// after since.
+func searchSince(file File, since time.Time, parse TimeParser) (int64, error) {
+	fi, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := fi.Size()
+
+	lo, hi := int64(0), size
+	for hi-lo > sinceScanWindow {
+		mid := lo + (hi-lo)/2
+		start, stamp, ok, err := firstStamped(file, mid, mid+sinceProbeLimit, parse, anyTime)
+		if err != nil {
+			return 0, err
+		}
+		if ok && stamp.Before(since) {
+			// everything up to that line is too old
+			lo = start + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	// without a matching line, where the search stopped is the end of
+	// the file, or the start of a last line that isn't complete yet
+	start, _, _, err := firstStamped(file, lo, size, parse, func(stamp time.Time) bool {
+		return !stamp.Before(since)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return start, nil
+}
+
+func anyTime(time.Time) bool { return true }
+
+// firstStamped finds the first line starting at or after pos and before
+// limit with a timestamp matching match, returning its offset and
+// timestamp. If there is none, it returns where it stopped looking, which
+// is the start of the last line if it isn't complete yet and might still
+// match.
+func firstStamped(file File, pos, limit int64, parse TimeParser, match func(time.Time) bool) (int64, time.Time, bool, error) {
+	r := bufio.NewReader(io.NewSectionReader(file, pos, 1<<62))
+	if pos != 0 {
+		// pos is likely in the middle of a line, skip to the next one
+		prev := make([]byte, 1)
+		if _, err := file.ReadAt(prev, pos-1); err != nil {
+			return 0, time.Time{}, false, err
+		}
+		if prev[0] != '\n' {
+			skipped, err := r.ReadBytes('\n')
+			if err == io.EOF {
+				return pos + int64(len(skipped)), time.Time{}, false, nil
+			}
+			if err != nil {
+				return 0, time.Time{}, false, err
+			}
+			pos += int64(len(skipped))
+		}
+	}
+
+	for pos < limit {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// the last line may still be being written, and is only
+			// skipped if its timestamp is known not to match
+			if len(line) == 0 {
+				return pos, time.Time{}, false, nil
+			}
+			stamp, ok := parse(line)
+			if ok && match(stamp) {
+				return pos, stamp, true, nil
+			}
+			if ok {
+				pos += int64(len(line))
+			}
+			return pos, time.Time{}, false, nil
+		}
+		if err != nil {
+			return 0, time.Time{}, false, err
+		}
+		if stamp, ok := parse(line); ok && match(stamp) {
+			return pos, stamp, true, nil
+		}
+		pos += int64(len(line))
+	}
+	return pos, time.Time{}, false, nil
+}
@@ -0,0 +1,79 @@
+package tailf_test
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestFollowSince(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		start := time.Date(2024, 1, 2, 15, 0, 0, 0, time.Local)
+		w := bufio.NewWriter(file)
+		for i := 0; i < 20000; i++ {
+			stamp := start.Add(time.Duration(i) * time.Second)
+			fmt.Fprintf(w, "%s message %d\n", stamp.Format("2006-01-02 15:04:05"), i)
+			if i%10 == 0 {
+				fmt.Fprintf(w, "\tat continuation line %d\n", i)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		since := start.Add(12345 * time.Second)
+		follow, err := tailf.FollowSince(filename, since, tailf.LayoutParser(time.RFC3339, "2006-01-02 15:04:05"))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		scanner := bufio.NewScanner(follow)
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		want := since.Format("2006-01-02 15:04:05") + " message 12345"
+		if got := scanner.Text(); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
+
+func TestFollowSinceIncompleteLine(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		start := time.Date(2024, 1, 2, 15, 0, 0, 0, time.Local)
+		for i := 0; i < 3; i++ {
+			stamp := start.Add(time.Duration(i) * time.Second)
+			fmt.Fprintf(file, "%s message %d\n", stamp.Format("2006-01-02 15:04:05"), i)
+		}
+		// the first line since is still being written
+		since := start.Add(3 * time.Second)
+		if _, err := file.WriteString(since.Format("2006-01-02 15:04:05") + " mess"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.FollowSince(filename, since, tailf.LayoutParser("2006-01-02 15:04:05"))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+		if _, err := file.WriteString("age 3\n"); err != nil {
+			return err
+		}
+
+		scanner := bufio.NewScanner(follow)
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		want := since.Format("2006-01-02 15:04:05") + " message 3"
+		if got := scanner.Text(); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
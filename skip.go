@@ -0,0 +1,100 @@
+package tailf
+
+import "os"
+
+// SkipToLive discards whatever is left to read and moves the reader to
+// the current end of the file, for consumers valuing fresh data over
+// complete data, such as live dashboards. It returns how many bytes of
+// the file were skipped, and emits a Skipped event.
+func (f *Follower) SkipToLive() (int64, error) {
+	defer f.emitPending()
+	f.sendmu.Lock()
+	defer f.sendmu.Unlock()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.released {
+		return 0, nil
+	}
+	return f.skipToLive()
+}
+
+func (f *Follower) skipToLive() (int64, error) {
+	if f.prev != nil {
+		f.rotationBuffer.reset()
+		f.crossRotation()
+	}
+	from := f.cursor()
+	f.ahead, f.aheadEnd = nil, 0
+
+	end, err := f.file.Seek(0, os.SEEK_END)
+	if err != nil {
+		return 0, err
+	}
+	if f.position.bounded && end > f.position.limit {
+		end = f.position.limit
+		if _, err := f.file.Seek(end, os.SEEK_SET); err != nil {
+			return 0, err
+		}
+	}
+	f.position.unmap()
+	f.position.pos = end
+	f.fileReader.Reset(f.position)
+	f.reader = f.newSource()
+
+	to := f.cursor()
+	skipped := to.Offset - from.Offset
+	if skipped < 0 {
+		skipped = 0
+	}
+	f.opts.logger.Info("skipped to end of file", "path", f.filename, "bytes", skipped)
+	f.queue(Skipped{From: from, To: to})
+	return skipped, nil
+}
+
+// Lag returns how many bytes are left to read: what was written to the
+// file past the read position, and what was read from the file but is
+// still buffered, including what is left of a rotated file.
+func (f *Follower) Lag() (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.currentLag()
+}
+
+func (f *Follower) currentLag() (int64, error) {
+	if f.released {
+		return 0, nil
+	}
+	fi, err := f.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return f.lag(fi.Size()), nil
+}
+
+// lag returns how many bytes are left to read, given the size of the
+// current file.
+func (f *Follower) lag(size int64) int64 {
+	n := size - f.position.pos
+	if n < 0 {
+		n = 0
+	}
+	return n + int64(f.buffered()+len(f.ahead))
+}
+
+// enforceMaxLag skips to the end of the file if the reader fell further
+// behind than allowed by WithMaxLag.
+func (f *Follower) enforceMaxLag() error {
+	if f.opts.maxLag <= 0 {
+		return nil
+	}
+	defer f.emitPending()
+	f.sendmu.Lock()
+	defer f.sendmu.Unlock()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.released || f.lag(f.size) <= f.opts.maxLag {
+		return nil
+	}
+	_, err := f.skipToLive()
+	return err
+}
@@ -0,0 +1,107 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestSkipToLive(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		backlog := strings.Repeat("hello, world!\n", 1000)
+		if _, err := file.WriteString(backlog); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		data := make([]byte, 14)
+		if _, err := io.ReadFull(follow, data); err != nil {
+			return err
+		}
+		skipped, err := follow.SkipToLive()
+		if err != nil {
+			return err
+		}
+		if want := int64(len(backlog) - 14); skipped != want {
+			t.Errorf("wanted %d bytes skipped, got %d", want, skipped)
+		}
+
+		return expectLive(t, follow, file)
+	})
+}
+
+func TestMaxLag(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		follow, err := tailf.Follow(filename, false, tailf.WithMaxLag(1024))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if _, err := file.WriteString(strings.Repeat("hello, world!\n", 1000)); err != nil {
+			return err
+		}
+		// let the follower notice it fell behind
+		time.Sleep(100 * time.Millisecond)
+
+		return expectLive(t, follow, file)
+	})
+}
+
+// expectLive writes a line to file and expects it to be the next thing
+// read from follow.
+func expectLive(t *testing.T, follow io.Reader, file *os.File) error {
+	want := "live!\n"
+	if _, err := file.WriteString(want); err != nil {
+		return err
+	}
+	data := make([]byte, len(want))
+	if _, err := io.ReadFull(follow, data); err != nil {
+		return err
+	}
+	if got := string(data); got != want {
+		t.Errorf("wanted '%v', got '%v'", want, got)
+	}
+	return nil
+}
+
+func TestLag(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!\n"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		// part of what is read is left buffered
+		if _, err := io.ReadFull(follow, make([]byte, 5)); err != nil {
+			return err
+		}
+		if _, err := file.WriteString("bonjour!\n"); err != nil {
+			return err
+		}
+
+		lag, err := follow.Lag()
+		if err != nil {
+			return err
+		}
+		if want := int64(14 + 9 - 5); lag != want {
+			t.Errorf("wanted a lag of %d bytes, got %d", want, lag)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,129 @@
+package tailf
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// SnapshotMode decides what is delivered from the files followed with
+// WithSnapshots, whenever their content changed.
+type SnapshotMode int
+
+const (
+	// FullSnapshots delivers the whole content of the file.
+	FullSnapshots SnapshotMode = iota
+	// DiffSnapshots delivers the lines of the file that weren't in the
+	// previous snapshot, in order.
+	DiffSnapshots
+)
+
+// WithSnapshots follows files that are generated anew whenever they are
+// read, such as those of /proc and sysfs, which never report writes and
+// always look empty. The file is read again every interval, and what
+// changed since the previous read is delivered as set by mode, ending
+// with a newline. Unless following from the start, the first read is
+// only compared with the next ones. Offsets count the bytes delivered.
+func WithSnapshots(interval time.Duration, mode SnapshotMode) Option {
+	return func(o *options) {
+		o.snapshots = mode
+		o.snapshotInterval = interval
+	}
+}
+
+// snapshotting tells if opts follow files with WithSnapshots.
+func snapshotting(opts []Option) bool {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o.snapshotInterval > 0
+}
+
+// snapshotFeed is a feedFile fed with the snapshots of a file.
+type snapshotFeed struct {
+	*feedFile
+	last []byte // previous snapshot
+}
+
+// openSnapshots opens the file called name to be read again and again.
+// If fromStart, its current content is delivered first.
+func openSnapshots(file File, name string, fromStart bool) (*feedFile, error) {
+	s := &snapshotFeed{}
+	p, err := newFeedFile(name, file, func(f *Follower, p *feedFile) { f.pumpSnapshots(s) })
+	if err != nil {
+		return nil, err
+	}
+	s.feedFile = p
+	if s.last, err = readSnapshot(file); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if fromStart {
+		p.buf = s.last
+		p.size = int64(len(s.last))
+	}
+	return p, nil
+}
+
+// readSnapshot reads the whole content of file again.
+func readSnapshot(file File) ([]byte, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	return data, nil
+}
+
+// delta returns what to deliver of the snapshot cur, given the previous
+// snapshot prev.
+func (m SnapshotMode) delta(prev, cur []byte) []byte {
+	if bytes.Equal(prev, cur) {
+		return nil
+	}
+	if m == FullSnapshots {
+		return cur
+	}
+	seen := make(map[string]int)
+	for _, line := range bytes.SplitAfter(prev, []byte{'\n'}) {
+		seen[string(line)]++
+	}
+	var diff []byte
+	for _, line := range bytes.SplitAfter(cur, []byte{'\n'}) {
+		if seen[string(line)] > 0 {
+			seen[string(line)]--
+			continue
+		}
+		diff = append(diff, line...)
+	}
+	return diff
+}
+
+// pumpSnapshots reads the followed file every interval, until the
+// follower is closed or the file can't be read anymore.
+func (f *Follower) pumpSnapshots(s *snapshotFeed) {
+	for {
+		select {
+		case <-f.clock().After(f.opts.snapshotInterval):
+		case <-s.done:
+			return
+		}
+		snap, err := readSnapshot(s.file)
+		if err != nil {
+			f.failFeed(s.feedFile, err)
+			return
+		}
+		delta := f.opts.snapshots.delta(s.last, snap)
+		s.last = snap
+		if len(delta) != 0 && !f.feed(s.feedFile, delta) {
+			return
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestSnapshots(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("rx 1\ntx 1"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true, tailf.WithSnapshots(5*time.Millisecond, tailf.FullSnapshots))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		want := "rx 1\ntx 1\nrx 1\ntx 2\n"
+		got := make([]byte, len(want))
+		if _, err := io.ReadFull(follow, got[:10]); err != nil {
+			return err
+		}
+		// rewritten in place, as the files of /proc are regenerated
+		if _, err := file.WriteAt([]byte("tx 2"), 5); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(follow, got[10:]); err != nil {
+			return err
+		}
+		if string(got) != want {
+			t.Errorf("wanted both snapshots %q, got %q", want, got)
+		}
+		return nil
+	})
+}
+
+func TestSnapshotDiffs(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("rx 1\ntx 1\n"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, false, tailf.WithSnapshots(5*time.Millisecond, tailf.DiffSnapshots))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if _, err := file.WriteAt([]byte("tx 2"), 5); err != nil {
+			return err
+		}
+		got := make([]byte, len("tx 2\n"))
+		if _, err := io.ReadFull(follow, got); err != nil {
+			return err
+		}
+		if string(got) != "tx 2\n" {
+			t.Errorf("wanted the changed line, got %q", got)
+		}
+		if _, err := file.WriteAt([]byte("rx 3"), 0); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(follow, got); err != nil {
+			return err
+		}
+		if string(got) != "rx 3\n" {
+			t.Errorf("wanted the changed line, got %q", got)
+		}
+		return nil
+	})
+}
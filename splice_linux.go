@@ -0,0 +1,150 @@
+package tailf
+
+import (
+	"io"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	spliceMove     = 0x1
+	spliceNonblock = 0x2
+
+	// maxSplice is how much is moved at once, the default capacity of a
+	// pipe.
+	maxSplice = 64 * 1024
+)
+
+// splicer moves data from a followed file to a file descriptor through a
+// pipe, with splice(2).
+type splicer struct {
+	w    io.Writer
+	dst  syscall.RawConn
+	r, p int // the ends of the pipe
+}
+
+// newSplicer returns a splicer writing to w, or nil if w isn't backed by
+// a file descriptor.
+func newSplicer(w io.Writer) *splicer {
+	sc, ok := w.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return nil
+	}
+	var p [2]int
+	if err := unix.Pipe2(p[:], unix.O_CLOEXEC|unix.O_NONBLOCK); err != nil {
+		return nil
+	}
+	return &splicer{w: w, dst: rc, r: p[0], p: p[1]}
+}
+
+func (s *splicer) close() {
+	_ = unix.Close(s.r)
+	_ = unix.Close(s.p)
+}
+
+// splice moves what's left of the file to the destination, up to
+// maxSplice bytes. It returns false if the file or the destination can't
+// be spliced, in which case what was moved already was written, and an
+// error if writing failed. The read position only moves past what was
+// written.
+func (s *splicer) splice(f *Follower) (int64, bool, error) {
+	// what else moves the offset of the file waits for the data in the
+	// pipe to be written
+	f.sendmu.Lock()
+	defer f.sendmu.Unlock()
+	f.mu.Lock()
+	if !f.spliceable() {
+		f.mu.Unlock()
+		return 0, true, nil
+	}
+	if f.prev != nil {
+		f.crossRotation()
+	}
+	// spliced from the read position, leaving the offset of the file as
+	// it is until the data was written; splice(2) returns an int on
+	// 32-bit platforms
+	off := f.position.pos
+	moved, err := unix.Splice(int(f.osFile().Fd()), &off, s.p, nil, maxSplice, spliceMove|spliceNonblock)
+	n := int64(moved)
+	f.mu.Unlock()
+	f.emitPending()
+	if err == unix.EINVAL {
+		return 0, false, nil
+	}
+	if err != nil || n <= 0 {
+		// let regular reads wait for more data or report the error
+		return 0, true, nil
+	}
+
+	written, ok, err := s.deliver(n)
+	f.mu.Lock()
+	if f.released {
+		f.final.Offset += written
+	} else {
+		f.position.pos += written
+		if _, serr := f.file.Seek(f.position.pos, io.SeekStart); serr != nil && err == nil {
+			err = serr
+		}
+		f.dropBehind()
+	}
+	f.mu.Unlock()
+	f.countBytes(written, 0, 0)
+	return written, ok, err
+}
+
+// deliver writes the n bytes in the pipe to the destination, splicing
+// them, or copying them if the destination can't be spliced to, such as
+// a file opened with O_APPEND, in which case it returns false.
+func (s *splicer) deliver(n int64) (int64, bool, error) {
+	var written int64
+	for written < n {
+		var m int64
+		var serr error
+		err := s.dst.Write(func(fd uintptr) bool {
+			moved, err := unix.Splice(s.r, nil, int(fd), nil, int(n-written), spliceMove|spliceNonblock)
+			m, serr = int64(moved), err
+			return serr != unix.EAGAIN
+		})
+		if err == nil {
+			err = serr
+		}
+		if err == unix.EINVAL {
+			m, err := s.drain(n - written)
+			return written + m, false, err
+		}
+		if err != nil {
+			return written, true, err
+		}
+		written += m
+	}
+	return written, true, nil
+}
+
+// drain copies the n bytes left in the pipe to the destination.
+func (s *splicer) drain(n int64) (int64, error) {
+	buf := make([]byte, imin(int(n), maxSplice))
+	var written int64
+	for written < n {
+		r, err := unix.Read(s.r, buf[:imin(int(n-written), len(buf))])
+		if err != nil {
+			return written, err
+		}
+		if r == 0 {
+			return written, io.ErrUnexpectedEOF
+		}
+		m, err := s.w.Write(buf[:r])
+		written += int64(m)
+		if err == nil && m != r {
+			err = io.ErrShortWrite
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package tailf
+
+import "io"
+
+// splicer is only available on Linux.
+type splicer struct{}
+
+func newSplicer(w io.Writer) *splicer { return nil }
+
+func (s *splicer) close() {}
+
+func (s *splicer) splice(f *Follower) (int64, bool, error) { return 0, false, nil }
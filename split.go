@@ -0,0 +1,96 @@
+package tailf
+
+import "bytes"
+
+// Splitter splits data in lines, without their trailing newline, as it is
+// read in chunks of any size. It does no I/O, so that it can be used on
+// its own, such as on data read from a Follower, and its splitting
+// checked without files nor timing. The zero Splitter is ready to use.
+//
+// Lines found within a chunk share its memory, so chunks mustn't be
+// modified while their lines are in use. Lines spanning several chunks
+// are joined in a buffer of their own.
+type Splitter struct {
+	// pending is the data left to split, in the order it was added. A
+	// line can span several chunks.
+	pending [][]byte
+}
+
+// newline ends the line left incomplete at a boundary.
+var newline = []byte{'\n'}
+
+// Add adds chunk to the data left to split.
+func (s *Splitter) Add(chunk []byte) {
+	if len(chunk) != 0 {
+		s.pending = append(s.pending, chunk)
+	}
+}
+
+// Boundary marks the end of a file after the data added so far, as when
+// the reader moves on from a rotated or truncated file. A line left
+// incomplete at the end of the file ends there: its writer moved on, so
+// the data added next starts a new line, rather than being torn into a
+// line made of the ends of both files.
+func (s *Splitter) Boundary() {
+	if n := len(s.pending); n != 0 {
+		if last := s.pending[n-1]; last[len(last)-1] != '\n' {
+			s.pending = append(s.pending, newline)
+		}
+	}
+}
+
+// Lines appends the complete lines left to lines, up to max of them, and
+// returns the extended slice.
+func (s *Splitter) Lines(lines [][]byte, max int) [][]byte {
+	for len(lines) < max && len(s.pending) != 0 {
+		head := s.pending[0]
+		if i := bytes.IndexByte(head, '\n'); i >= 0 {
+			lines = append(lines, head[:i:i])
+			s.pending[0] = head[i+1:]
+			if len(s.pending[0]) == 0 {
+				s.pending = s.pending[1:]
+			}
+			continue
+		}
+		if len(s.pending) == 1 {
+			break
+		}
+
+		// join the start of the next chunk, up to its first newline
+		next := s.pending[1]
+		i := bytes.IndexByte(next, '\n') + 1
+		if i == 0 {
+			i = len(next)
+		}
+		joined := make([]byte, 0, len(head)+i)
+		joined = append(append(joined, head...), next[:i]...)
+		s.pending[0] = joined
+		if i == len(next) {
+			s.pending = append(s.pending[:1], s.pending[2:]...)
+		} else {
+			s.pending[1] = next[i:]
+		}
+	}
+	return lines
+}
+
+// Flush returns all the data left and empties the splitter. Once the
+// complete lines were taken with Lines, that is a trailing incomplete
+// line, which is nil if there is none.
+func (s *Splitter) Flush() []byte {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	rest := bytes.Join(s.pending, nil)
+	s.pending = nil
+	return rest
+}
+
+// Len returns how many bytes are left to split.
+func (s *Splitter) Len() int {
+	n := 0
+	for _, chunk := range s.pending {
+		n += len(chunk)
+	}
+	return n
+}
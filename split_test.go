@@ -0,0 +1,72 @@
+package tailf_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/aybabtme/tailf"
+)
+
+func FuzzSplitter(f *testing.F) {
+	f.Add([]byte("hello\nworld\n"), []byte("bonjour\n"), uint8(3))
+	f.Add([]byte("hello\ntorn"), []byte("record\n"), uint8(1))
+	f.Add([]byte(""), []byte("\n\nincomplete"), uint8(0))
+	f.Add([]byte("a long line spanning chunks"), []byte(""), uint8(2))
+
+	// the lines of data, the last one possibly incomplete
+	linesOf := func(data []byte) []string {
+		var lines []string
+		for _, line := range bytes.SplitAfter(data, []byte{'\n'}) {
+			if len(line) != 0 {
+				lines = append(lines, string(bytes.TrimSuffix(line, []byte{'\n'})))
+			}
+		}
+		return lines
+	}
+
+	f.Fuzz(func(t *testing.T, before, after []byte, size uint8) {
+		var s tailf.Splitter
+		add := func(data []byte) {
+			for n := int(size)%7 + 1; len(data) != 0; data = data[imin(n, len(data)):] {
+				s.Add(append([]byte(nil), data[:imin(n, len(data))]...))
+			}
+		}
+		add(before)
+		s.Boundary()
+		add(after)
+
+		var got []string
+		for {
+			lines := s.Lines(nil, 2)
+			if len(lines) == 0 {
+				break
+			}
+			for _, line := range lines {
+				if bytes.IndexByte(line, '\n') >= 0 {
+					t.Fatalf("wanted lines without newlines, got %q", line)
+				}
+				got = append(got, string(line))
+			}
+		}
+		if rest := s.Flush(); rest != nil {
+			got = append(got, string(rest))
+		}
+		if s.Len() != 0 {
+			t.Errorf("wanted nothing left once flushed, got %d bytes", s.Len())
+		}
+
+		// the incomplete line at the end of before is a line of its own
+		want := append(linesOf(before), linesOf(after)...)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("wanted lines %q, got %q", want, got)
+		}
+	})
+}
+
+func imin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,118 @@
+package tailf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ErrCorruptState signifies that a state file couldn't be loaded because
+// its content is damaged, as opposed to missing.
+var ErrCorruptState = errors.New("tailf: corrupt state file")
+
+// stateVersion is the version of the schema of state files. Files written
+// before the schema was versioned hold the bare cursors, and are version 0.
+const stateVersion = 1
+
+type stateFile struct {
+	Version int `json:"version"`
+	// Checksum is the CRC-32 (IEEE) of the Cursors, as written.
+	Checksum uint32          `json:"checksum"`
+	Cursors  json.RawMessage `json:"cursors"`
+}
+
+// WriteState saves cursors to the state file at path, replacing it
+// atomically: the file is written in full under a temporary name, synced
+// and renamed over path, so a crash at any point leaves either the old or
+// the new state behind.
+func WriteState(path string, cursors map[string]Cursor) error {
+	raw, err := json.Marshal(cursors)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(stateFile{
+		Version:  stateVersion,
+		Checksum: crc32.ChecksumIEEE(raw),
+		Cursors:  raw,
+	})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// ReadState loads the cursors saved in the state file at path by
+// WriteState. It returns an error wrapping ErrCorruptState if the file is
+// damaged, and an error satisfying os.IsNotExist if there is no such file.
+func ReadState(path string) (map[string]Cursor, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var state stateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("state file %q: %w: %v", path, ErrCorruptState, err)
+	}
+
+	raw := []byte(state.Cursors)
+	switch state.Version {
+	case 0:
+		// unversioned, the whole file is the cursors
+		raw = bytes.TrimSpace(data)
+	case stateVersion:
+		if sum := crc32.ChecksumIEEE(raw); sum != state.Checksum {
+			return nil, fmt.Errorf("state file %q: %w: checksum is %08x, want %08x", path, ErrCorruptState, sum, state.Checksum)
+		}
+	default:
+		return nil, fmt.Errorf("state file %q: unsupported version %d", path, state.Version)
+	}
+
+	cursors := make(map[string]Cursor)
+	if err := json.Unmarshal(raw, &cursors); err != nil {
+		return nil, fmt.Errorf("state file %q: %w: %v", path, ErrCorruptState, err)
+	}
+	return cursors, nil
+}
+
+// writeFileAtomic writes data to a temporary file, syncs it and renames
+// it over path.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir makes a rename in dir durable. Not every platform can sync a
+// directory, in which case the error is ignored.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	_ = d.Sync()
+	return d.Close()
+}
@@ -0,0 +1,54 @@
+package tailf_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestStateDetectsCorruption(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		path := filepath.Join(filepath.Dir(filename), "state.json")
+		want := map[string]tailf.Cursor{
+			"/var/log/a.log": {Device: 1, Inode: 2, Fingerprint: 3, FingerprintLen: 4, Offset: 1234},
+		}
+		if err := tailf.WriteState(path, want); err != nil {
+			return err
+		}
+
+		got, err := tailf.ReadState(path)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("wanted %v, got %v", want, got)
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		corruptions := map[string][]byte{
+			"bit flip":  bytes.Replace(data, []byte("1234"), []byte("1235"), 1),
+			"truncated": data[:len(data)/2],
+		}
+		for name, corrupt := range corruptions {
+			if err := ioutil.WriteFile(path, corrupt, 0644); err != nil {
+				return err
+			}
+			if _, err := tailf.ReadState(path); err == nil {
+				t.Errorf("%s: wanted an error", name)
+			} else if !errors.Is(err, tailf.ErrCorruptState) {
+				t.Errorf("%s: wanted an ErrCorruptState, got %v", name, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,212 @@
+package tailf
+
+import (
+	"bytes"
+	"sort"
+	"time"
+)
+
+// Stats is a snapshot of the activity of a follower, or of a group of
+// followers.
+type Stats struct {
+	// Bytes and Lines are how much was read.
+	Bytes, Lines int64
+	// Rotations and Truncations are how many times the reader moved on to
+	// a new file, or back to the start of a truncated one.
+	Rotations, Truncations int64
+	// Reopens is how many times the followed path was opened again.
+	Reopens int64
+	// Errors is how many times reading failed.
+	Errors int64
+	// OpenFiles is how many file descriptors are held: the followed file
+	// and its watcher while following, and the file the rotation buffer
+	// spilled to, if any.
+	OpenFiles int
+	// LastEvent is when data was last read, or the reader last moved on
+	// to a new file. It is zero if neither happened yet.
+	LastEvent time.Time
+	// Lag is how many bytes are left to read.
+	Lag int64
+	// DroppedBytes and DroppedLines are how much data left in rotated
+	// files was dropped, as configured with WithDropOldest.
+	DroppedBytes, DroppedLines int64
+	// Throttled is how long reads were held back by the rate limiter.
+	Throttled time.Duration
+	// Latency is the distribution of the time from a write being noticed
+	// to data being read, including any coalescing window.
+	Latency Histogram
+	// ReadTime is the distribution of the time spent in reads returning
+	// data, leaving out the time spent waiting for writes.
+	ReadTime Histogram
+}
+
+// LatencyBuckets are the upper bounds of the buckets of a Histogram.
+var LatencyBuckets = [...]time.Duration{
+	100 * time.Microsecond, 250 * time.Microsecond, 500 * time.Microsecond,
+	time.Millisecond, 2500 * time.Microsecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+// Histogram is a distribution of durations.
+type Histogram struct {
+	// Counts[i] is how many durations were at most LatencyBuckets[i], and
+	// more than the bound before it. The last count is for the durations
+	// longer than every bound.
+	Counts [len(LatencyBuckets) + 1]int64
+	// Count and Sum are how many durations there are, and their total.
+	Count int64
+	Sum   time.Duration
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	i := sort.Search(len(LatencyBuckets), func(i int) bool { return d <= LatencyBuckets[i] })
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+}
+
+func (h *Histogram) add(o Histogram) {
+	for i, n := range o.Counts {
+		h.Counts[i] += n
+	}
+	h.Count += o.Count
+	h.Sum += o.Sum
+}
+
+// add accumulates o in s: counters are summed, and the latest event is
+// kept.
+func (s *Stats) add(o Stats) {
+	s.Bytes += o.Bytes
+	s.Lines += o.Lines
+	s.Rotations += o.Rotations
+	s.Truncations += o.Truncations
+	s.Reopens += o.Reopens
+	s.Errors += o.Errors
+	s.OpenFiles += o.OpenFiles
+	if o.LastEvent.After(s.LastEvent) {
+		s.LastEvent = o.LastEvent
+	}
+	s.Lag += o.Lag
+	s.DroppedBytes += o.DroppedBytes
+	s.DroppedLines += o.DroppedLines
+	s.Throttled += o.Throttled
+	s.Latency.add(o.Latency)
+	s.ReadTime.add(o.ReadTime)
+}
+
+// Stats returns a snapshot of the activity of the follower.
+func (f *Follower) Stats() Stats {
+	f.mu.Lock()
+	lag, err := f.currentLag()
+	if err != nil {
+		lag = f.lag(f.size)
+	}
+	droppedBytes, droppedLines := f.droppedBytes, f.droppedLines
+	var open int
+	if !f.closed {
+		open += 2
+	}
+	if f.rotationBuffer != nil && f.rotationBuffer.spill != nil {
+		open++
+	}
+	f.mu.Unlock()
+
+	f.statsmu.Lock()
+	s := f.stats
+	f.statsmu.Unlock()
+	s.Lag = lag
+	s.DroppedBytes, s.DroppedLines = droppedBytes, droppedLines
+	s.OpenFiles = open
+	return s
+}
+
+// Stats returns the activity of the followers of the group, added up.
+func (c *Checkpointer) Stats() Stats {
+	var s Stats
+	for _, f := range c.members() {
+		s.add(f.Stats())
+	}
+	return s
+}
+
+// FollowerStats returns the activity of each follower of the group, by
+// followed path.
+func (c *Checkpointer) FollowerStats() map[string]Stats {
+	stats := make(map[string]Stats)
+	for _, f := range c.members() {
+		stats[f.filename] = f.Stats()
+	}
+	return stats
+}
+
+// members returns the followers of the group.
+func (c *Checkpointer) members() []*Follower {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	followers := make([]*Follower, 0, len(c.followers))
+	for _, f := range c.followers {
+		followers = append(followers, f)
+	}
+	return followers
+}
+
+func (f *Follower) countRead(data []byte, throttled time.Duration) {
+	f.countBytes(int64(len(data)), int64(bytes.Count(data, []byte{'\n'})), throttled)
+}
+
+// countBytes accounts for n bytes holding lines having been read.
+func (f *Follower) countBytes(n, lines int64, throttled time.Duration) {
+	now := f.clock().Now()
+	f.statsmu.Lock()
+	defer f.statsmu.Unlock()
+	f.stats.Bytes += n
+	f.stats.Lines += lines
+	f.stats.Throttled += throttled
+	f.stats.LastEvent = now
+	if !f.writeSeen.IsZero() {
+		f.stats.Latency.observe(now.Sub(f.writeSeen))
+		f.writeSeen = time.Time{}
+	}
+}
+
+// countWrite notes when a write was noticed, unless an earlier one wasn't
+// read yet.
+func (f *Follower) countWrite() {
+	f.statsmu.Lock()
+	defer f.statsmu.Unlock()
+	if f.writeSeen.IsZero() {
+		f.writeSeen = f.clock().Now()
+	}
+}
+
+func (f *Follower) countReadTime(d time.Duration) {
+	f.statsmu.Lock()
+	defer f.statsmu.Unlock()
+	f.stats.ReadTime.observe(d)
+}
+
+func (f *Follower) countRotation(truncated bool) {
+	f.statsmu.Lock()
+	defer f.statsmu.Unlock()
+	if truncated {
+		f.stats.Truncations++
+	} else {
+		f.stats.Rotations++
+	}
+	f.stats.LastEvent = f.clock().Now()
+}
+
+func (f *Follower) countError(err error) {
+	f.statsmu.Lock()
+	defer f.statsmu.Unlock()
+	f.stats.Errors++
+	f.lastErr = err
+}
+
+func (f *Follower) countReopen() {
+	f.statsmu.Lock()
+	defer f.statsmu.Unlock()
+	f.stats.Reopens++
+}
@@ -0,0 +1,103 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestStats(t *testing.T) {
+	withTempFile(t, time.Millisecond*500, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("a\nb\n"); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if _, err := io.ReadFull(follow, make([]byte, 4)); err != nil {
+			return err
+		}
+		if _, err := file.WriteString("c\n"); err != nil {
+			return err
+		}
+		s := follow.Stats()
+		if s.Bytes != 4 || s.Lines != 2 || s.Lag != 2 || s.LastEvent.IsZero() {
+			t.Errorf("wanted 4 bytes and 2 lines read and 2 bytes of lag, got %+v", s)
+		}
+
+		if err := os.Rename(filename, filename+".1"); err != nil {
+			return err
+		}
+		file, err = os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := file.WriteString("d\n"); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(follow, make([]byte, 4)); err != nil {
+			return err
+		}
+
+		s = follow.Stats()
+		want := tailf.Stats{Bytes: 8, Lines: 4, Rotations: 1, Reopens: 1, OpenFiles: 2, LastEvent: s.LastEvent,
+			Latency: s.Latency, ReadTime: s.ReadTime}
+		if s != want {
+			t.Errorf("wanted %+v, got %+v", want, s)
+		}
+		// the writes to the new file were noticed before being read
+		if s.Latency.Count == 0 || s.ReadTime.Count < 2 {
+			t.Errorf("wanted latencies and read times to be recorded, got %+v and %+v", s.Latency, s.ReadTime)
+		}
+		var counted int64
+		for _, n := range s.ReadTime.Counts {
+			counted += n
+		}
+		if counted != s.ReadTime.Count {
+			t.Errorf("wanted %d read times in buckets, got %d", s.ReadTime.Count, counted)
+		}
+		return nil
+	})
+}
+
+func TestCheckpointerStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := tailf.NewCheckpointer(tailf.NewFileStore(filepath.Join(dir, "state")), time.Hour)
+	defer c.Close()
+
+	for _, name := range []string{"a.log", "b.log"} {
+		filename := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(filename, []byte("hello\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer follow.Close()
+		if _, err := io.ReadFull(follow, make([]byte, 6)); err != nil {
+			t.Fatal(err)
+		}
+		c.Add(follow)
+	}
+
+	if s := c.Stats(); s.Bytes != 12 || s.Lines != 2 {
+		t.Errorf("wanted 12 bytes and 2 lines read, got %+v", s)
+	}
+}
@@ -0,0 +1,118 @@
+package tailf
+
+import (
+	"io"
+	"math"
+	"time"
+)
+
+// StopPolicy decides when reading stops, for consumers only interested in
+// a bounded part of a file. Reading stops as soon as any of the non-zero
+// conditions is met, after which reads return io.EOF. The follower must
+// still be closed.
+type StopPolicy struct {
+	// Bytes is the number of bytes read before stopping.
+	Bytes int64
+	// Lines is the number of lines read before stopping.
+	Lines int
+	// Sentinel stops reading after the first line that is Sentinel,
+	// without its newline, which is read.
+	Sentinel string
+	// After is the time reading stops at, since following started,
+	// whether or not what was written by then was read.
+	After time.Duration
+}
+
+// WithStop stops reading as set by p, instead of following the file until
+// the follower is closed.
+func WithStop(p StopPolicy) Option {
+	return func(o *options) {
+		o.stop = p
+	}
+}
+
+type stopCounter struct {
+	bytes int64
+	lines int
+	// matched is how much of the current line is the start of the
+	// sentinel, or -1 if the line isn't the sentinel.
+	matched int
+	reached bool
+}
+
+// count accounts for data having been read toward p, and returns how much
+// of it is read before stopping, all of it if reading doesn't stop.
+func (s *stopCounter) count(p StopPolicy, data []byte) int {
+	for i, c := range data {
+		s.bytes++
+		stop := p.Bytes != 0 && s.bytes >= p.Bytes
+		switch {
+		case c == '\n':
+			s.lines++
+			stop = stop || (p.Lines != 0 && s.lines >= p.Lines) ||
+				(p.Sentinel != "" && s.matched == len(p.Sentinel))
+			s.matched = 0
+		case s.matched >= 0 && s.matched < len(p.Sentinel) && p.Sentinel[s.matched] == c:
+			s.matched++
+		default:
+			s.matched = -1
+		}
+		if stop {
+			s.reached = true
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// left returns how many bytes are left to read before stopping. The
+// follower must be locked.
+func (f *Follower) left() int64 {
+	if f.opts.stop.Bytes == 0 {
+		return math.MaxInt64
+	}
+	return f.opts.stop.Bytes - f.sinceStart.bytes
+}
+
+// readStopping reads into b like read does, up to where reading stops,
+// and puts back what was read past it.
+func (f *Follower) readStopping(b []byte, c *Cursor) (int, error) {
+	f.mu.Lock()
+	reached, left := f.sinceStart.reached, f.left()
+	f.mu.Unlock()
+	if reached {
+		return 0, io.EOF
+	}
+	if int64(len(b)) > left {
+		b = b[:left]
+	}
+
+	n, err := f.take(b, c)
+	f.mu.Lock()
+	reached = f.sinceStart.reached
+	kept := f.sinceStart.count(f.opts.stop, b[:n])
+	if kept != n {
+		f.unread(b[kept:n], c)
+	}
+	if !reached && f.sinceStart.reached {
+		f.opts.logger.Debug("stopped reading", "path", f.filename)
+	}
+	f.mu.Unlock()
+	f.consumed(b[:kept])
+	return kept, err
+}
+
+// stopAfter stops reading once the time set by the stop policy passed,
+// waking up the reader, unless following stops first.
+func (f *Follower) stopAfter() {
+	select {
+	case <-f.clock().After(f.opts.stop.After):
+	case <-f.stopped:
+		return
+	}
+	f.mu.Lock()
+	f.sinceStart.reached = true
+	f.mu.Unlock()
+	f.opts.logger.Debug("stopped reading", "path", f.filename)
+	f.notify()
+}
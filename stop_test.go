@@ -0,0 +1,51 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestStop(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		policy tailf.StopPolicy
+		want   string
+	}{
+		{"Bytes", tailf.StopPolicy{Bytes: 8}, "first\nse"},
+		{"Lines", tailf.StopPolicy{Lines: 2}, "first\nsecond\n"},
+		{"Sentinel", tailf.StopPolicy{Sentinel: "second"}, "first\nsecond\n"},
+		{"Earliest", tailf.StopPolicy{Lines: 3, Sentinel: "first"}, "first\n"},
+		{"After", tailf.StopPolicy{After: 100 * time.Millisecond}, "first\nsecond\nsecondary\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+				follow, err := tailf.Follow(filename, true, tailf.WithStop(tt.policy))
+				if err != nil {
+					return fmt.Errorf("failed creating tailf.follower: %v", err)
+				}
+				defer follow.Close()
+
+				if _, err := file.WriteString("first\nsecond\nsecondary\n"); err != nil {
+					return err
+				}
+				got, err := ioutil.ReadAll(follow)
+				if err != nil {
+					return err
+				}
+				if string(got) != tt.want {
+					t.Errorf("wanted to read %q, got %q", tt.want, got)
+				}
+				// what wasn't read is left behind the cursor
+				if c := follow.Cursor(); c.Offset != int64(len(tt.want)) {
+					t.Errorf("wanted the cursor at offset %d, got %d", len(tt.want), c.Offset)
+				}
+				return nil
+			})
+		})
+	}
+}
@@ -0,0 +1,265 @@
+// Package tailfsyslog forwards followed lines to a syslog server, framed as
+// in RFC 5424, which makes a minimal file-to-syslog relay of:
+//
+//	w, err := tailfsyslog.Dial("tcp", "logs.example.com:514", tailfsyslog.Config{AppName: "nginx"})
+//	...
+//	_, err = io.Copy(w, follower)
+package tailfsyslog
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+// Facility is the facility of the messages. The zero Facility is User.
+type Facility int
+
+// Facilities. The kernel facility is left out, since it is reserved to
+// the kernel.
+const (
+	User Facility = iota
+	Mail
+	Daemon
+	Auth
+	Syslog
+	LPR
+	News
+	UUCP
+	Cron
+	AuthPriv
+	FTP
+	Local0
+	Local1
+	Local2
+	Local3
+	Local4
+	Local5
+	Local6
+	Local7
+)
+
+// code returns the numerical code of the facility, as defined by RFC
+// 5424.
+func (f Facility) code() int {
+	if f >= Local0 {
+		return 16 + int(f-Local0)
+	}
+	return 1 + int(f)
+}
+
+// Severity is the severity of the messages. The zero Severity is Info.
+type Severity int
+
+// Severities.
+const (
+	Info Severity = iota
+	Emergency
+	Alert
+	Critical
+	Error
+	Warning
+	Notice
+	Debug
+)
+
+// code returns the numerical code of the severity, as defined by RFC
+// 5424.
+func (s Severity) code() int {
+	switch s {
+	case Info:
+		return 6
+	case Debug:
+		return 7
+	}
+	return int(s) - 1
+}
+
+// Config sets the fields of the messages sent, and how to connect to the
+// server.
+type Config struct {
+	// Facility and Severity make the priority of the messages.
+	Facility Facility
+	Severity Severity
+	// Hostname defaults to the name of the host, and AppName to the name
+	// of the program.
+	Hostname string
+	AppName  string
+	// TLSConfig is used to connect with the "tls" network.
+	TLSConfig *tls.Config
+	// Retry is the policy of redialing the server when writing to a
+	// stream fails. If nil, the write is retried once on a new
+	// connection.
+	Retry *tailf.Backoff
+}
+
+// maxLine is the length past which a line is split into several
+// messages.
+const maxLine = 64 * 1024
+
+// Writer sends each line written to it as a message to a syslog server.
+// Over UDP, each message is a datagram, while over TCP and TLS messages
+// are framed by their length, as in RFC 5425. Writes to a stream that
+// fail are retried on a new connection, as set by Config.Retry.
+type Writer struct {
+	network, addr string
+	cfg           Config
+	header        string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	partial []byte
+	msg     bytes.Buffer
+}
+
+// Dial connects to the syslog server at addr, over network, which is
+// "udp", "tcp" or "tls".
+func Dial(network, addr string, cfg Config) (*Writer, error) {
+	switch network {
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("syslog: unsupported network %q", network)
+	}
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = filepath.Base(os.Args[0])
+	}
+	w := &Writer{network: network, addr: addr, cfg: cfg}
+	w.header = fmt.Sprintf("%s %s %d - -",
+		field(cfg.Hostname, 255), field(cfg.AppName, 48), os.Getpid())
+	if err := w.dial(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// field returns s as a header field of at most max characters, or the
+// nil value if it's empty.
+func field(s string, max int) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < max; i++ {
+		// only printable US-ASCII is allowed
+		if s[i] > ' ' && s[i] < 0x7f {
+			b = append(b, s[i])
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+func (w *Writer) dial() error {
+	var conn net.Conn
+	var err error
+	if w.network == "tls" {
+		conn, err = tls.Dial("tcp", w.addr, w.cfg.TLSConfig)
+	} else {
+		conn, err = net.Dial(w.network, w.addr)
+	}
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+// Write sends each complete line in p as a message. What follows the
+// last newline is held until the line is completed, or the writer is
+// closed.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	data := p
+	if len(w.partial) != 0 {
+		data = append(w.partial, p...)
+		w.partial = w.partial[:0]
+	}
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.sendLines(data[:i]); err != nil {
+			return 0, err
+		}
+		data = data[i+1:]
+	}
+	if len(data) > maxLine {
+		if err := w.sendLines(data); err != nil {
+			return 0, err
+		}
+		data = nil
+	}
+	w.partial = append(w.partial[:0], data...)
+	return len(p), nil
+}
+
+// sendLines sends line, split in messages of at most maxLine bytes.
+func (w *Writer) sendLines(line []byte) error {
+	for len(line) > maxLine {
+		if err := w.send(line[:maxLine]); err != nil {
+			return err
+		}
+		line = line[maxLine:]
+	}
+	return w.send(line)
+}
+
+func (w *Writer) send(line []byte) error {
+	pri := w.cfg.Facility.code()*8 + w.cfg.Severity.code()
+	ts := time.Now().Format("2006-01-02T15:04:05.000000Z07:00")
+	w.msg.Reset()
+	fmt.Fprintf(&w.msg, "<%d>1 %s %s ", pri, ts, w.header)
+	w.msg.Write(bytes.TrimSuffix(line, []byte{'\r'}))
+	frame := w.msg.Bytes()
+	if w.network != "udp" {
+		frame = append([]byte(fmt.Sprintf("%d ", len(frame))), frame...)
+	}
+
+	_, err := w.conn.Write(frame)
+	if err == nil || w.network == "udp" {
+		return err
+	}
+	w.conn.Close()
+	retry := tailf.Backoff{MaxAttempts: 1}
+	if w.cfg.Retry != nil {
+		retry = *w.cfg.Retry
+	}
+	return retry.Retry(context.Background(), func() error {
+		if err := w.dial(); err != nil {
+			return err
+		}
+		_, err := w.conn.Write(frame)
+		if err != nil {
+			w.conn.Close()
+		}
+		return err
+	})
+}
+
+// Close sends what is left of an incomplete line, and closes the
+// connection to the server.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var err error
+	if len(w.partial) != 0 {
+		err = w.send(w.partial)
+		w.partial = nil
+	}
+	if cerr := w.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
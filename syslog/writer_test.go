@@ -0,0 +1,128 @@
+package tailfsyslog_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	tailfsyslog "github.com/aybabtme/tailf/syslog"
+)
+
+var message = regexp.MustCompile(`^<(\d+)>1 \S+ host app \d+ - - (.*)$`)
+
+func TestWriterTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	msgs := make(chan string)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			var n int
+			if _, err := fmt.Fscanf(r, "%d ", &n); err != nil {
+				close(msgs)
+				return
+			}
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				close(msgs)
+				return
+			}
+			msgs <- string(msg)
+		}
+	}()
+
+	dir, err := ioutil.TempDir("", "tailf_syslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "a.log")
+	if err := ioutil.WriteFile(filename, []byte("hello\nworld\npartial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	follow, err := tailf.Follow(filename, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := tailfsyslog.Dial("tcp", l.Addr().String(), tailfsyslog.Config{
+		Facility: tailfsyslog.Local0,
+		Severity: tailfsyslog.Notice,
+		Hostname: "host",
+		AppName:  "app",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(w, follow)
+		copied <- err
+	}()
+
+	for _, want := range []string{"hello", "world"} {
+		select {
+		case msg := <-msgs:
+			m := message.FindStringSubmatch(msg)
+			if m == nil || m[1] != "133" || m[2] != want {
+				t.Errorf("wanted a notice from local0 saying %q, got %q", want, msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("wanted a message saying %q", want)
+		}
+	}
+
+	follow.Close()
+	if err := <-copied; err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if m := message.FindStringSubmatch(<-msgs); m == nil || m[2] != "partial" {
+		t.Errorf("wanted the incomplete line to be sent on close, got %q", m)
+	}
+}
+
+func TestWriterUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w, err := tailfsyslog.Dial("udp", conn.LocalAddr().String(), tailfsyslog.Config{Hostname: "host", AppName: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("hello\r\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := message.FindStringSubmatch(string(buf[:n])); m == nil || m[1] != "14" || m[2] != "hello" {
+		t.Errorf("wanted an info from user saying hello, got %q", buf[:n])
+	}
+}
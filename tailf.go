@@ -15,9 +15,11 @@ package tailf
 
 import (
 	"bufio"
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sync"
@@ -27,85 +29,233 @@ import (
 	"gopkg.in/fsnotify.v1"
 )
 
-type (
+var (
 	// ErrFileTruncated signifies the underlying file of a tailf.Follower
-	// has been truncated. The follower should be discarded.
-	ErrFileTruncated struct{ error }
+	// has been truncated.
+	ErrFileTruncated = errors.New("tailf: file was truncated")
 	// ErrFileRemoved signifies the underlying file of a tailf.Follower
-	// has been removed. The follower should be discarded.
-	ErrFileRemoved struct{ error }
+	// has been removed.
+	ErrFileRemoved = errors.New("tailf: file was removed")
 )
 
-type follower struct {
+// Follower is an io.ReadCloser following the writes to a file. Reads
+// block until more data is written to the file, instead of returning
+// io.EOF.
+type Follower struct {
 	filename string
 
+	// sendmu is held while WriteTo sends data from the file without the
+	// follower being locked, and by what else moves the offset of the
+	// file. It is locked before mu.
+	sendmu sync.Mutex
+
 	mu             sync.Mutex
 	notifyc        chan struct{}
-	errc           chan error
-	file           *os.File
+	failed         error              // error following stopped with, if it failed
+	stopped        chan struct{}      // closed once following stopped
+	stopping       context.Context    // done once following stopped, for retries to give up
+	stop           context.CancelFunc // cancels stopping
+	loops          sync.WaitGroup     // goroutines of the follower, WaitClosed waits for them
+	file           File
+	position       *positionReader
+	gen            Cursor  // identity of the file, without offset
+	prev           *Cursor // end of the previous file, until the reader moves past it
+	prevTruncated  bool    // whether prev is the same file, before it got truncated
+	ahead          []byte  // data read ahead of the reader, or past where it stops
+	aheadEnd       int     // how much of ahead is left of the previous file, 0 if none
+	acker          *Acker
 	fileReader     *bufio.Reader
-	rotationBuffer *bytes.Buffer
+	rotationBuffer *rotationBuffer
 	reader         io.Reader
 	watch          *fsnotify.Watcher
 	size           int64
+	opts           options
+	transformer    *transformReader
+	closed         bool
+	notifyClosed   bool
+	remounting     bool    // whether the path is waited on after its filesystem was lost
+	readFailures   int     // reads that failed in a row for transient reasons
+	denied         bool    // whether the path is waited on to be readable again
+	writerExited   bool    // whether the writer set with WithWriter exited
+	teeErr         error   // error writing to the tee failed with, if it did
+	pending        []Event // events that occurred while locked, not emitted yet
+	released       bool    // whether the buffers were returned to the pools
+	dropped        int64   // offset up to which the page cache was told to drop the file
+	final          Cursor  // cursor at the time the buffers were released
+
+	sinceCheckpoint checkpointCounter
+	sinceStart      stopCounter
+	history         *history
+	batch           batchReader
+
+	droppedBytes, droppedLines int64
+
+	statsmu   sync.Mutex
+	stats     Stats
+	lastErr   error
+	writeSeen time.Time // when the oldest write not read yet was noticed
+
+	emitmu       sync.Mutex // held while emitting pending events, to keep them in order
+	evmu         sync.Mutex
+	events       chan Event
+	eventsClosed bool
 }
 
-// Follow returns an io.ReadCloser that follows the writes to a file.
-func Follow(filename string, fromStart bool) (io.ReadCloser, error) {
-	file, err := os.OpenFile(filename, os.O_RDONLY, 0)
+// Follow returns a Follower that follows the writes to a file.
+//
+// Named pipes are followed from their writers' next write, whatever
+// fromStart is, since what was written before is gone. What happens
+// when the writers close the pipe is set with WithFIFOPolicy.
+func Follow(filename string, fromStart bool, opts ...Option) (*Follower, error) {
+	fsys := filesystem(opts)
+	if isPipe(fsys, filename) {
+		// a pipe can't be seeked, and opening it as a file would block
+		// until it has a writer
+		pipe, err := openPipe(filename)
+		if err != nil {
+			return nil, err
+		}
+		return follow(filename, pipe, 0, opts)
+	}
+
+	file, err := openFile(fsys, filename)
 	if err != nil {
 		return nil, err
 	}
+	if snapshotting(opts) {
+		snapshots, err := openSnapshots(file, filename, fromStart)
+		if err != nil {
+			return nil, err
+		}
+		return follow(filename, snapshots, 0, opts)
+	}
 
+	var pos int64
 	if !fromStart {
-		_, err := file.Seek(0, os.SEEK_END)
+		pos, err = file.Seek(0, os.SEEK_END)
 		if err != nil {
 			_ = file.Close()
 			return nil, err
 		}
 	}
 
-	reader := bufio.NewReader(file)
+	return follow(filename, file, pos, opts)
+}
 
-	watch, err := fsnotify.NewWatcher()
+// follow starts following filename, reading from file at pos. The file
+// is closed if following can't start.
+func follow(filename string, file File, pos int64, opts []Option) (*Follower, error) {
+	gen, err := identify(file)
 	if err != nil {
+		_ = file.Close()
 		return nil, err
 	}
 
-	absolute_path, err := filepath.Abs(filename)
+	position := &positionReader{file: file, pos: pos}
+
+	watch, err := fsnotify.NewWatcher()
 	if err != nil {
+		_ = file.Close()
 		return nil, err
 	}
 
-	f := &follower{
-		filename:       absolute_path,
-		notifyc:        make(chan struct{}),
-		errc:           make(chan error),
-		file:           file,
-		fileReader:     reader,
-		rotationBuffer: bytes.NewBuffer(nil),
-		reader:         reader,
-		watch:          watch,
-		size:           0,
+	f := &Follower{
+		filename: filename,
+		notifyc:  make(chan struct{}, 1),
+		stopped:  make(chan struct{}),
+		file:     file,
+		position: position,
+		gen:      gen,
+		watch:    watch,
+		size:     0,
+	}
+	f.stopping, f.stop = context.WithCancel(context.Background())
+	f.opts.eventBuffer = eventBufferSize
+	f.opts.logger = nopLogger{}
+	for _, opt := range opts {
+		opt(&f.opts)
 	}
+	if f.opts.fs == nil {
+		absolute_path, err := filepath.Abs(filename)
+		if err != nil {
+			_ = watch.Close()
+			_ = file.Close()
+			return nil, err
+		}
+		f.filename = absolute_path
+	}
+	f.events = make(chan Event, f.opts.eventBuffer)
+	if f.opts.history > 0 {
+		f.history = newHistory(f.opts.history)
+	}
+	position.bounded, position.limit = f.opts.bounded, f.opts.limit
+	if file := f.osFile(); file != nil {
+		position.ring = f.opts.ring
+		if f.opts.mmap {
+			position.mapped = mapFile(file, pos)
+		}
+	}
+	f.adviseOpen()
+	f.fileReader = getReader(position, f.opts.bufferSize)
+	f.rotationBuffer = newRotationBuffer(f.opts)
+	f.reader = f.newSource()
 
-	if err := watch.Add(filepath.Dir(absolute_path)); err != nil {
+	if f.opts.fs == nil && remotePath(f.filename) {
+		// the writes of other hosts to network shares aren't reliably
+		// notified
+		f.opts.polling = true
+	}
+	if feed, ok := file.(*feedFile); ok {
+		// the data comes from reading the file, not from events
+		f.spawn(func() { feed.pump(f, feed) })
+	} else if f.opts.polling {
+		f.spawn(f.pollForChanges)
+	} else if err := watch.Add(longPath(filepath.Dir(f.filename))); err != nil {
 		// If we can't watch the directory, we need to poll the file to see if it changes
-		go f.pollForChanges()
+		f.opts.logger.Warn("can't watch directory, polling file", "path", f.filename, "err", err)
+		f.spawn(f.pollForChanges)
+	}
+
+	register(f)
+	if f.opts.observer != nil {
+		f.opts.observer.Followed(f)
 	}
 
-	go f.followFile()
+	f.spawn(f.followFile)
+	if f.opts.eventStream {
+		f.spawn(f.streamEvents)
+	}
+	if f.opts.heartbeat > 0 {
+		f.spawn(f.heartbeat)
+	}
+	if f.opts.stop.After > 0 {
+		f.spawn(f.stopAfter)
+	}
+	if f.opts.writer != 0 {
+		f.spawn(f.watchWriter)
+	}
 
 	return f, nil
 }
 
 // Close will remove the watch on the file. Subsequent reads to the file
 // will eventually reach EOF.
-func (f *follower) Close() error {
+func (f *Follower) Close() error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	if !f.closed {
+		unregister(f)
+		if f.opts.observer != nil {
+			defer f.opts.observer.Closed(f)
+		}
+	}
+	f.closed = true
+	if !f.opts.eventStream {
+		defer f.closeEvents()
+	}
 	werr := f.watch.Close()
 	cerr := f.file.Close()
+	f.position.unmap()
 	switch {
 	case werr != nil && cerr == nil:
 		return werr
@@ -114,45 +264,223 @@ func (f *follower) Close() error {
 	case werr != nil && cerr != nil:
 		return fmt.Errorf("couldn't remove watch (%v) and close file (%v)", werr, cerr)
 	}
+	if f.buffered() == 0 {
+		f.release()
+	}
 	return nil
 }
 
-func (f *follower) Read(b []byte) (int, error) {
+// Err returns the error following failed with, which reads return once
+// the data read before the failure was read, or nil if it didn't fail.
+func (f *Follower) Err() error {
 	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.failed
+}
+
+// CloseContext closes the follower like Close, then waits for it to stop
+// following the file, which wakes up the reads in progress, and to stop
+// streaming events with WithEventStream. If ctx is done first, it returns
+// an error wrapping ctx.Err() that tells what didn't stop, and the
+// follower finishes closing in the background.
+func (f *Follower) CloseContext(ctx context.Context) error {
+	closed := make(chan error, 1)
+	go func() { closed <- f.Close() }()
+	var err error
+	select {
+	case err = <-closed:
+	case <-ctx.Done():
+		return fmt.Errorf("tailf: closing %s: follower is busy: %w", f.filename, ctx.Err())
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		f.WaitClosed()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return fmt.Errorf("tailf: closing %s: following or streaming events didn't stop: %w", f.filename, ctx.Err())
+	}
+	return err
+}
+
+// WaitClosed waits for all the goroutines of the follower to return, once
+// it was closed or following failed: watching and polling the file,
+// waiting for it to come back, emitting heartbeats and streaming events
+// with WithEventStream. Once it returns, the follower holds no goroutine
+// nor watch, which services creating and closing many followers can rely
+// on.
+func (f *Follower) WaitClosed() {
+	f.loops.Wait()
+}
 
-	// Refill the buffer
-	_, err := f.fileReader.Peek(1)
-	switch err { // some errors are expected
-	case nil:
-		// all is good
-	case io.EOF:
-		// `readable` will be 0 and we will block
-		// until inotify reports new data, carry on
-	case bufio.ErrBufferFull:
-		// the bufio.Reader was already full, carry on
+// spawn runs loop in the background, as one of the goroutines that
+// WaitClosed waits for. It must be called before following starts, or
+// through background once it did.
+func (f *Follower) spawn(loop func()) {
+	f.loops.Add(1)
+	go func() {
+		defer f.loops.Done()
+		loop()
+	}()
+}
+
+// background runs task in the background like spawn does, unless
+// following already stopped, in which case there's nothing left for the
+// task to do. The task must return promptly once following stops. The
+// follower must be locked.
+func (f *Follower) background(task func()) {
+	select {
+	case <-f.stopped:
+		return
 	default:
-		perr, ok := err.(*os.PathError)
-		if ok && perr.Err == syscall.Errno(syscall.EBADF) {
-			// bad file number will likely be replaced by
-			// a new file on an inotify event, so carry on
-		} else {
-			f.mu.Unlock()
-			return 0, err
+	}
+	f.spawn(task)
+}
+
+// stopFollowing marks following as stopped, which ends the goroutines waiting
+// on it. It's locked against background, so that no goroutine is added
+// once WaitClosed may return.
+func (f *Follower) stopFollowing() {
+	f.mu.Lock()
+	close(f.stopped)
+	f.mu.Unlock()
+	f.stop()
+}
+
+func (f *Follower) Read(b []byte) (int, error) {
+	return f.read(b, nil)
+}
+
+// read reads into b like Read does. If c isn't nil and data was read, c is
+// set to the cursor right after that data.
+func (f *Follower) read(b []byte, c *Cursor) (int, error) {
+	if f.opts.stop != (StopPolicy{}) {
+		return f.readStopping(b, c)
+	}
+	n, err := f.take(b, c)
+	f.consumed(b[:n])
+	return n, err
+}
+
+// take reads into b what was read ahead of the reader, if anything, or
+// else from the file, without accounting for the data as consumed. Once
+// writing to the tee failed, it returns the error instead.
+func (f *Follower) take(b []byte, c *Cursor) (int, error) {
+	f.mu.Lock()
+	err := f.teeErr
+	f.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	if n := f.readAhead(b, c); n != 0 {
+		return n, nil
+	}
+	return f.fetch(b, c)
+}
+
+// fetch reads into b like read does, without accounting for the data as
+// handed to the reader, for data read ahead of it.
+func (f *Follower) fetch(b []byte, c *Cursor) (int, error) {
+	start := f.clock().Now()
+	n, err := f.readOnce(b, c)
+	if n != 0 {
+		f.countReadTime(f.clock().Now().Sub(start))
+	}
+	f.emitPending()
+	if err != nil && err != io.EOF {
+		f.countError(err)
+		f.opts.logger.Error("read failed", "path", f.filename, "err", err)
+		if f.opts.hooks.OnError != nil {
+			f.opts.hooks.OnError(err)
 		}
+		f.emit(Error{Err: err})
+	}
+	return n, err
+}
+
+// consumed accounts for data having been handed to the reader.
+func (f *Follower) consumed(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	if f.opts.tee != nil {
+		f.tee(data)
+	}
+	if f.history != nil {
+		f.history.add(data)
+	}
+	var throttled time.Duration
+	if f.opts.limiter != nil {
+		throttled = f.opts.limiter.wait(data, f.clock())
+	}
+	f.countRead(data, throttled)
+	if f.opts.observer != nil {
+		f.opts.observer.Read(f, data)
+	}
+}
+
+func (f *Follower) readOnce(b []byte, c *Cursor) (int, error) {
+	f.mu.Lock()
+	if f.released {
+		f.mu.Unlock()
+		return 0, io.EOF
+	}
+
+	if n, ok := f.readDirect(b, c); ok {
+		f.mu.Unlock()
+		return n, nil
 	}
-	readable := f.fileReader.Buffered()
 
-	// check for errors before doing anything
+	readable := f.buffered()
+	if readable == 0 {
+		// Refill the buffer
+		_, err := f.fileReader.Peek(1)
+		switch err { // some errors are expected
+		case nil:
+			// all is good
+			f.readFailures = 0
+		case io.EOF:
+			// `readable` will be 0 and we will block
+			// until inotify reports new data, carry on
+			f.readFailures = 0
+		case bufio.ErrBufferFull:
+			// the bufio.Reader was already full, carry on
+		default:
+			perr, ok := err.(*os.PathError)
+			if f.retryRead(err) {
+				// woken up to read again once the failure is
+				// likely over, carry on
+			} else if ok && perr.Err == syscall.Errno(syscall.EBADF) {
+				// bad file number will likely be replaced by
+				// a new file on an inotify event, so carry on
+			} else if err := f.checkMount(err); err != nil {
+				f.mu.Unlock()
+				return 0, f.permissionError(err)
+			}
+		}
+		readable = f.buffered()
+	}
+	if readable == 0 && (f.position.exhausted() || f.writerExited) {
+		f.mu.Unlock()
+		return 0, io.EOF
+	}
+
+	// check for errors before waiting for more
+	if f.failed != nil && readable == 0 {
+		f.mu.Unlock()
+		return 0, f.failed
+	}
 	select {
-	case err, open := <-f.errc:
-		if !open && readable != 0 {
+	case <-f.stopped:
+		if readable != 0 {
 			break
 		}
+		f.release()
 		f.mu.Unlock()
-		if !open {
-			return 0, io.EOF
-		}
-		return 0, err
+		return 0, io.EOF
 	default:
 	}
 
@@ -162,131 +490,373 @@ func (f *follower) Read(b []byte) (int, error) {
 		// wait for the file to grow
 		_, open := <-f.notifyc
 		if !open {
+			f.mu.Lock()
+			if f.failed != nil {
+				f.mu.Unlock()
+				return 0, f.failed
+			}
+			if f.buffered() == 0 {
+				f.release()
+			}
+			f.mu.Unlock()
 			return 0, io.EOF
 		}
 		// then let the reader try again
 		return 0, nil
 	}
 
+	if f.prev != nil && f.rotationBuffer.Len() == 0 {
+		f.crossRotation()
+	}
+
 	n, err := f.reader.Read(b[:imin(readable, len(b))])
+	if c != nil && n != 0 {
+		*c = f.cursor()
+	}
+	f.rotationBuffer.delivered(b[:n])
+	f.checkpoint(b[:n])
+	f.dropBehind()
 	f.mu.Unlock()
 
 	return n, err
 }
 
-func (f *follower) followFile() {
+// readDirect reads from the file straight into b, when b is larger than
+// the bufio.Reader's buffer and nothing is buffered, which saves a copy
+// per buffer while catching up on a large backlog. It returns false if
+// the data must go through the regular path instead.
+func (f *Follower) readDirect(b []byte, c *Cursor) (int, bool) {
+	if len(b) < f.fileReader.Size() || f.transformer != nil ||
+		f.rotationBuffer.Len() != 0 || f.fileReader.Buffered() != 0 {
+		return 0, false
+	}
+	if f.prev != nil {
+		f.crossRotation()
+	}
+	n, _ := f.position.Read(b)
+	if n == 0 {
+		// let the regular path block or report the error
+		return 0, false
+	}
+	if c != nil {
+		*c = f.cursor()
+	}
+	f.rotationBuffer.delivered(b[:n])
+	f.checkpoint(b[:n])
+	f.dropBehind()
+	return n, true
+}
+
+func (f *Follower) followFile() {
 	defer f.watch.Close()
-	defer close(f.notifyc)
-	defer close(f.errc)
+	defer f.closeNotify()
+	defer f.stopFollowing()
+
+	// writes seen during the coalescing window, handled once it ends
+	var coalesced <-chan time.Time
 	for {
 		select {
 		case ev, open := <-f.watch.Events:
 			if !open {
 				return
 			}
-			if pathEqual(ev.Name, f.filename) {
-				err := f.handleFileEvent(ev)
-				if err != nil {
-					f.errc <- err
-					return
+			if !pathEqual(ev.Name, f.filename) || f.droppedEvent() {
+				break
+			}
+			if isOp(ev, fsnotify.Write) || isOp(ev, fsnotify.Create) {
+				f.countWrite()
+			}
+			if f.opts.coalesce > 0 && isOp(ev, fsnotify.Write) && !isOp(ev, fsnotify.Create) {
+				if coalesced == nil {
+					coalesced = f.clock().After(f.opts.coalesce)
 				}
+				continue
+			}
+			err := f.handleFileEvent(ev)
+			if err != nil {
+				f.fail(err)
+				return
+			}
+		case <-coalesced:
+			coalesced = nil
+			if err := f.handleWrite(); err != nil {
+				f.fail(err)
+				return
 			}
 		case err, open := <-f.watch.Errors:
 			if !open {
 				return
 			}
 			if err != nil {
-				f.errc <- err
+				f.fail(err)
 				return
 			}
 		}
 
-		select {
-		case f.notifyc <- struct{}{}:
-			// try to wake up whoever was waiting on an update
-		default:
-			// otherwise just wait for the next event
-		}
+		f.notify()
+	}
+}
+
+// notify wakes up whoever is waiting on an update, or the next reader to
+// wait if there is none, since the file may have grown in between.
+func (f *Follower) notify() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.notifyClosed {
+		return
+	}
+	select {
+	case f.notifyc <- struct{}{}:
+	default:
+		// a wake up is already pending
+		f.opts.logger.Debug("notification coalesced with pending one", "path", f.filename)
 	}
 }
 
-func (f *follower) handleFileEvent(ev fsnotify.Event) error {
+// fail stops following with err, which the reader gets once it read
+// what was buffered, without waiting for the reader to take it.
+func (f *Follower) fail(err error) {
+	f.mu.Lock()
+	if f.failed == nil {
+		f.failed = err
+	}
+	f.mu.Unlock()
+	f.opts.logger.Debug("following failed", "path", f.filename, "err", err)
+	// ends the event loop
+	_ = f.watch.Close()
+	f.notify()
+}
+
+// closeNotify wakes up the readers for good, once following stopped.
+func (f *Follower) closeNotify() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.notifyClosed = true
+	close(f.notifyc)
+}
+
+// knownOps are the operations of fsnotify events that are handled.
+const knownOps = fsnotify.Create | fsnotify.Write | fsnotify.Remove | fsnotify.Rename | fsnotify.Chmod
+
+// handleFileEvent handles each of the operations of ev, as fsnotify may
+// deliver several in one event, such as a write and a chmod.
+func (f *Follower) handleFileEvent(ev fsnotify.Event) error {
+	if ev.Op&^knownOps != 0 || ev.Op == 0 {
+		f.opts.logger.Warn("unknown fsnotify event, ignoring it", "path", ev.Name, "op", uint32(ev.Op))
+		if f.opts.hooks.OnUnknownEvent != nil {
+			f.opts.hooks.OnUnknownEvent(ev.Name, uint32(ev.Op))
+		}
+	}
+
 	switch {
 	case isOp(ev, fsnotify.Create):
-		// new file created with the same name
-		return f.reopenFile()
+		// new file created with the same name, which is read whole, so
+		// writes to it need no handling
+		if err := f.reopenFile(false); err != nil {
+			return err
+		}
 
 	case isOp(ev, fsnotify.Write):
-		// On write, check to see if the file has been truncated
-		// If not, insure the bufio buffer is full
-		switch f.checkForTruncate() {
-		case nil:
-			return f.fillFileBuffer()
-		case ErrFileRemoved{}:
-			// If file was written to and then removed before we could even Stat the file, just wait for the next creation
-			return nil
-		default:
-			return f.reopenFile()
+		if err := f.handleWrite(); err != nil {
+			return err
 		}
 
 	case isOp(ev, fsnotify.Remove), isOp(ev, fsnotify.Rename):
 		// wait for a new file to be created
-		return nil
+	}
 
-	case isOp(ev, fsnotify.Chmod):
-		// Modified time on the file changed, noop
-		return nil
+	if isOp(ev, fsnotify.Chmod) {
+		// the file may have been made unreadable, which only matters
+		// once it is reopened, since it is already open
+		f.checkReadable()
+	}
+	return nil
+}
 
+// handleWrite checks to see if the file has been truncated on write. If
+// not, it insures the bufio buffer is full.
+func (f *Follower) handleWrite() error {
+	switch err := f.checkForTruncate(); {
+	case err == nil:
+		if err := f.enforceMaxLag(); err != nil {
+			return err
+		}
+		return f.fillFileBuffer()
+	case errors.Is(err, ErrFileRemoved):
+		// If file was written to and then removed before we could even Stat the file, just wait for the next creation
+		f.opts.logger.Debug("file removed after write, waiting for a new one", "path", f.filename)
+		return nil
+	case err == errFileReplaced:
+		return f.reopenFile(false)
 	default:
-		return fmt.Errorf("recieved unknown fsnotify event: %#v", ev)
+		if mountLost(err) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			return f.checkMount(err)
+		}
+		return f.reopenFile(true)
 	}
 }
 
-func (f *follower) reopenFile() error {
+// reopenFile moves on to the file currently at the followed path, once
+// everything left in the current file was read. truncated tells if the
+// current file is being reopened because it was truncated, rather than
+// rotated away.
+func (f *Follower) reopenFile(truncated bool) (err error) {
+	f.delayReopen()
+	defer f.emitPending()
+	f.sendmu.Lock()
+	defer f.sendmu.Unlock()
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	if f.released {
+		return nil
+	}
 
-	_, err := os.Stat(f.filename)
+	_, err = fs.Stat(f.filesystem(), f.filename)
 	if os.IsNotExist(err) {
 		// File disappeared too quickly, wait for next rotation
+		f.opts.logger.Debug("file disappeared before reopening, waiting for a new one", "path", f.filename)
 		return nil
 	}
 	if err != nil {
+		return f.checkPermission(err, truncated)
+	}
+	// opened before anything changes, so that a file that can't be
+	// opened leaves the follower as it was
+	next, err := openFile(f.filesystem(), f.filename)
+	if os.IsNotExist(err) {
+		f.opts.logger.Debug("file disappeared before reopening, waiting for a new one", "path", f.filename)
+		return nil
+	}
+	if err != nil {
+		return f.checkPermission(err, truncated)
+	}
+	f.opts.logger.Info("reopening file", "path", f.filename, "truncated", truncated)
+
+	if f.opts.observer != nil {
+		done := f.opts.observer.Reopening(f, truncated)
+		defer func() { done(err) }()
+	}
+
+	// recover unread bytes
+	if err := f.drain(); err != nil {
+		_ = next.Close()
 		return err
 	}
+	if n, lines := f.rotationBuffer.takeDropped(); n != 0 {
+		f.droppedBytes += n
+		f.droppedLines += lines
+		f.opts.logger.Warn("dropped data left in rotated file", "path", f.filename, "bytes", n, "lines", lines)
+		f.queue(Dropped{Bytes: n, Lines: lines})
+	}
+
+	prev := f.gen
+	if prev.FingerprintLen < fingerprintSize {
+		if fp, n := fingerprint(f.file, fingerprintSize); n > prev.FingerprintLen {
+			prev.Fingerprint, prev.FingerprintLen = fp, n
+		}
+	}
+	prev.Offset = f.position.pos
 
+	f.position.unmap()
 	if err := f.file.Close(); err != nil {
+		_ = next.Close()
 		return err
 	}
 
-	f.file, err = os.OpenFile(f.filename, os.O_RDONLY, 0)
-	if err != nil {
+	f.file = next
+	if f.gen, err = identify(f.file); err != nil {
 		return err
 	}
+	var start int64
+	if !truncated {
+		start = f.replacedOffset(prev, f.file)
+	}
+	f.adviseOpen()
+	// later writes are compared to the size of the new file, so that they
+	// don't look like it was truncated
+	f.size = 0
+	if fi, err := f.file.Stat(); err == nil {
+		f.size = fi.Size()
+	}
 
-	// recover buffered bytes
-	unreadByteCount := f.rotationBuffer.Len() + f.fileReader.Buffered()
-	buf := bytes.NewBuffer(make([]byte, unreadByteCount))
+	// a bounded range ends in the file it started in
+	f.position = &positionReader{file: f.file, pos: start, bounded: f.position.bounded, ring: f.position.ring}
+	f.fileReader.Reset(f.position)
+	f.prev = &prev
+	f.prevTruncated = truncated
 
-	n, err := f.reader.Read(buf.Bytes())
-	if err != nil {
-		return err
-	} else if n != unreadByteCount {
-		return fmt.Errorf("failed to flush the buffer completely: Actual(%d) | Expected(%d) | buf_len(%d)", n, unreadByteCount, buf.Len())
+	// append buffered bytes before the new file
+	f.reader = io.MultiReader(f.rotationBuffer, f.newSource())
+	f.countReopen()
+	if f.opts.hooks.OnReopen != nil {
+		f.opts.hooks.OnReopen(prev, f.gen)
 	}
 
-	f.fileReader.Reset(f.file)
-	f.rotationBuffer = buf
+	return nil
+}
 
-	// append buffered bytes before the new file
-	f.reader = io.MultiReader(f.rotationBuffer, f.fileReader)
+// drain appends everything left to read from the current file
+// generation to the rotation buffer, decoded if need be, including what
+// wasn't yet buffered.
+func (f *Follower) drain() error {
+	if f.transformer == nil {
+		_, err := f.rotationBuffer.ReadFrom(f.fileReader)
+		return err
+	}
 
+	if _, err := f.rotationBuffer.Write(f.transformer.dst); err != nil {
+		return err
+	}
+	f.transformer.dst = nil
+	for {
+		if err := f.transformer.pull(); err != nil {
+			return err
+		}
+		out, err := f.transformer.transform(false)
+		if err != nil {
+			return err
+		}
+		if _, err := f.rotationBuffer.Write(out); err != nil {
+			return err
+		}
+		_, err = f.fileReader.Peek(1)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	tail, err := f.transformer.flush()
+	if err != nil {
+		return err
+	}
+	_, err = f.rotationBuffer.Write(tail)
 	return err
 }
 
-func (f *follower) fillFileBuffer() error {
+// buffered returns how many bytes can be read without touching the file.
+func (f *Follower) buffered() int {
+	if f.released {
+		return 0
+	}
+	n := f.rotationBuffer.Len() + f.fileReader.Buffered()
+	if f.transformer != nil {
+		n += f.transformer.pending()
+	}
+	return n
+}
+
+func (f *Follower) fillFileBuffer() error {
+	f.sendmu.Lock()
+	defer f.sendmu.Unlock()
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	if f.released {
+		return nil
+	}
 
 	_, err := f.fileReader.Peek(1) // Refill the buffer
 	switch err {
@@ -295,91 +865,197 @@ func (f *follower) fillFileBuffer() error {
 		return nil
 	default:
 		// not nil and not an expected error
-		return err
+		if f.retryRead(err) {
+			return nil
+		}
+		return f.permissionError(f.checkMount(err))
 	}
 }
 
 // Note: if the file gets truncated, and before the size can be stat'd,
 // it has regrown to be >= the same size as previously, the truncate
 // will be missed. tl;dr, don't use copy-truncate...
-func (f *follower) checkForTruncate() error {
+func (f *Follower) checkForTruncate() error {
 	f.mu.Lock()
 
-	fi, err := os.Stat(f.filename)
-
-	f.mu.Unlock()
+	fi, err := fs.Stat(f.filesystem(), f.filename)
 	if os.IsNotExist(err) {
-		return ErrFileRemoved{fmt.Errorf("file was removed: %v", f.filename)}
+		defer f.mu.Unlock()
+		return f.fileError(ErrFileRemoved, err)
 	}
+	f.mu.Unlock()
 	if err != nil {
 		return err
 	}
 
 	newSize := fi.Size()
-	if newSize < f.size {
-		err = ErrFileTruncated{fmt.Errorf("file (%s) was truncated", f.filename)}
+	oldSize := f.trackedSize()
+	if newSize < oldSize && f.replaced(fi) {
+		// saved by writing a new file in its place, as editors do
+		return errFileReplaced
 	}
+	truncated := newSize < oldSize || f.shrunk()
 
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if truncated {
+		err = f.fileError(ErrFileTruncated, nil)
+	}
 	f.size = newSize
 	return err
 }
 
-// This is here for situations where the directory the watched file sits in can't be inotified on
-func (f *follower) pollForChanges() {
+// shrunk tells if the file being read is now shorter than the offset it
+// was read up to, as when it was truncated and written again past the
+// size last seen, which only brings write events.
+func (f *Follower) shrunk() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.released {
+		return false
+	}
+	fi, err := f.file.Stat()
+	return err == nil && fi.Size() < f.position.pos
+}
+
+// This is here for situations where the directory the watched file sits in can't be inotified on,
+// or when polling was asked for with WithPolling or WithFS, in which case writes are detected by polling too.
+// The interval between polls is shortened while the file changes and lengthened while it is idle.
+func (f *Follower) pollForChanges() {
 	previousFile, err := f.file.Stat()
 	if err != nil {
-		f.errc <- err
+		f.fail(err)
 	}
 
-	if err := f.watch.Add(f.filename); err != nil {
-		f.errc <- err
+	watchFile := !f.opts.polling
+	if watchFile {
+		if err := f.watch.Add(longPath(f.filename)); err != nil {
+			f.fail(err)
+		}
 	}
 
-	for {
-		currentFile, err := os.Stat(f.filename)
+	// files of a NotifyFS are polled as soon as they change
+	wake := make(chan struct{}, 1)
+	if nfs, ok := f.opts.fs.(NotifyFS); ok {
+		stop, err := nfs.Notify(f.filename, func() {
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		})
+		if err != nil {
+			f.opts.logger.Warn("can't be notified of changes, polling file", "path", f.filename, "err", err)
+		} else {
+			defer stop()
+		}
+	}
+
+	minInterval, maxInterval := f.opts.pollMin, f.opts.pollMax
+	if minInterval <= 0 {
+		minInterval = defaultPollMin
+	}
+	if maxInterval < minInterval {
+		maxInterval = imaxDuration(defaultPollMax, minInterval)
+	}
+	interval := maxInterval
+
+	for !f.isClosed() && f.Err() == nil {
+		changed := false
+		currentFile, err := fs.Stat(f.filesystem(), f.filename)
 
 		switch err {
 		case nil:
-			switch os.SameFile(currentFile, previousFile) {
+			switch f.sameFile(previousFile, currentFile) {
 			case true:
-				// No change, do nothing
-				break
-			case false:
+				// compare the next info with the latest, as a
+				// SameFileFS may only tell them apart by what changes
+				// as the file grows
 				previousFile = currentFile
-				if err := f.reopenFile(); err != nil {
-					f.errc <- err
+				if !watchFile && currentFile.Size() != f.trackedSize() {
+					if err := f.handleWrite(); err != nil {
+						f.fail(err)
+					}
+					changed = true
 				}
-
-				if err := f.watch.Add(f.filename); err != nil {
-					f.errc <- err
+			case false:
+				previousFile = currentFile
+				if err := f.reopenFile(false); err != nil {
+					f.fail(err)
 				}
 
-				select {
-				case f.notifyc <- struct{}{}:
-					// try to wake up whoever was waiting on an update
-				default:
-					// otherwise just wait for the next event
+				if watchFile {
+					if err := f.watch.Add(longPath(f.filename)); err != nil {
+						f.fail(err)
+					}
 				}
+				changed = true
 			}
 		default:
-			// Filename doens't seem to be there, wait for it to re-appear
+			// Filename doens't seem to be there, wait for it to re-appear,
+			// unless its filesystem went away, which the reader finds out
+			// when woken up, or it can't be read anymore, which reopening
+			// it reports
+			if mountLost(err) {
+				f.notify()
+			} else if errors.Is(err, fs.ErrPermission) {
+				if err := f.reopenFile(false); err != nil {
+					f.fail(err)
+				}
+			}
 		}
 
-		time.Sleep(time.Second)
+		if changed {
+			f.countWrite()
+			f.notify()
+			interval = minInterval
+		} else if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+		select {
+		case <-f.clock().After(interval):
+		case <-wake:
+		case <-f.stopped:
+		}
 	}
 }
 
+const (
+	defaultPollMin = 100 * time.Millisecond
+	defaultPollMax = time.Second
+)
+
+// isClosed tells if Close was called.
+func (f *Follower) isClosed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+// trackedSize returns the size of the file when it was last checked.
+func (f *Follower) trackedSize() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.size
+}
+
+func imaxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 func isOp(ev fsnotify.Event, op fsnotify.Op) bool {
 	return ev.Op&op == op
 }
 
 func pathEqual(lhs, rhs string) bool {
 	var err error
-	lhs, err = filepath.Abs(lhs)
+	lhs, err = filepath.Abs(shortPath(lhs))
 	if err != nil {
 		return false
 	}
-	rhs, err = filepath.Abs(rhs)
+	rhs, err = filepath.Abs(shortPath(rhs))
 	if err != nil {
 		return false
 	}
@@ -392,3 +1068,10 @@ func imin(a, b int) int {
 	}
 	return b
 }
+
+func imax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
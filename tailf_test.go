@@ -3,6 +3,8 @@ package tailf_test
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -15,6 +17,7 @@ import (
 	"time"
 
 	"github.com/aybabtme/tailf"
+	"go.uber.org/goleak"
 )
 
 func TestImpl(t *testing.T) {
@@ -237,6 +240,41 @@ func canFollowFileFromEnd(t *testing.T, filename string, file *os.File) error {
 	return nil
 }
 
+func TestCanCatchUpWithLargeReads(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		want := make([]byte, 1<<20)
+		for i := range want {
+			want[i] = byte('a' + rand.Intn(26))
+		}
+		if _, err := file.Write(want); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		got := make([]byte, len(want))
+		buf := make([]byte, 256*1024)
+		for n := 0; n < len(got); {
+			m, err := follow.Read(buf)
+			if err != nil {
+				return err
+			}
+			n += copy(got[n:], buf[:m])
+		}
+		if !bytes.Equal(want, got) {
+			t.Errorf("data read differs from data written")
+		}
+		if off := follow.Cursor().Offset; off != int64(len(want)) {
+			t.Errorf("wanted cursor at %d, got %d", len(want), off)
+		}
+		return nil
+	})
+}
+
 func TestFollowTruncation(t *testing.T) { withTempFile(t, time.Millisecond*150, canFollowTruncation) }
 
 func canFollowTruncation(t *testing.T, filename string, file *os.File) error {
@@ -282,6 +320,55 @@ func canFollowTruncation(t *testing.T, filename string, file *os.File) error {
 	return nil
 }
 
+// Truncate a file that was read further than its size was last seen, and
+// write it again, so that it shrinks without its size ever looking smaller
+func TestFollowShrinkInPlace(t *testing.T) {
+	var truncated bool
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString(strings.Repeat("x", 100)); err != nil {
+			return err
+		}
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+		if _, err := io.ReadFull(follow, make([]byte, 100)); err != nil {
+			return err
+		}
+
+		if err := file.Truncate(0); err != nil {
+			return err
+		}
+		want := strings.Repeat("y", 50)
+		if _, err := file.WriteAt([]byte(want), 0); err != nil {
+			return err
+		}
+		got := make([]byte, len(want))
+		if _, err := io.ReadFull(follow, got); err != nil {
+			return err
+		}
+		if string(got) != want {
+			t.Errorf("wanted the rewritten file to be read, got %q", got)
+		}
+		// the truncation is noticed before the rewritten data is read
+		for {
+			select {
+			case ev := <-follow.Events():
+				if _, ok := ev.(tailf.Truncated); ok {
+					truncated = true
+					return nil
+				}
+			default:
+				return nil
+			}
+		}
+	})
+	if !truncated {
+		t.Fatal("wanted a Truncated event")
+	}
+}
+
 // Continually read from a file that is having data written to it every 5ms, and randomly truncated every [5,55]ms
 func TestFollowRandomTruncation(t *testing.T) {
 	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
@@ -489,3 +576,183 @@ func withTempFile(t *testing.T, timeout time.Duration, action func(t *testing.T,
 		t.Error("test took too long :(")
 	}
 }
+
+func BenchmarkRead(b *testing.B) {
+	for _, size := range []int{512, 64 * 1024} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			benchmarkRead(b, size)
+		})
+	}
+}
+
+func benchmarkRead(b *testing.B, size int) {
+	file, err := ioutil.TempFile("", "tailf_bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	chunk := bytes.Repeat([]byte("hello, world!\n"), size/14+1)[:size]
+	for i := 0; i < b.N; i++ {
+		if _, err := file.Write(chunk); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	follow, err := tailf.Follow(file.Name(), true)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer follow.Close()
+
+	buf := make([]byte, size)
+	b.SetBytes(int64(size))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := io.ReadFull(follow, buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestPollingWithoutNotifications(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		follow, err := tailf.Follow(filename, false, tailf.WithPolling(5*time.Millisecond, 50*time.Millisecond))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		// let the poller go idle before writing
+		time.Sleep(100 * time.Millisecond)
+		if _, err := file.WriteString("hello,"); err != nil {
+			return err
+		}
+
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+		file, err = os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := file.WriteString(" world!"); err != nil {
+			return err
+		}
+
+		want := "hello, world!"
+		data := make([]byte, len(want))
+		if _, err := io.ReadFull(follow, data); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
+
+func TestErrWithoutReader(t *testing.T) {
+	fsys := newMemFS()
+	fsys.write("a.log", "hello,", false)
+
+	follow, err := tailf.Follow("a.log", true, tailf.WithFS(fsys), tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+
+	// fails while nobody reads
+	fsys.setDenied(true)
+	deadline := time.Now().Add(time.Second)
+	for follow.Err() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("wanted following to fail")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	got := make([]byte, len("hello,"))
+	if _, err := io.ReadFull(follow, got); err != nil {
+		t.Fatalf("wanted the data read before the failure, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := follow.Read(got); err != follow.Err() {
+			t.Errorf("wanted reads to fail with %v, got %v", follow.Err(), err)
+		}
+	}
+}
+
+func TestCloseContext(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		follow, err := tailf.Follow(filename, false, tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		read := make(chan error, 1)
+		go func() {
+			_, err := follow.Read(make([]byte, 1))
+			read <- err
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		if err := follow.CloseContext(ctx); err != nil {
+			return err
+		}
+		if err := <-read; err != io.EOF {
+			t.Errorf("wanted the pending read to reach EOF, got %v", err)
+		}
+		return nil
+	})
+}
+
+func TestCloseContextTimeout(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!"); err != nil {
+			return err
+		}
+		follow, err := tailf.Follow(filename, true, tailf.WithEventStream(), tailf.WithEventBuffer(0))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+
+		// the events aren't drained, so streaming them can't stop
+		time.Sleep(20 * time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := follow.CloseContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("wanted closing to time out, got %v", err)
+		}
+		for range follow.Events() {
+		}
+		return nil
+	})
+}
+
+func TestWaitClosed(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	for _, opts := range [][]tailf.Option{
+		nil,
+		{tailf.WithPolling(5*time.Millisecond, 5*time.Millisecond)},
+		{tailf.WithEventStream(), tailf.WithHeartbeat(time.Hour)},
+	} {
+		withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+			for i := 0; i < 10; i++ {
+				follow, err := tailf.Follow(filename, false, opts...)
+				if err != nil {
+					return fmt.Errorf("failed creating tailf.follower: %v", err)
+				}
+				if err := follow.Close(); err != nil {
+					return err
+				}
+				follow.WaitClosed()
+			}
+			return nil
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package tailftest
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+// Backend is a filesystem which RunBackendTests changes to check how the
+// followers of its files cope. FS is one; other filesystems implement the
+// changes with their own API. A change that fails should panic, which
+// fails the test.
+type Backend interface {
+	// Append appends s to the file called name, creating it if it
+	// doesn't exist.
+	Append(name, s string)
+	// Rotate renames the file called name to rotated, and creates an
+	// empty file called name in its place.
+	Rotate(name, rotated string)
+	// Truncate empties the file called name.
+	Truncate(name string)
+	// Remove removes the file called name.
+	Remove(name string)
+}
+
+// backendTimeout is how long RunBackendTests waits for followers to
+// notice a change, which remote backends may take a while to show.
+const backendTimeout = 5 * time.Second
+
+// RunBackendTests checks that the followers of the files of backend read
+// what is appended to them, carry on through rotations, truncations and
+// removals, and resume where they were after a restart, as they do with
+// files of the OS filesystem. Each test runs as a subtest, on files of
+// its own named after it.
+//
+// If backend is an fs.FS, its files are followed with tailf.WithFS.
+// Otherwise, opts must tell where they are followed from, as the options
+// of packages following other filesystems do. The followers poll backend
+// often, unless opts, added to their options, set otherwise with
+// tailf.WithPolling.
+func RunBackendTests(t *testing.T, backend Backend, opts ...tailf.Option) {
+	opts = backendOptions(backend, opts)
+	follow := func(t *testing.T, name string, fromStart bool) (*tailf.Follower, *Recorder) {
+		t.Helper()
+		follow, err := tailf.Follow(name, fromStart, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = follow.Close() })
+		return follow, Record(follow)
+	}
+	wait := func(t *testing.T, rec *Recorder, like tailf.Event) {
+		t.Helper()
+		if _, err := rec.WaitFor(like, backendTimeout); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Append", func(t *testing.T) {
+		backend.Append("append.log", "hello,")
+		follow, _ := follow(t, "append.log", true)
+		read(t, follow, "hello,")
+		backend.Append("append.log", " world!")
+		read(t, follow, " world!")
+	})
+
+	t.Run("FromEnd", func(t *testing.T) {
+		backend.Append("end.log", "hello,")
+		follow, _ := follow(t, "end.log", false)
+		backend.Append("end.log", " world!")
+		read(t, follow, " world!")
+	})
+
+	t.Run("Rotate", func(t *testing.T) {
+		backend.Append("rotate.log", "hello,")
+		follow, rec := follow(t, "rotate.log", true)
+		read(t, follow, "hello,")
+
+		// what was left in the rotated file is read first
+		backend.Append("rotate.log", " world!")
+		backend.Rotate("rotate.log", "rotate.log.1")
+		backend.Append("rotate.log", "bonjour!")
+		read(t, follow, " world!bonjour!")
+		wait(t, rec, tailf.Rotated{})
+	})
+
+	t.Run("Truncate", func(t *testing.T) {
+		backend.Append("truncate.log", "hello, world!")
+		follow, rec := follow(t, "truncate.log", true)
+		read(t, follow, "hello, world!")
+
+		backend.Truncate("truncate.log")
+		backend.Append("truncate.log", "hi")
+		read(t, follow, "hi")
+		wait(t, rec, tailf.Truncated{})
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		backend.Append("remove.log", "hello,")
+		follow, rec := follow(t, "remove.log", true)
+		read(t, follow, "hello,")
+
+		backend.Remove("remove.log")
+		backend.Append("remove.log", "bonjour!")
+		read(t, follow, "bonjour!")
+		wait(t, rec, tailf.Rotated{})
+	})
+
+	t.Run("Restart", func(t *testing.T) {
+		backend.Append("restart.log", "hello,")
+		follow, _ := follow(t, "restart.log", true)
+		read(t, follow, "hello,")
+		c := follow.Cursor()
+		if err := follow.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// resumes where it was, skipping what was read already
+		backend.Append("restart.log", " world!")
+		follow, err := tailf.Resume("restart.log", c, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer follow.Close()
+		read(t, follow, " world!")
+		c = follow.Cursor()
+		if err := follow.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// starts over from the start of the file, truncated since
+		backend.Truncate("restart.log")
+		backend.Append("restart.log", "hi")
+		follow, err = tailf.Resume("restart.log", c, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer follow.Close()
+		read(t, follow, "hi")
+	})
+}
+
+// backendOptions returns the options of the followers of the files of
+// backend, which are followed with tailf.WithFS if it is an fs.FS, and
+// polled often unless opts, added last, set otherwise.
+func backendOptions(backend Backend, opts []tailf.Option) []tailf.Option {
+	defaults := []tailf.Option{tailf.WithPolling(time.Millisecond, 20*time.Millisecond)}
+	if fsys, ok := backend.(fs.FS); ok {
+		defaults = append(defaults, tailf.WithFS(fsys))
+	}
+	return append(defaults, opts...)
+}
+
+// read reads len(want) bytes from follow, failing t unless they are want.
+func read(t *testing.T, follow *tailf.Follower, want string) {
+	t.Helper()
+	if err := readWant(follow, want); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readWant reads len(want) bytes from follow, failing unless they are
+// want.
+func readWant(follow *tailf.Follower, want string) error {
+	got := make([]byte, len(want))
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(follow, got)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+	case <-time.After(backendTimeout):
+		return fmt.Errorf("wanted to read %q within %v", want, backendTimeout)
+	}
+	if string(got) != want {
+		return fmt.Errorf("wanted to read %q, got %q", want, got)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package tailftest_test
+
+import (
+	"testing"
+
+	"github.com/aybabtme/tailf/tailftest"
+)
+
+func TestBackend(t *testing.T) {
+	tailftest.RunBackendTests(t, tailftest.NewFS())
+}
@@ -0,0 +1,56 @@
+package tailftest
+
+import (
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+// bufferName is the name of the file of a Buffer in its FS.
+const bufferName = "buffer"
+
+// Buffer is a file held in memory, like a bytes.Buffer, whose followers
+// only see it grow when Notify is called. Tests write to it, then decide
+// when followers wake up to what was written, which runs at full speed
+// and doesn't depend on the timing of a filesystem. It is safe for
+// concurrent use.
+type Buffer struct {
+	fsys *FS
+}
+
+// NewBuffer returns an empty Buffer.
+func NewBuffer() *Buffer {
+	fsys := NewFS()
+	fsys.files[bufferName] = &data{modTime: time.Now()}
+	return &Buffer{fsys: fsys}
+}
+
+// Follow follows the buffer, from its start or from what is written to
+// it next. Followers blocked waiting for data only wake up once Notify is
+// called, as they don't poll the buffer unless opts set otherwise with
+// tailf.WithPolling.
+func (b *Buffer) Follow(fromStart bool, opts ...tailf.Option) (*tailf.Follower, error) {
+	return b.fsys.Follow(bufferName, fromStart, append([]tailf.Option{tailf.WithPolling(time.Hour, time.Hour)}, opts...)...)
+}
+
+// Write appends p to the buffer, without notifying its followers. It
+// never fails.
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.fsys.mu.Lock()
+	defer b.fsys.mu.Unlock()
+	d := b.fsys.files[bufferName]
+	d.b = append(d.b, p...)
+	d.modTime = time.Now()
+	return len(p), nil
+}
+
+// WriteString appends s to the buffer, like Write.
+func (b *Buffer) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+// Notify tells the followers of the buffer that it grew, which wakes them
+// up to read what was written since.
+func (b *Buffer) Notify() {
+	b.fsys.change(func() []string { return []string{bufferName} })
+}
@@ -0,0 +1,51 @@
+package tailftest_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf/tailftest"
+)
+
+func TestBuffer(t *testing.T) {
+	buf := tailftest.NewBuffer()
+	buf.WriteString("hello,")
+
+	follow, err := buf.Follow(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+
+	got := make([]byte, len("hello, world!"))
+	if _, err := io.ReadFull(follow, got[:6]); err != nil {
+		t.Fatal(err)
+	}
+
+	read := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(follow, got[6:])
+		read <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	buf.WriteString(" world!")
+	select {
+	case err := <-read:
+		t.Fatalf("wanted the write to go unnoticed until notified, read %q, %v", got, err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	buf.Notify()
+	select {
+	case err := <-read:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wanted the write to be read once notified")
+	}
+	if string(got) != "hello, world!" {
+		t.Errorf("wanted %q, got %q", "hello, world!", got)
+	}
+}
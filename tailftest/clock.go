@@ -0,0 +1,78 @@
+package tailftest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a tailf.Clock whose time only passes when it is advanced, so
+// that tests decide when followers poll, retry or beat, instead of
+// waiting for them to. It is safe for concurrent use.
+type Clock struct {
+	mu      sync.Mutex
+	waited  *sync.Cond
+	now     time.Time
+	pending []wait
+}
+
+// wait is a call to After that isn't over.
+type wait struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewClock returns a Clock telling now until it is advanced.
+func NewClock(now time.Time) *Clock {
+	c := &Clock{now: now}
+	c.waited = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the time of c.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After sends the time on the returned channel once c was advanced by d.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.pending = append(c.pending, wait{at: c.now.Add(d), c: ch})
+	c.waited.Broadcast()
+	return ch
+}
+
+// Advance moves the time of c forward by d, ending the waits that are
+// over.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.pending[:0]
+	for _, w := range c.pending {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.c <- c.now
+	}
+	c.pending = pending
+}
+
+// BlockUntil returns once n calls to After wait for c to be advanced, as
+// when followers are idle. Waits that were given up on count until they
+// are over.
+func (c *Clock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.pending) < n {
+		c.waited.Wait()
+	}
+}
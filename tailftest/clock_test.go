@@ -0,0 +1,34 @@
+package tailftest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	"github.com/aybabtme/tailf/tailftest"
+)
+
+func TestClock(t *testing.T) {
+	fsys := tailftest.NewFS()
+	fsys.Append("a.log", "hello,")
+	clock := tailftest.NewClock(time.Unix(0, 0))
+
+	follow, err := fsys.Follow("a.log", false, tailf.WithClock(clock), tailf.WithHeartbeat(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+
+	// the poller and the heartbeat wait for an hour to pass, which takes
+	// no time
+	clock.BlockUntil(2)
+	clock.Advance(time.Hour)
+	select {
+	case ev := <-follow.Events():
+		if _, ok := ev.(tailf.Heartbeat); !ok {
+			t.Errorf("wanted a heartbeat, got %#v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("wanted a heartbeat once an hour passed")
+	}
+}
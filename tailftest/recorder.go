@@ -0,0 +1,119 @@
+package tailftest
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+// Recorder records the events of a follower in order, so that tests can
+// wait for them and check them. Followers configured with
+// tailf.WithEventStream deliver their data as DataChunk events, which are
+// recorded along with the others.
+type Recorder struct {
+	mu      sync.Mutex
+	events  []tailf.Event
+	next    int           // index of the event WaitFor looks at next
+	changed chan struct{} // closed when an event is recorded, or recording stops
+	done    bool
+}
+
+// Record records the events of follow, until its channel of events is
+// closed. Nothing else should receive from it.
+func Record(follow *tailf.Follower) *Recorder {
+	r := &Recorder{changed: make(chan struct{})}
+	go func() {
+		for ev := range follow.Events() {
+			r.record(ev, false)
+		}
+		r.record(nil, true)
+	}()
+	return r
+}
+
+func (r *Recorder) record(ev tailf.Event, done bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ev != nil {
+		r.events = append(r.events, ev)
+	}
+	r.done = done
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Events returns the events recorded so far.
+func (r *Recorder) Events() []tailf.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]tailf.Event(nil), r.events...)
+}
+
+// Data returns the data of the DataChunk events recorded so far.
+func (r *Recorder) Data() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var data []byte
+	for _, ev := range r.events {
+		if chunk, ok := ev.(tailf.DataChunk); ok {
+			data = append(data, chunk.Bytes...)
+		}
+	}
+	return data
+}
+
+// WaitFor waits for an event of the same type as like, such as
+// tailf.Rotated{}, to be recorded after the last one WaitFor returned,
+// and returns it. It fails once timeout passed, or if the follower stopped
+// emitting events.
+func (r *Recorder) WaitFor(like tailf.Event, timeout time.Duration) (tailf.Event, error) {
+	want := reflect.TypeOf(like)
+	expired := time.After(timeout)
+	for {
+		r.mu.Lock()
+		for ; r.next < len(r.events); r.next++ {
+			if ev := r.events[r.next]; reflect.TypeOf(ev) == want {
+				r.next++
+				r.mu.Unlock()
+				return ev, nil
+			}
+		}
+		changed, done := r.changed, r.done
+		r.mu.Unlock()
+		if done {
+			return nil, fmt.Errorf("tailftest: no %v was emitted before the follower stopped", want)
+		}
+		select {
+		case <-changed:
+		case <-expired:
+			return nil, fmt.Errorf("tailftest: no %v was emitted within %v, got %v", want, timeout, r.Events())
+		}
+	}
+}
+
+// WaitForData waits for the data recorded to hold s, and fails once
+// timeout passed, or if the follower stopped emitting events.
+func (r *Recorder) WaitForData(s string, timeout time.Duration) error {
+	expired := time.After(timeout)
+	for {
+		r.mu.Lock()
+		changed, done := r.changed, r.done
+		r.mu.Unlock()
+		data := r.Data()
+		if bytes.Contains(data, []byte(s)) {
+			return nil
+		}
+		if done {
+			return fmt.Errorf("tailftest: %q wasn't read before the follower stopped, got %q", s, data)
+		}
+		select {
+		case <-changed:
+		case <-expired:
+			return fmt.Errorf("tailftest: %q wasn't read within %v, got %q", s, timeout, data)
+		}
+	}
+}
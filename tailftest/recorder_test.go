@@ -0,0 +1,42 @@
+package tailftest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	"github.com/aybabtme/tailf/tailftest"
+)
+
+func TestRecorder(t *testing.T) {
+	fsys := tailftest.NewFS()
+	fsys.Append("a.log", "hello,")
+
+	follow, err := fsys.Follow("a.log", true, tailf.WithEventStream())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := tailftest.Record(follow)
+
+	if err := rec.WaitForData("hello,", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	fsys.Rotate("a.log", "a.log.1")
+	fsys.Append("a.log", " world!")
+	if _, err := rec.WaitFor(tailf.Rotated{}, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.WaitForData("hello, world!", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.WaitFor(tailf.Truncated{}, 10*time.Millisecond); err == nil {
+		t.Error("wanted no truncation to be recorded")
+	}
+
+	if err := follow.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.WaitFor(tailf.Rotated{}, time.Second); err == nil {
+		t.Error("wanted a single rotation to be recorded")
+	}
+}
@@ -0,0 +1,157 @@
+package tailftest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pattern is how a Scenario writes to its file.
+type Pattern int
+
+const (
+	// Steady appends one line at a time.
+	Steady Pattern = iota
+	// Logrotate appends one line at a time and, every RotateEvery lines,
+	// renames the file away and creates a new one in its place, as
+	// logrotate does by default.
+	Logrotate
+	// CopyTruncate appends one line at a time and, every RotateEvery
+	// lines, copies the file away and truncates it in place, as logrotate
+	// does with copytruncate. Lines not read before the file is truncated
+	// are lost to its followers.
+	CopyTruncate
+	// Bursty appends Burst lines at a time.
+	Bursty
+)
+
+// Scenario writes numbered lines to a file of the OS filesystem following
+// a Pattern, so that how followers cope with it can be reproduced. The
+// file is written the same way on every run, the only variation being
+// how fast the follower keeps up.
+type Scenario struct {
+	// Path is the file written to, created if need be. Files rotated away
+	// are called Path.1, Path.2 and so on.
+	Path    string
+	Pattern Pattern
+	// Lines is how many lines are written, as returned by Line.
+	Lines int
+	// Interval is the wait between writes, none if zero.
+	Interval time.Duration
+	// RotateEvery is how many lines are written between rotations, with
+	// Logrotate and CopyTruncate, 10 if zero.
+	RotateEvery int
+	// Burst is how many lines are written at once with Bursty, 10 if
+	// zero.
+	Burst int
+}
+
+// Line returns the line numbered n that scenarios write, counting from 0.
+func Line(n int) string {
+	return "line " + strconv.Itoa(n) + "\n"
+}
+
+// Want returns the lines a scenario writing n lines writes, in order, as
+// its followers read them unless they lose some to truncation.
+func Want(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteString(Line(i))
+	}
+	return b.String()
+}
+
+// Run writes the lines of s, until they are all written or ctx is done.
+func (s Scenario) Run(ctx context.Context) error {
+	rotateEvery, burst := s.RotateEvery, s.Burst
+	if rotateEvery <= 0 {
+		rotateEvery = 10
+	}
+	if burst <= 0 || s.Pattern != Bursty {
+		burst = 1
+	}
+
+	file, err := os.OpenFile(s.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	rotated := 0
+	for n := 0; n < s.Lines; {
+		var lines string
+		for i := 0; i < burst && n < s.Lines; i++ {
+			lines += Line(n)
+			n++
+		}
+		if _, err := file.WriteString(lines); err != nil {
+			return err
+		}
+
+		if n%rotateEvery == 0 && n < s.Lines {
+			switch s.Pattern {
+			case Logrotate:
+				rotated++
+				if file, err = s.rotate(file, rotated); err != nil {
+					return err
+				}
+			case CopyTruncate:
+				rotated++
+				if err := s.copyTruncate(file, rotated); err != nil {
+					return err
+				}
+			}
+		}
+
+		if s.Interval <= 0 {
+			continue
+		}
+		select {
+		case <-time.After(s.Interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return file.Close()
+}
+
+// rotate renames file to its nth rotated name, and returns the new file
+// created in its place.
+func (s Scenario) rotate(file *os.File, n int) (*os.File, error) {
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(s.Path, s.rotatedPath(n)); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(s.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, 0644)
+}
+
+// copyTruncate copies file to its nth rotated name, and truncates it.
+func (s Scenario) copyTruncate(file *os.File, n int) error {
+	src, err := os.Open(s.Path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(s.rotatedPath(n))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return file.Truncate(0)
+}
+
+func (s Scenario) rotatedPath(n int) string {
+	return fmt.Sprintf("%s.%d", s.Path, n)
+}
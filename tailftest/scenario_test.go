@@ -0,0 +1,91 @@
+package tailftest_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	"github.com/aybabtme/tailf/tailftest"
+)
+
+func TestScenarios(t *testing.T) {
+	for name, pattern := range map[string]tailftest.Pattern{
+		"steady":    tailftest.Steady,
+		"logrotate": tailftest.Logrotate,
+		"bursty":    tailftest.Bursty,
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "tailftest")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			s := tailftest.Scenario{Path: filepath.Join(dir, "a.log"), Pattern: pattern, Lines: 50, Interval: time.Millisecond}
+			if err := ioutil.WriteFile(s.Path, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			follow, err := tailf.Follow(s.Path, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer follow.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			written := make(chan error, 1)
+			go func() { written <- s.Run(ctx) }()
+
+			want := tailftest.Want(s.Lines)
+			got := make([]byte, len(want))
+			read := make(chan error, 1)
+			go func() {
+				_, err := io.ReadFull(follow, got)
+				read <- err
+			}()
+			select {
+			case err := <-read:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-ctx.Done():
+				t.Fatal("wanted to read every line")
+			}
+			if string(got) != want {
+				t.Errorf("wanted every line in order, got %q", got)
+			}
+			if err := <-written; err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCopyTruncate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailftest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := tailftest.Scenario{Path: filepath.Join(dir, "a.log"), Pattern: tailftest.CopyTruncate, Lines: 25}
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	for _, name := range []string{"a.log.1", "a.log.2", "a.log"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got += string(data)
+	}
+	if want := tailftest.Want(s.Lines); got != want {
+		t.Errorf("wanted the lines split across the copies, got %q", got)
+	}
+}
@@ -0,0 +1,222 @@
+package tailftest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+// RunScript runs script, a list of steps changing a file of backend and
+// checking what its follower reads, so that tests codify the semantics
+// they depend on in a line, such as:
+//
+//	append 10B; rotate; append 5B; expect "aaaaaaaaaabbbbb"; truncate; append "hi"; expect "hi"
+//
+// Steps are separated by semicolons or newlines, and are one of:
+//
+//	append "text"     appends text, quoted as a Go string
+//	append <n>B       appends n bytes of a letter, a for the first such
+//	                  step, b for the next and so on
+//	rotate            renames the file away and creates it again
+//	truncate          truncates the file
+//	remove            removes the file
+//	wait <duration>   waits, such as for a poll, as time.ParseDuration
+//	restart           closes the follower, and resumes from its cursor
+//	expect "text"     reads len(text) bytes, which must be text
+//	expect rotated    waits for the follower to emit a tailf.Rotated
+//	expect truncated  waits for the follower to emit a tailf.Truncated
+//
+// The file is named after the test, created empty, and followed from its
+// start before the first step. The script fails t at the first step that
+// fails, or if it can't be parsed. The follower is set up as with
+// RunBackendTests, from backend and opts.
+func RunScript(t *testing.T, backend Backend, script string, opts ...tailf.Option) {
+	t.Helper()
+	steps, err := parseScript(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &scriptRun{
+		backend: backend,
+		name:    strings.NewReplacer("/", "_", " ", "_").Replace(t.Name()) + ".log",
+		opts:    backendOptions(backend, opts),
+	}
+	backend.Append(r.name, "")
+	if err := r.follow(func() (*tailf.Follower, error) { return tailf.Follow(r.name, true, r.opts...) }); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.follower.Close() }()
+
+	for _, s := range steps {
+		if err := s.run(r); err != nil {
+			t.Fatalf("%s: %v", s.src, err)
+		}
+	}
+}
+
+// step is a step of a script, as written in it.
+type step struct {
+	src string
+	run func(*scriptRun) error
+}
+
+// scriptRun is the state of a script being run.
+type scriptRun struct {
+	backend  Backend
+	name     string
+	opts     []tailf.Option
+	follower *tailf.Follower
+	rec      *Recorder
+	appended int // how many steps appended generated data
+	rotated  int // how many times the file was rotated
+}
+
+// follow replaces the follower of r with the one open returns.
+func (r *scriptRun) follow(open func() (*tailf.Follower, error)) error {
+	follow, err := open()
+	if err != nil {
+		return err
+	}
+	r.follower, r.rec = follow, Record(follow)
+	return nil
+}
+
+// parseScript parses the steps of script.
+func parseScript(script string) ([]step, error) {
+	var steps []step
+	for _, src := range splitSteps(script) {
+		src = strings.TrimSpace(src)
+		if src == "" {
+			continue
+		}
+		cmd, arg := src, ""
+		if i := strings.IndexAny(src, " \t"); i >= 0 {
+			cmd, arg = src[:i], strings.TrimSpace(src[i+1:])
+		}
+		run, err := parseStep(cmd, arg)
+		if err != nil {
+			return nil, fmt.Errorf("tailftest: bad step %q: %v", src, err)
+		}
+		steps = append(steps, step{src: src, run: run})
+	}
+	return steps, nil
+}
+
+// splitSteps splits script at the semicolons and newlines outside of
+// quoted strings.
+func splitSteps(script string) []string {
+	var steps []string
+	start, quoted, escaped := 0, false, false
+	for i, c := range script {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case !quoted && (c == ';' || c == '\n'):
+			steps = append(steps, script[start:i])
+			start = i + 1
+		}
+	}
+	return append(steps, script[start:])
+}
+
+func parseStep(cmd, arg string) (func(*scriptRun) error, error) {
+	noArg := func(run func(*scriptRun) error) (func(*scriptRun) error, error) {
+		if arg != "" {
+			return nil, fmt.Errorf("%s takes no argument", cmd)
+		}
+		return run, nil
+	}
+
+	switch cmd {
+	case "append":
+		if strings.HasSuffix(arg, "B") {
+			n, err := strconv.Atoi(strings.TrimSuffix(arg, "B"))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("wanted a number of bytes, such as 10B")
+			}
+			return func(r *scriptRun) error {
+				letter := string(rune('a' + r.appended%26))
+				r.appended++
+				r.backend.Append(r.name, strings.Repeat(letter, n))
+				return nil
+			}, nil
+		}
+		text, err := strconv.Unquote(arg)
+		if err != nil {
+			return nil, fmt.Errorf("wanted a quoted string or a number of bytes")
+		}
+		return func(r *scriptRun) error {
+			r.backend.Append(r.name, text)
+			return nil
+		}, nil
+
+	case "rotate":
+		return noArg(func(r *scriptRun) error {
+			r.rotated++
+			r.backend.Rotate(r.name, r.name+"."+strconv.Itoa(r.rotated))
+			return nil
+		})
+
+	case "truncate":
+		return noArg(func(r *scriptRun) error {
+			r.backend.Truncate(r.name)
+			return nil
+		})
+
+	case "remove":
+		return noArg(func(r *scriptRun) error {
+			r.backend.Remove(r.name)
+			return nil
+		})
+
+	case "wait":
+		d, err := time.ParseDuration(arg)
+		if err != nil {
+			return nil, err
+		}
+		return func(r *scriptRun) error {
+			time.Sleep(d)
+			return nil
+		}, nil
+
+	case "restart":
+		return noArg(func(r *scriptRun) error {
+			c := r.follower.Cursor()
+			if err := r.follower.Close(); err != nil {
+				return err
+			}
+			return r.follow(func() (*tailf.Follower, error) { return tailf.Resume(r.name, c, r.opts...) })
+		})
+
+	case "expect":
+		var like tailf.Event
+		switch arg {
+		case "rotated":
+			like = tailf.Rotated{}
+		case "truncated":
+			like = tailf.Truncated{}
+		default:
+			want, err := strconv.Unquote(arg)
+			if err != nil {
+				return nil, fmt.Errorf("wanted a quoted string, rotated or truncated")
+			}
+			return func(r *scriptRun) error {
+				return readWant(r.follower, want)
+			}, nil
+		}
+		return func(r *scriptRun) error {
+			_, err := r.rec.WaitFor(like, backendTimeout)
+			return err
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown step")
+}
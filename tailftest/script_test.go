@@ -0,0 +1,27 @@
+package tailftest_test
+
+import (
+	"testing"
+
+	"github.com/aybabtme/tailf/tailftest"
+)
+
+func TestRunScript(t *testing.T) {
+	fsys := tailftest.NewFS()
+	for name, script := range map[string]string{
+		"rotate":   `append 10B; rotate; append 5B; expect "aaaaaaaaaabbbbb"; expect rotated`,
+		"truncate": `append "hello, world!"; expect "hello, world!"; truncate; append "hi"; expect "hi"; expect truncated`,
+		"remove":   `append "hello;\n"; expect "hello;\n"; remove; wait 10ms; append "bonjour"; expect "bonjour"`,
+		"lines": `
+			append 3B
+			expect "aaa"
+			restart
+			append 2B
+			expect "bb"
+		`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			tailftest.RunScript(t, fsys, script)
+		})
+	}
+}
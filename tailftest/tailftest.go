@@ -0,0 +1,226 @@
+// Package tailftest provides an in-memory filesystem whose files tests
+// append to, rotate, truncate and remove, so that applications built on
+// tailf can test how they consume followers without touching the disk,
+// nor waiting for changes to be polled. Its scenarios write real files
+// the way loggers and logrotate do, to reproduce how followers cope, and
+// its recorder records what followers emit, for tests to wait on. Its
+// buffers leave it to tests to tell followers when they grew, and its
+// scripts codify how followers cope with a sequence of changes. Its
+// conformance suite, RunBackendTests, checks that followers of the files
+// of other filesystems behave as they do with OS files.
+package tailftest
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+// FS is an in-memory filesystem, whose files are followed with Follow.
+// It is safe for concurrent use.
+type FS struct {
+	mu       sync.Mutex
+	files    map[string]*data
+	watchers map[string]map[*func()]struct{}
+}
+
+// data is the content of a file, shared by the names it had.
+type data struct {
+	b       []byte
+	modTime time.Time
+}
+
+// NewFS returns an empty FS.
+func NewFS() *FS {
+	return &FS{files: make(map[string]*data), watchers: make(map[string]map[*func()]struct{})}
+}
+
+// Follow follows the file of fsys called name, as tailf.Follow does for
+// files of the OS filesystem.
+func (fsys *FS) Follow(name string, fromStart bool, opts ...tailf.Option) (*tailf.Follower, error) {
+	return tailf.Follow(name, fromStart, append([]tailf.Option{tailf.WithFS(fsys)}, opts...)...)
+}
+
+// Append appends s to the file called name, creating it if it doesn't
+// exist.
+func (fsys *FS) Append(name, s string) {
+	fsys.change(func() []string {
+		d, ok := fsys.files[name]
+		if !ok {
+			d = &data{}
+			fsys.files[name] = d
+		}
+		d.b = append(d.b, s...)
+		d.modTime = time.Now()
+		return []string{name}
+	})
+}
+
+// Rotate renames the file called name to rotated, replacing it, and
+// creates an empty file called name in its place, as logrotate does.
+func (fsys *FS) Rotate(name, rotated string) {
+	fsys.change(func() []string {
+		if d, ok := fsys.files[name]; ok {
+			fsys.files[rotated] = d
+		}
+		fsys.files[name] = &data{modTime: time.Now()}
+		return []string{name, rotated}
+	})
+}
+
+// Truncate empties the file called name, if it exists.
+func (fsys *FS) Truncate(name string) {
+	fsys.change(func() []string {
+		if d, ok := fsys.files[name]; ok {
+			d.b = nil
+			d.modTime = time.Now()
+		}
+		return []string{name}
+	})
+}
+
+// Remove removes the file called name, if it exists. Its followers read
+// what was left in it, and wait for a file to be created in its place.
+func (fsys *FS) Remove(name string) {
+	fsys.change(func() []string {
+		delete(fsys.files, name)
+		return []string{name}
+	})
+}
+
+// change applies apply with fsys locked, then notifies the watchers of
+// the names it returns.
+func (fsys *FS) change(apply func() []string) {
+	fsys.mu.Lock()
+	var watchers []*func()
+	for _, name := range apply() {
+		for w := range fsys.watchers[name] {
+			watchers = append(watchers, w)
+		}
+	}
+	fsys.mu.Unlock()
+	for _, changed := range watchers {
+		(*changed)()
+	}
+}
+
+// Open opens the file called name, which implements tailf.File.
+func (fsys *FS) Open(name string) (fs.File, error) {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	d, ok := fsys.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &file{fsys: fsys, name: name, d: d}, nil
+}
+
+// Stat returns the info of the file called name.
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	d, ok := fsys.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return d.info(name), nil
+}
+
+// SameFile tells if fi1 and fi2 are the info of the same file, whatever
+// its name, as tailf.SameFileFS.
+func (fsys *FS) SameFile(fi1, fi2 fs.FileInfo) bool {
+	d1, ok1 := fi1.Sys().(*data)
+	d2, ok2 := fi2.Sys().(*data)
+	return ok1 && ok2 && d1 == d2
+}
+
+// Notify calls changed whenever the file called name changes, as
+// tailf.NotifyFS, so that its followers see changes right away.
+func (fsys *FS) Notify(name string, changed func()) (func(), error) {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	if fsys.watchers[name] == nil {
+		fsys.watchers[name] = make(map[*func()]struct{})
+	}
+	w := &changed
+	fsys.watchers[name][w] = struct{}{}
+	return func() {
+		fsys.mu.Lock()
+		defer fsys.mu.Unlock()
+		delete(fsys.watchers[name], w)
+	}, nil
+}
+
+func (d *data) info(name string) fs.FileInfo {
+	return info{name: path.Base(name), size: int64(len(d.b)), modTime: d.modTime, d: d}
+}
+
+type info struct {
+	name    string
+	size    int64
+	modTime time.Time
+	d       *data
+}
+
+func (fi info) Name() string       { return fi.name }
+func (fi info) Size() int64        { return fi.size }
+func (fi info) Mode() fs.FileMode  { return 0644 }
+func (fi info) ModTime() time.Time { return fi.modTime }
+func (fi info) IsDir() bool        { return false }
+func (fi info) Sys() interface{}   { return fi.d }
+
+// file is an open file of an FS, which keeps reading the same data once
+// it is rotated or removed, as OS files do.
+type file struct {
+	fsys *FS
+	name string
+	d    *data
+	pos  int64
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	return f.d.info(f.name), nil
+}
+
+func (f *file) ReadAt(b []byte, off int64) (int, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if off >= int64(len(f.d.b)) {
+		return 0, io.EOF
+	}
+	n := copy(b, f.d.b[off:])
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *file) Read(b []byte) (int, error) {
+	n, err := f.ReadAt(b, f.pos)
+	f.pos += int64(n)
+	if n != 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *file) Seek(off int64, whence int) (int64, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	switch whence {
+	case io.SeekCurrent:
+		off += f.pos
+	case io.SeekEnd:
+		off += int64(len(f.d.b))
+	}
+	f.pos = off
+	return off, nil
+}
+
+func (f *file) Close() error { return nil }
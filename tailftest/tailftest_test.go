@@ -0,0 +1,80 @@
+package tailftest_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+	"github.com/aybabtme/tailf/tailftest"
+)
+
+func TestFS(t *testing.T) {
+	fsys := tailftest.NewFS()
+	fsys.Append("a.log", "hello,")
+
+	follow, err := fsys.Follow("a.log", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer follow.Close()
+
+	read := func(want string) {
+		t.Helper()
+		got := make([]byte, len(want))
+		done := make(chan error, 1)
+		go func() {
+			_, err := io.ReadFull(follow, got)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("wanted to read %q", want)
+		}
+		if string(got) != want {
+			t.Errorf("wanted %q, got %q", want, got)
+		}
+	}
+	// moved on to another file, rather than back to the start of the same
+	reopened := func(rotated bool) {
+		t.Helper()
+		for {
+			select {
+			case ev := <-follow.Events():
+				switch ev.(type) {
+				case tailf.Rotated, tailf.Truncated:
+					if _, ok := ev.(tailf.Rotated); ok != rotated {
+						t.Errorf("wanted the file to be rotated: %v, got %#v", rotated, ev)
+					}
+					return
+				}
+			case <-time.After(time.Second):
+				t.Fatal("wanted the file to be reopened")
+			}
+		}
+	}
+
+	read("hello,")
+	fsys.Append("a.log", " world!")
+	read(" world!")
+
+	fsys.Append("a.log", " bye!")
+	fsys.Rotate("a.log", "a.log.1")
+	fsys.Append("a.log", "bonjour,")
+	read(" bye!bonjour,")
+	reopened(true)
+
+	fsys.Truncate("a.log")
+	fsys.Append("a.log", "hi")
+	read("hi")
+	reopened(false)
+
+	fsys.Remove("a.log")
+	fsys.Append("a.log", "hey")
+	read("hey")
+	reopened(true)
+}
@@ -0,0 +1,36 @@
+package tailf
+
+import (
+	"fmt"
+	"io"
+)
+
+// WithTee writes everything read from the follower to w as well, such as
+// to an archive or an audit log, in the order it is read, across
+// rotations and truncations. Once writing to w fails, reads return the
+// error instead of reading on, so that nothing is read that w missed,
+// but for the data whose write failed.
+func WithTee(w io.Writer) Option {
+	return func(o *options) {
+		o.tee = w
+	}
+}
+
+// tee writes data, just handed to the reader, to the writer set with
+// WithTee.
+func (f *Follower) tee(data []byte) {
+	n, err := f.opts.tee.Write(data)
+	if err == nil && n != len(data) {
+		err = io.ErrShortWrite
+	}
+	if err == nil {
+		return
+	}
+	err = fmt.Errorf("tailf: writing to tee: %w", err)
+	f.countError(err)
+	f.opts.logger.Error("tee failed", "path", f.filename, "err", err)
+	f.emit(Error{Err: err})
+	f.mu.Lock()
+	f.teeErr = err
+	f.mu.Unlock()
+}
@@ -0,0 +1,71 @@
+package tailf_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestTee(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello,"); err != nil {
+			return err
+		}
+		var tee bytes.Buffer
+		follow, err := tailf.Follow(filename, true, tailf.WithTee(&tee))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+
+		// what was left in the rotated file is teed first
+		if _, err := file.WriteString(" world!"); err != nil {
+			return err
+		}
+		if err := os.Rename(filename, filename+".1"); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filename, []byte("bonjour!"), 0644); err != nil {
+			return err
+		}
+		want := "hello, world!bonjour!"
+		got := make([]byte, len(want))
+		if _, err := io.ReadFull(follow, got); err != nil {
+			return err
+		}
+		follow.Close()
+		if string(got) != want || tee.String() != want {
+			t.Errorf("wanted to read and tee %q, read %q and teed %q", want, got, tee.String())
+		}
+		return nil
+	})
+}
+
+func TestTeeFailure(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		if _, err := file.WriteString("hello, world!"); err != nil {
+			return err
+		}
+		broken := errors.New("disk full")
+		follow, err := tailf.Follow(filename, true, tailf.WithTee(failingWriter{broken}))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		// the data that failed to be teed is read, but nothing after it
+		if n, err := follow.Read(make([]byte, 5)); n != 5 || err != nil {
+			t.Errorf("wanted to read 5 bytes, got %d, %v", n, err)
+		}
+		if _, err := follow.Read(make([]byte, 5)); !errors.Is(err, broken) {
+			t.Errorf("wanted %v, got %v", broken, err)
+		}
+		return nil
+	})
+}
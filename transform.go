@@ -0,0 +1,209 @@
+package tailf
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// transformReader applies a transform.Transformer to the data buffered in
+// a bufio.Reader. Unlike transform.Reader, it never reads past what is
+// already buffered and never remembers io.EOF, since reaching the end of
+// a followed file is only ever temporary.
+type transformReader struct {
+	t   transform.Transformer
+	r   *bufio.Reader
+	src []byte // pulled from r, not yet consumed by t
+	dst []byte // produced by t, not yet returned
+
+	// short is set when what's left in src is an incomplete input unit
+	// that t won't consume until more data is appended.
+	short bool
+}
+
+func newTransformReader(t transform.Transformer, r *bufio.Reader) *transformReader {
+	t.Reset()
+	return &transformReader{t: t, r: r}
+}
+
+// pending returns how many bytes held by the reader can be transformed
+// and read right away.
+func (tr *transformReader) pending() int {
+	if tr.short {
+		return len(tr.dst)
+	}
+	return len(tr.dst) + len(tr.src)
+}
+
+func (tr *transformReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if len(tr.dst) != 0 {
+		n := copy(p, tr.dst)
+		tr.dst = tr.dst[n:]
+		return n, nil
+	}
+
+	if err := tr.pull(); err != nil {
+		return 0, err
+	}
+	if len(tr.src) == 0 {
+		return 0, nil
+	}
+
+	nDst, nSrc, err := tr.t.Transform(p, tr.src, false)
+	tr.src = tr.src[nSrc:]
+	tr.short = err == transform.ErrShortSrc
+	switch err {
+	case nil, transform.ErrShortSrc:
+		return nDst, nil
+	case transform.ErrShortDst:
+		if nDst != 0 {
+			return nDst, nil
+		}
+		// p can't even hold a single output unit, go through dst
+		out, err := tr.transform(false)
+		if err != nil {
+			return 0, err
+		}
+		tr.dst = out
+		n := copy(p, tr.dst)
+		tr.dst = tr.dst[n:]
+		return n, nil
+	default:
+		return nDst, err
+	}
+}
+
+// pull moves whatever the bufio.Reader has buffered into src.
+func (tr *transformReader) pull() error {
+	n := tr.r.Buffered()
+	if n == 0 {
+		return nil
+	}
+	b, err := tr.r.Peek(n)
+	if err != nil {
+		return err
+	}
+	tr.src = append(tr.src, b...)
+	tr.short = false
+	_, err = tr.r.Discard(n)
+	return err
+}
+
+// transform runs all of src through the transformer. When atEOF is true,
+// partial input left at the end of src is flushed as well.
+func (tr *transformReader) transform(atEOF bool) ([]byte, error) {
+	out := make([]byte, 0, len(tr.src)+utf8Max)
+	buf := make([]byte, len(tr.src)*2+utf8Max)
+	for {
+		nDst, nSrc, err := tr.t.Transform(buf, tr.src, atEOF)
+		out = append(out, buf[:nDst]...)
+		tr.src = tr.src[nSrc:]
+		tr.short = err == transform.ErrShortSrc
+		switch err {
+		case nil:
+			return out, nil
+		case transform.ErrShortSrc:
+			if !atEOF {
+				return out, nil
+			}
+			if nDst == 0 && nSrc == 0 {
+				// the transformer won't make progress on these bytes
+				return out, err
+			}
+		case transform.ErrShortDst:
+			if nDst == 0 {
+				buf = make([]byte, len(buf)*2)
+			}
+		default:
+			return out, err
+		}
+	}
+}
+
+// flush transforms everything left in the reader, including partial
+// input, and returns the result. It is used when the file generation
+// being transformed has been rotated away.
+func (tr *transformReader) flush() ([]byte, error) {
+	if err := tr.pull(); err != nil {
+		return nil, err
+	}
+	out, err := tr.transform(true)
+	out = append(tr.dst, out...)
+	tr.dst = nil
+	return out, err
+}
+
+const utf8Max = 4
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// decoderFor picks the transformer decoding file to UTF-8. The byte order
+// mark is read from the start of the file, so it is detected even when
+// following begins at the end of the file.
+func (f *Follower) decoderFor(file File) transform.Transformer {
+	if !f.opts.decoding {
+		return nil
+	}
+
+	head := make([]byte, len(bomUTF8))
+	n, _ := file.ReadAt(head, 0)
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		return unicode.UTF8BOM.NewDecoder()
+	case bytes.HasPrefix(head, bomUTF16LE):
+		return unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()
+	case bytes.HasPrefix(head, bomUTF16BE):
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder()
+	case f.opts.encoding != nil:
+		return f.opts.encoding.NewDecoder()
+	}
+	return nil
+}
+
+// transformerFor returns the chain of transformers to apply to file, or
+// nil if the data is to be delivered as is.
+func (f *Follower) transformerFor(file File) transform.Transformer {
+	var chain []transform.Transformer
+	if dec := f.decoderFor(file); dec != nil {
+		chain = append(chain, dec)
+	}
+	if bin := f.binaryTransformer(); bin != nil {
+		chain = append(chain, bin)
+	}
+	for _, newT := range f.opts.transforms {
+		chain = append(chain, newT())
+	}
+
+	switch len(chain) {
+	case 0:
+		return nil
+	case 1:
+		return chain[0]
+	default:
+		return transform.Chain(chain...)
+	}
+}
+
+// newSource returns the reader delivering data from the current file
+// generation, with the configured transforms applied.
+func (f *Follower) newSource() io.Reader {
+	t := f.transformerFor(f.file)
+	if t == nil {
+		f.transformer = nil
+		return f.fileReader
+	}
+	f.transformer = newTransformReader(t, f.fileReader)
+	return f.transformer
+}
@@ -0,0 +1,142 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/aybabtme/tailf"
+	xunicode "golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+)
+
+func TestCanDecodeUTF16(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		enc := xunicode.UTF16(xunicode.LittleEndian, xunicode.UseBOM).NewEncoder()
+
+		head, err := enc.String("hello,")
+		if err != nil {
+			return err
+		}
+		if _, err := file.WriteString(head); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, false, tailf.WithDecoding(nil))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		tail, err := xunicode.UTF16(xunicode.LittleEndian, xunicode.IgnoreBOM).NewEncoder().String(" wörld!")
+		if err != nil {
+			return err
+		}
+		if _, err := file.WriteString(tail); err != nil {
+			return err
+		}
+
+		want := " wörld!"
+		data := make([]byte, len(want))
+		if _, err := io.ReadAtLeast(follow, data, len(want)); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
+
+func TestTransformIsReappliedAfterRotation(t *testing.T) {
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		upper := func() transform.Transformer { return runes.Map(unicode.ToUpper) }
+
+		follow, err := tailf.Follow(filename, true, tailf.WithTransform(upper))
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+		defer follow.Close()
+
+		if _, err := file.WriteString("hello,"); err != nil {
+			return err
+		}
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+		file, err = os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := file.WriteString(" world!"); err != nil {
+			return err
+		}
+
+		want := "HELLO, WORLD!"
+		data := make([]byte, len(want))
+		if _, err := io.ReadAtLeast(follow, data, len(want)); err != nil {
+			return err
+		}
+		if got := string(data); got != want {
+			t.Errorf("wanted '%v', got '%v'", want, got)
+		}
+		return nil
+	})
+}
+
+func TestTransformPerFollower(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_transform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	enc := xunicode.UTF16(xunicode.LittleEndian, xunicode.IgnoreBOM)
+	want := map[string]string{}
+	for _, name := range []string{"a.log", "b.log"} {
+		path := filepath.Join(dir, name)
+		want[path] = strings.Repeat("hello from "+name+"\n", 1000)
+		data, err := enc.NewEncoder().String(want[path])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the followers of the set each decode with a transformer of their own
+	set := tailf.NewFollowerSet(tailf.WithTransform(func() transform.Transformer {
+		return enc.NewDecoder()
+	}))
+	defer set.Close()
+	errs := make(chan error, len(want))
+	for path, text := range want {
+		follow, err := set.Add(path, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func(path, text string) {
+			data := make([]byte, len(text))
+			if _, err := io.ReadFull(follow, data); err != nil {
+				errs <- err
+			} else if string(data) != text {
+				errs <- fmt.Errorf("%s decoded wrong: %.40q...", path, data)
+			} else {
+				errs <- nil
+			}
+		}(path, text)
+	}
+	for range want {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
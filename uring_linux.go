@@ -0,0 +1,241 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package tailf
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+var errURingClosed = errors.New("io_uring backend was closed")
+
+const (
+	sysIOURingSetup = 425
+	sysIOURingEnter = 426
+
+	iouringOffSQRing = 0
+	iouringOffCQRing = 0x8000000
+	iouringOffSQEs   = 0x10000000
+
+	iouringOpRead        = 22
+	iouringEnterGetEvent = 1
+
+	iouringSQESize = 64
+	iouringCQESize = 16
+)
+
+// iouringParams is struct io_uring_params.
+type iouringParams struct {
+	sqEntries    uint32
+	cqEntries    uint32
+	flags        uint32
+	sqThreadCPU  uint32
+	sqThreadIdle uint32
+	features     uint32
+	wqFD         uint32
+	resv         [3]uint32
+	sqOff        struct{ head, tail, ringMask, ringEntries, flags, dropped, array, resv1, resv2, resv3 uint32 }
+	cqOff        struct{ head, tail, ringMask, ringEntries, overflow, cqes, flags, resv1, resv2, resv3 uint32 }
+}
+
+// URing is an experimental read backend issuing the reads of the
+// followers sharing it through io_uring. Reads issued at the same time by
+// different followers are submitted together, which saves syscalls when
+// following many busy files.
+type URing struct {
+	fd      int
+	entries uint32
+
+	sqRing, cqRing, sqes []byte
+	sqTail, sqMask       *uint32
+	sqArray              []uint32
+	cqHead, cqTail       *uint32
+	cqMask               *uint32
+	cqes                 []byte
+
+	reads chan *uringRead
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+type uringRead struct {
+	fd   int
+	b    []byte
+	off  int64
+	n    int
+	err  error
+	done chan struct{}
+}
+
+// NewURing sets up an io_uring with room for entries reads submitted at
+// once.
+func NewURing(entries uint) (*URing, error) {
+	var p iouringParams
+	fd, _, errno := syscall.Syscall(sysIOURingSetup, uintptr(entries), uintptr(unsafe.Pointer(&p)), 0)
+	if errno != 0 {
+		return nil, os.NewSyscallError("io_uring_setup", errno)
+	}
+	u := &URing{
+		fd:      int(fd),
+		entries: p.sqEntries,
+		reads:   make(chan *uringRead),
+		done:    make(chan struct{}),
+	}
+	if err := u.mmap(&p); err != nil {
+		u.unmap()
+		_ = syscall.Close(u.fd)
+		return nil, err
+	}
+	u.wg.Add(1)
+	go u.run()
+	return u, nil
+}
+
+func (u *URing) mmap(p *iouringParams) error {
+	var err error
+	prot, flags := syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE
+	sqSize := int(p.sqOff.array + p.sqEntries*4)
+	if u.sqRing, err = syscall.Mmap(u.fd, iouringOffSQRing, sqSize, prot, flags); err != nil {
+		return os.NewSyscallError("mmap", err)
+	}
+	cqSize := int(p.cqOff.cqes + p.cqEntries*iouringCQESize)
+	if u.cqRing, err = syscall.Mmap(u.fd, iouringOffCQRing, cqSize, prot, flags); err != nil {
+		return os.NewSyscallError("mmap", err)
+	}
+	if u.sqes, err = syscall.Mmap(u.fd, iouringOffSQEs, int(p.sqEntries*iouringSQESize), prot, flags); err != nil {
+		return os.NewSyscallError("mmap", err)
+	}
+
+	u.sqTail = (*uint32)(unsafe.Pointer(&u.sqRing[p.sqOff.tail]))
+	u.sqMask = (*uint32)(unsafe.Pointer(&u.sqRing[p.sqOff.ringMask]))
+	u.sqArray = (*[1 << 28]uint32)(unsafe.Pointer(&u.sqRing[p.sqOff.array]))[:p.sqEntries:p.sqEntries]
+	u.cqHead = (*uint32)(unsafe.Pointer(&u.cqRing[p.cqOff.head]))
+	u.cqTail = (*uint32)(unsafe.Pointer(&u.cqRing[p.cqOff.tail]))
+	u.cqMask = (*uint32)(unsafe.Pointer(&u.cqRing[p.cqOff.ringMask]))
+	u.cqes = u.cqRing[p.cqOff.cqes:]
+	return nil
+}
+
+func (u *URing) unmap() {
+	for _, b := range [][]byte{u.sqRing, u.cqRing, u.sqes} {
+		if b != nil {
+			_ = syscall.Munmap(b)
+		}
+	}
+}
+
+// Close waits for the reads in flight and tears down the io_uring. The
+// followers using it fail on their next read.
+func (u *URing) Close() error {
+	close(u.done)
+	u.wg.Wait()
+	u.unmap()
+	return syscall.Close(u.fd)
+}
+
+// pread reads from file at offset off into b, like a positionReader
+// reading the file would.
+func (u *URing) pread(file *os.File, b []byte, off int64) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	r := &uringRead{fd: int(file.Fd()), b: b, off: off, done: make(chan struct{})}
+	select {
+	case u.reads <- r:
+	case <-u.done:
+		return 0, errURingClosed
+	}
+	<-r.done
+	if r.err != nil {
+		return 0, &os.PathError{Op: "read", Path: file.Name(), Err: r.err}
+	}
+	if r.n == 0 {
+		return 0, io.EOF
+	}
+	return r.n, nil
+}
+
+func (u *URing) run() {
+	defer u.wg.Done()
+	for {
+		var batch []*uringRead
+		select {
+		case <-u.done:
+			return
+		case r := <-u.reads:
+			batch = append(batch, r)
+		}
+		// take along the reads issued in the meantime
+	gather:
+		for len(batch) < int(u.entries) {
+			select {
+			case r := <-u.reads:
+				batch = append(batch, r)
+			default:
+				break gather
+			}
+		}
+		u.submit(batch)
+		for _, r := range batch {
+			close(r.done)
+		}
+	}
+}
+
+// submit queues the reads of batch and waits for all of them to complete.
+func (u *URing) submit(batch []*uringRead) {
+	tail := *u.sqTail
+	for i, r := range batch {
+		idx := tail & *u.sqMask
+		sqe := u.sqes[idx*iouringSQESize : (idx+1)*iouringSQESize]
+		for j := range sqe {
+			sqe[j] = 0
+		}
+		sqe[0] = iouringOpRead
+		*(*int32)(unsafe.Pointer(&sqe[4])) = int32(r.fd)
+		*(*uint64)(unsafe.Pointer(&sqe[8])) = uint64(r.off)
+		*(*uint64)(unsafe.Pointer(&sqe[16])) = uint64(uintptr(unsafe.Pointer(&r.b[0])))
+		*(*uint32)(unsafe.Pointer(&sqe[24])) = uint32(len(r.b))
+		*(*uint64)(unsafe.Pointer(&sqe[32])) = uint64(i)
+		u.sqArray[idx] = idx
+		tail++
+	}
+	atomic.StoreUint32(u.sqTail, tail)
+
+	toSubmit, left := len(batch), len(batch)
+	for left > 0 {
+		_, _, errno := syscall.Syscall6(sysIOURingEnter, uintptr(u.fd), uintptr(toSubmit), uintptr(left), iouringEnterGetEvent, 0, 0)
+		if errno == syscall.EINTR {
+			continue
+		}
+		if errno != 0 {
+			for _, r := range batch {
+				if r.err == nil && r.n == 0 {
+					r.err = errno
+				}
+			}
+			return
+		}
+		toSubmit = 0
+
+		head, cqTail := *u.cqHead, atomic.LoadUint32(u.cqTail)
+		for ; head != cqTail; head++ {
+			cqe := u.cqes[(head&*u.cqMask)*iouringCQESize:]
+			r := batch[*(*uint64)(unsafe.Pointer(&cqe[0]))]
+			if res := *(*int32)(unsafe.Pointer(&cqe[8])); res < 0 {
+				r.err = syscall.Errno(-res)
+			} else {
+				r.n = int(res)
+			}
+			left--
+		}
+		atomic.StoreUint32(u.cqHead, head)
+	}
+}
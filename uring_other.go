@@ -0,0 +1,30 @@
+//go:build !linux || !(amd64 || arm64)
+// +build !linux !amd64,!arm64
+
+package tailf
+
+import (
+	"errors"
+	"os"
+)
+
+var errURingUnsupported = errors.New("io_uring backend is only available on Linux amd64 and arm64")
+
+// URing is an experimental read backend issuing the reads of the
+// followers sharing it through io_uring, which is only available on
+// Linux.
+type URing struct{}
+
+// NewURing fails, since io_uring isn't available on this platform.
+func NewURing(entries uint) (*URing, error) {
+	return nil, errURingUnsupported
+}
+
+// Close does nothing.
+func (u *URing) Close() error {
+	return nil
+}
+
+func (u *URing) pread(file *os.File, b []byte, off int64) (int, error) {
+	return 0, errURingUnsupported
+}
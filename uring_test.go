@@ -0,0 +1,43 @@
+package tailf_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestCanFollowThroughURing(t *testing.T) {
+	ring, err := tailf.NewURing(64)
+	if err != nil {
+		t.Skipf("io_uring isn't available: %v", err)
+	}
+	defer ring.Close()
+
+	withTempFile(t, time.Millisecond*150, func(t *testing.T, filename string, file *os.File) error {
+		want := "hello, world!\n"
+		if _, err := file.WriteString(want); err != nil {
+			return err
+		}
+
+		for i := 0; i < 2; i++ {
+			follow, err := tailf.Follow(filename, true, tailf.WithURing(ring))
+			if err != nil {
+				return fmt.Errorf("failed creating tailf.follower: %v", err)
+			}
+			defer follow.Close()
+
+			data := make([]byte, len(want))
+			if _, err := io.ReadFull(follow, data); err != nil {
+				return err
+			}
+			if got := string(data); got != want {
+				t.Errorf("wanted '%v', got '%v'", want, got)
+			}
+		}
+		return nil
+	})
+}
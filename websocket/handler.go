@@ -0,0 +1,184 @@
+// Package tailfwebsocket streams the lines written to a file to WebSocket
+// clients, such as browsers.
+package tailfwebsocket
+
+import (
+	"bufio"
+	"net/http"
+	"regexp"
+	"sync"
+
+	"github.com/aybabtme/tailf"
+	"github.com/gorilla/websocket"
+)
+
+// Message is what is sent to clients, as JSON: either a line, or how many
+// lines were dropped because the client didn't keep up.
+type Message struct {
+	Line    string `json:"line,omitempty"`
+	Dropped int64  `json:"dropped,omitempty"`
+}
+
+const (
+	defaultBuffer = 1024
+	maxLine       = 1024 * 1024
+)
+
+// Handler is an http.Handler following a file for each WebSocket client,
+// and sending them the lines written to it from then on. Clients can ask
+// for the lines matching a regular expression only, with the filter query
+// parameter.
+type Handler struct {
+	// Filename is the file to follow.
+	Filename string
+	// LinesPerSec caps how many lines are read for each client, every
+	// second. 0 means no limit.
+	LinesPerSec float64
+	// Buffer is how many lines are queued for a client that is slower
+	// than the file is written to. Once the queue is full, the oldest
+	// lines are dropped. 0 means 1024 lines.
+	Buffer int
+	// Options configure the followers.
+	Options []tailf.Option
+	// Upgrader upgrades the requests to WebSocket connections.
+	Upgrader websocket.Upgrader
+	// Authorize, if not nil, is called for each request before following
+	// the file. Requests for which it returns an error are denied. Over
+	// TLS, r.TLS holds the certificates the client presented.
+	Authorize func(r *http.Request) error
+}
+
+// ServeHTTP implements http.Handler.
+//
+// TLS is set up on the http.Server serving the handler, for instance
+// with a configuration from tailfnet.ServerTLSConfig.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Authorize != nil {
+		if err := h.Authorize(r); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	var filter *regexp.Regexp
+	if expr := r.URL.Query().Get("filter"); expr != "" {
+		var err error
+		if filter, err = regexp.Compile(expr); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	opts := h.Options
+	if h.LinesPerSec > 0 {
+		opts = append(opts[:len(opts):len(opts)], tailf.WithRateLimit(tailf.NewRateLimiter(0, h.LinesPerSec)))
+	}
+	follow, err := tailf.Follow(h.Filename, false, opts...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer follow.Close()
+
+	conn, err := h.Upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader replied with the error
+		return
+	}
+	defer conn.Close()
+
+	// reading is needed to notice the client going away
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				follow.Close()
+				return
+			}
+		}
+	}()
+
+	buffer := h.Buffer
+	if buffer <= 0 {
+		buffer = defaultBuffer
+	}
+	q := newQueue(buffer)
+	go func() {
+		defer q.close()
+		lines := bufio.NewScanner(follow)
+		lines.Buffer(nil, maxLine)
+		for lines.Scan() {
+			if filter == nil || filter.Match(lines.Bytes()) {
+				q.push(lines.Text())
+			}
+		}
+	}()
+
+	for {
+		lines, dropped, ok := q.pop()
+		if !ok {
+			return
+		}
+		if dropped != 0 {
+			if err := conn.WriteJSON(Message{Dropped: dropped}); err != nil {
+				return
+			}
+		}
+		for _, line := range lines {
+			if err := conn.WriteJSON(Message{Line: line}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// queue holds the lines waiting to be sent to a client, dropping the
+// oldest ones once it is full.
+type queue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	lines   []string
+	max     int
+	dropped int64
+	closed  bool
+}
+
+func newQueue(max int) *queue {
+	q := &queue{max: max}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *queue) push(line string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.lines) == q.max {
+		q.lines = q.lines[1:]
+		q.dropped++
+	}
+	q.lines = append(q.lines, line)
+	q.cond.Signal()
+}
+
+// pop waits for lines to be queued, and returns them along with how many
+// were dropped since the last call. It returns false once the queue is
+// closed and empty.
+func (q *queue) pop() ([]string, int64, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.lines) == 0 && q.dropped == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.lines) == 0 && q.dropped == 0 {
+		return nil, 0, false
+	}
+	lines, dropped := q.lines, q.dropped
+	q.lines, q.dropped = nil, 0
+	return lines, dropped, true
+}
+
+func (q *queue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Signal()
+}
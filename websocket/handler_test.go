@@ -0,0 +1,60 @@
+package tailfwebsocket_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tailfwebsocket "github.com/aybabtme/tailf/websocket"
+	"github.com/gorilla/websocket"
+)
+
+func TestHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tailf_websocket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "a.log")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	srv := httptest.NewServer(&tailfwebsocket.Handler{Filename: filename})
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url+"?filter=(", nil); err == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("wanted an invalid filter to be rejected, got %v", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?filter=^error", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// the file is followed from its end by the time the connection is
+	// upgraded
+	if _, err := file.WriteString("error: 1\ninfo: 2\nerror: 3\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for _, want := range []string{"error: 1", "error: 3"} {
+		var msg tailfwebsocket.Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Line != want {
+			t.Errorf("wanted line %q, got %+v", want, msg)
+		}
+	}
+}
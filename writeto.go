@@ -0,0 +1,146 @@
+package tailf
+
+import (
+	"io"
+	"net"
+)
+
+// maxSendfile is how much is sent at once with sendfile.
+const maxSendfile = 1 << 20
+
+// WriteTo writes the followed data to w until the follower is closed and
+// everything it buffered was written, or reading or writing fails. This
+// is what io.Copy(w, f) uses.
+//
+// When the follower doesn't need to look at the data, the data already on
+// disk isn't copied through userspace: it is sent with sendfile(2) when w
+// is a *net.TCPConn and the platform supports it, or, on Linux, spliced to
+// w when it is backed by another file descriptor, such as a pipe, and
+// accepts it. Such data is counted by Stats in bytes, but not in lines.
+// Otherwise, as for files opened to append to, the data is copied through
+// large pooled buffers, which are read into straight from the file when
+// nothing is buffered.
+func (f *Follower) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	conn, _ := w.(*net.TCPConn)
+	var sp *splicer
+	if conn == nil {
+		sp = newSplicer(w)
+	}
+	if sp != nil {
+		defer sp.close()
+	}
+
+	// buffers at least as large as the read buffer are read into directly
+	pbuf := getCopyBuffer(f.opts.bufferSize)
+	defer putCopyBuffer(pbuf)
+	buf := *pbuf
+	for {
+		if conn != nil {
+			n, err := f.sendfile(conn)
+			written += n
+			if err != nil {
+				return written, err
+			}
+			if n != 0 {
+				continue
+			}
+		}
+		if sp != nil {
+			n, ok, err := sp.splice(f)
+			written += n
+			if err != nil {
+				return written, err
+			}
+			if !ok {
+				// splicing isn't supported for this file or destination
+				sp.close()
+				sp = nil
+			}
+			if n != 0 {
+				continue
+			}
+		}
+
+		n, err := f.Read(buf)
+		if n != 0 {
+			m, werr := w.Write(buf[:n])
+			written += int64(m)
+			if werr != nil {
+				return written, werr
+			}
+			if m != n {
+				return written, io.ErrShortWrite
+			}
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// sendfile sends what is on disk past the read position to conn, up to
+// maxSendfile bytes. net.TCPConn.ReadFrom uses sendfile(2) when reading
+// from a file, where the platform supports it.
+func (f *Follower) sendfile(conn *net.TCPConn) (int64, error) {
+	// the follower isn't locked while sending, so that a slow peer
+	// doesn't hold up Close or Stats, but what else moves the offset of
+	// the file waits
+	f.sendmu.Lock()
+	defer f.sendmu.Unlock()
+	f.mu.Lock()
+	if !f.spliceable() {
+		f.mu.Unlock()
+		return 0, nil
+	}
+	fi, err := f.file.Stat()
+	if err != nil {
+		// let regular reads report the error
+		f.mu.Unlock()
+		return 0, nil
+	}
+	left := fi.Size() - f.position.pos
+	if left <= 0 {
+		f.mu.Unlock()
+		return 0, nil
+	}
+	if left > maxSendfile {
+		left = maxSendfile
+	}
+	if f.prev != nil {
+		f.crossRotation()
+	}
+	file := f.file
+	f.mu.Unlock()
+	f.emitPending()
+
+	n, err := conn.ReadFrom(io.LimitReader(file, left))
+	f.mu.Lock()
+	if f.released {
+		f.final.Offset += n
+	} else {
+		f.position.pos += n
+		f.dropBehind()
+	}
+	closed := f.closed
+	f.mu.Unlock()
+	f.countBytes(n, 0, 0)
+	if err != nil && closed {
+		// the file was closed while sending, reads report the end
+		return n, nil
+	}
+	return n, err
+}
+
+// spliceable tells if the data to read next can be moved straight from
+// the file, because nothing is buffered and the follower doesn't need to
+// look at it.
+func (f *Follower) spliceable() bool {
+	return !f.released && f.osFile() != nil && f.transformer == nil && f.buffered() == 0 && len(f.ahead) == 0 &&
+		f.position.mapped == nil && f.position.ring == nil && !f.position.bounded &&
+		f.opts.checkpoints == (CheckpointPolicy{}) && f.opts.stop == (StopPolicy{}) && f.opts.limiter == nil &&
+		f.opts.tee == nil && f.history == nil && f.opts.observer == nil && !f.opts.eventStream && !f.opts.dropOldest
+}
@@ -0,0 +1,240 @@
+package tailf_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aybabtme/tailf"
+)
+
+func TestWriteToPipe(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		want := make([]byte, 1<<20)
+		for i := range want {
+			want[i] = byte('a' + rand.Intn(26))
+		}
+		if _, err := file.Write(want); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+
+		r, w, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		copied := make(chan error, 1)
+		go func() {
+			_, err := io.Copy(w, follow)
+			w.Close()
+			copied <- err
+		}()
+
+		got, err := ioutil.ReadAll(io.LimitReader(r, int64(len(want))))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(want, got) {
+			t.Errorf("data copied differs from data written")
+		}
+		if off := follow.Cursor().Offset; off != int64(len(want)) {
+			t.Errorf("wanted cursor at %d, got %d", len(want), off)
+		}
+
+		if err := follow.Close(); err != nil {
+			return err
+		}
+		if err := <-copied; err != nil {
+			return err
+		}
+		// counted once sent, which the reader might see first
+		if n := follow.Stats().Bytes; n != int64(len(want)) {
+			t.Errorf("wanted %d bytes counted, got %d", len(want), n)
+		}
+		return nil
+	})
+}
+
+func TestWriteToAppendFile(t *testing.T) {
+	withTempFile(t, 2*time.Second, func(t *testing.T, filename string, file *os.File) error {
+		want := make([]byte, 1<<20)
+		for i := range want {
+			want[i] = byte('a' + rand.Intn(26))
+		}
+		if _, err := file.Write(want); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+
+		// files opened to append to can't be spliced to
+		out := filename + ".out"
+		if err := ioutil.WriteFile(out, []byte("start\n"), 0644); err != nil {
+			return err
+		}
+		w, err := os.OpenFile(out, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+
+		copied := make(chan error, 1)
+		go func() {
+			_, err := io.Copy(w, follow)
+			copied <- err
+		}()
+		for {
+			fi, err := os.Stat(out)
+			if err != nil {
+				return err
+			}
+			if fi.Size() >= int64(len("start\n")+len(want)) {
+				break
+			}
+			select {
+			case err := <-copied:
+				return fmt.Errorf("copying stopped early: %v", err)
+			case <-time.After(time.Millisecond):
+			}
+		}
+		if err := follow.Close(); err != nil {
+			return err
+		}
+		if err := <-copied; err != nil {
+			return err
+		}
+
+		got, err := ioutil.ReadFile(out)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(append([]byte("start\n"), want...), got) {
+			t.Errorf("data appended differs from data written")
+		}
+		if off := follow.Cursor().Offset; off != int64(len(want)) {
+			t.Errorf("wanted cursor at %d, got %d", len(want), off)
+		}
+		return nil
+	})
+}
+
+func TestWriteToTCPConn(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		want := make([]byte, 3<<20)
+		for i := range want {
+			want[i] = byte('a' + rand.Intn(26))
+		}
+		if _, err := file.Write(want); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return err
+		}
+		defer l.Close()
+
+		copied := make(chan error, 1)
+		go func() {
+			conn, err := net.Dial("tcp", l.Addr().String())
+			if err != nil {
+				copied <- err
+				return
+			}
+			defer conn.Close()
+			_, err = io.Copy(conn, follow)
+			copied <- err
+		}()
+
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		got, err := ioutil.ReadAll(io.LimitReader(conn, int64(len(want))))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(want, got) {
+			t.Errorf("data sent differs from data written")
+		}
+
+		if err := follow.Close(); err != nil {
+			return err
+		}
+		if err := <-copied; err != nil {
+			return err
+		}
+		// counted once sent, which the peer might see first
+		if n := follow.Stats().Bytes; n != int64(len(want)) {
+			t.Errorf("wanted %d bytes counted, got %d", len(want), n)
+		}
+		return nil
+	})
+}
+
+func TestWriteToWriter(t *testing.T) {
+	withTempFile(t, time.Second, func(t *testing.T, filename string, file *os.File) error {
+		want := make([]byte, 2<<20)
+		for i := range want {
+			want[i] = byte('a' + rand.Intn(26))
+		}
+		half := len(want) / 2
+		if _, err := file.Write(want[:half]); err != nil {
+			return err
+		}
+
+		follow, err := tailf.Follow(filename, true)
+		if err != nil {
+			return fmt.Errorf("failed creating tailf.follower: %v", err)
+		}
+
+		// an io.PipeWriter has no file descriptor to splice to
+		r, w := io.Pipe()
+		copied := make(chan error, 1)
+		go func() {
+			_, err := io.Copy(w, follow)
+			w.Close()
+			copied <- err
+		}()
+
+		if _, err := file.Write(want[half:]); err != nil {
+			return err
+		}
+
+		got, err := ioutil.ReadAll(io.LimitReader(r, int64(len(want))))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(want, got) {
+			t.Errorf("data copied differs from data written")
+		}
+
+		if err := follow.Close(); err != nil {
+			return err
+		}
+		go ioutil.ReadAll(r)
+		return <-copied
+	})
+}